@@ -1,23 +1,69 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
 	"github.com/stellar/sep-reference/reference/go/internal/config"
 	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/keyring"
 	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/observer"
+	"github.com/stellar/sep-reference/reference/go/internal/submitter"
+	"github.com/stellar/sep-reference/reference/go/platform"
 	"github.com/stellar/sep-reference/reference/go/sep1"
 	"github.com/stellar/sep-reference/reference/go/sep10"
+	"github.com/stellar/sep-reference/reference/go/sep12"
 	"github.com/stellar/sep-reference/reference/go/sep24"
+	"github.com/stellar/sep-reference/reference/go/sep31"
+	"github.com/stellar/sep-reference/reference/go/sep38"
+	"github.com/stellar/sep-reference/reference/go/sep6"
 )
 
 func main() {
 	cfg := config.Load()
 
-	txStore := db.NewMemoryTransactionStore()
-	customerStore := db.NewMemoryCustomerStore()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		if err := reencrypt(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opened, err := openStores(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer opened.close()
+	txStore, customerStore, quoteStore, blobStore := opened.transactions, opened.customers, opened.quotes, opened.blobs
+	if cfg.CustomerKeyringFile != "" {
+		keys, err := keyring.LoadFile(cfg.CustomerKeyringFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("load customer keyring: %w", err))
+		}
+		customerStore = db.NewEncryptedCustomerStore(customerStore, keys)
+		blobStore = db.NewEncryptedBlobStore(blobStore, keys)
+		log.Printf("Encrypting customer fields under key %s", keys.PrimaryKeyID())
+	}
 
 	authService := sep10.NewService(
 		cfg.ServerAccount,
@@ -30,7 +76,73 @@ func main() {
 		cfg.TokenTTL,
 	)
 
+	sep12Service := sep12.NewService(cfg, customerStore, blobStore)
+	if cfg.SEP9FieldsFile != "" {
+		fields, err := sep12.LoadFields(cfg.SEP9FieldsFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("load SEP-9 fields: %w", err))
+		}
+		sep12Service.Fields = fields
+	}
 	sep24Service := sep24.NewService(cfg, txStore, customerStore)
+	sep24Service.Quotes = quoteStore
+	// SEP-6 withdrawals and SEP-31 receives share the withdraw memo space so a
+	// memo on an incoming payment identifies exactly one transaction.
+	sep6Service := sep6.NewService(cfg, txStore, sep12Service)
+	sep6Service.Quotes = quoteStore
+	sep6Service.Memos = sep24Service.Memos
+	sep31Service := sep31.NewService(cfg, txStore, customerStore)
+	sep31Service.Quotes = quoteStore
+	sep31Service.Memos = sep24Service.Memos
+	var deadLetters callback.DeadLetterStore = callback.NewMemoryDeadLetters()
+	if cfg.CallbackDeadLetters != "" {
+		deadLetters = callback.NewFileDeadLetters(cfg.CallbackDeadLetters)
+	}
+	callbacks, err := callback.NewDispatcher(cfg.SigningKey, deadLetters)
+	if err != nil {
+		log.Fatal(err)
+	}
+	callbacks.MaxAttempts = cfg.CallbackMaxAttempts
+	callbacks.BaseDelay = cfg.CallbackRetryDelay
+	sep12Service.Callbacks = callbacks
+	sep24Service.Callbacks = callbacks
+	sep31Service.Callbacks = callbacks
+	var publisher *events.Publisher
+	if cfg.EventsWebhookURL != "" {
+		if cfg.EventsWebhookSecret == "" {
+			log.Fatal("EVENTS_WEBHOOK_URL is set without EVENTS_WEBHOOK_SECRET; events would be signed with an empty key")
+		}
+		var outbox events.Outbox = events.NewMemoryOutbox()
+		if cfg.EventsOutboxFile != "" {
+			fileOutbox, err := events.NewFileOutbox(cfg.EventsOutboxFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			outbox = fileOutbox
+		}
+		publisher = events.NewPublisher(cfg.EventsWebhookURL, cfg.EventsWebhookSecret, outbox)
+		publisher.Retention = cfg.EventsRetention
+		sep12Service.Events = publisher
+		sep24Service.Events = publisher
+	}
+	platformService := platform.NewService(cfg, txStore)
+	platformService.Deposits = sep24Service
+	platformService.QueuePayouts = cfg.DepositPayouts == config.PayoutsWorker
+	sep24Service.PlatformPayouts = cfg.DepositPayouts == config.PayoutsPlatform
+	platformService.Notifier = platform.Notifiers{sep24Service, sep31Service}
+	sep38Service := sep38.NewService(cfg, quoteStore, sep38.NewStaticRateSource(cfg.QuoteRates))
+	sep38Service.Events = publisher
+	if cfg.FeeRulesFile != "" {
+		rules, err := fees.LoadRules(cfg.FeeRulesFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("load fee rules: %w", err))
+		}
+		sep24Service.Fees = fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, rules)
+		sep6Service.Fees = sep24Service.Fees
+		sep31Service.Fees = sep24Service.Fees
+		sep38Service.Fees = sep24Service.Fees
+		platformService.Fees = sep24Service.Fees
+	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/auth", sep10.NewHTTPHandler(authService))
@@ -43,15 +155,183 @@ func main() {
 		_, _ = w.Write([]byte("ok"))
 	})
 
+	sep6Service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	sep12Service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
 	sep24Service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	sep31Service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	sep38Service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	if cfg.AdminAPIKey != "" {
+		sep12Service.RegisterAdminRoutes(mux, middleware.AdminAuth(cfg.AdminAPIKey))
+		sep24Service.RegisterAdminRoutes(mux, middleware.AdminAuth(cfg.AdminAPIKey))
+		platformService.RegisterRoutes(mux, middleware.AdminAuth(cfg.AdminAPIKey))
+		if publisher != nil {
+			publisher.RegisterAdminRoutes(mux, middleware.AdminAuth(cfg.AdminAPIKey))
+		}
+	}
+
+	var workers sync.WaitGroup
+	runWorker := func(run func()) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run()
+		}()
+	}
+
+	runWorker(func() { callbacks.Run(ctx) })
+	if publisher != nil {
+		runWorker(func() { publisher.Run(ctx) })
+		log.Printf("Publishing events to %s", cfg.EventsWebhookURL)
+	}
+	runWorker(func() { sep24Service.RunExpirySweeper(ctx, cfg.ExpirySweepInterval) })
+
+	if cfg.HorizonURL != "" {
+		var cursors observer.CursorStore = observer.NewMemoryCursorStore()
+		if cfg.ObserverCursorFile != "" {
+			cursors = observer.NewFileCursorStore(cfg.ObserverCursorFile)
+		}
+		paymentObserver := observer.New("sep24", cfg.DistributionAccount, observer.NewHorizonSource(cfg.HorizonURL), cursors, observer.Handlers{sep24Service, sep31Service})
+		runWorker(func() {
+			if err := paymentObserver.Run(ctx); err != nil {
+				log.Printf("payment observer stopped: %v", err)
+			}
+		})
+		log.Printf("Observing payments to %s via %s", cfg.DistributionAccount, cfg.HorizonURL)
+
+		sep24Service.Payments = submitter.New(submitter.NewHorizonLedger(cfg.HorizonURL), cfg.DistributionSeed, cfg.NetworkPassphrase)
+		runWorker(func() { sep24Service.RunDepositWorker(ctx, cfg.PaymentPollInterval) })
+	}
 
 	log.Printf("SEP Reference server starting")
 	log.Printf("SEP-1:  http://%s/.well-known/stellar.toml", cfg.HomeDomain)
+	log.Printf("SEP-6:  http://%s/sep6", cfg.HomeDomain)
 	log.Printf("SEP-10: http://%s/auth", cfg.HomeDomain)
+	log.Printf("SEP-12: http://%s/sep12", cfg.HomeDomain)
 	log.Printf("SEP-24: http://%s/sep24", cfg.HomeDomain)
+	log.Printf("SEP-31: http://%s/sep31", cfg.HomeDomain)
+	log.Printf("SEP-38: http://%s/sep38", cfg.HomeDomain)
 	log.Printf("Ready to accept connections")
 
-	if err := http.ListenAndServe(cfg.Addr, mux); err != nil {
+	server := &http.Server{Addr: cfg.Addr, Handler: mux}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		stop()
+		workers.Wait()
 		log.Fatal(fmt.Errorf("server exited: %w", err))
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	workers.Wait()
+}
+
+// stores holds the persistent stores for cfg.DatabaseBackend.
+type stores struct {
+	transactions db.TransactionStore
+	customers    db.CustomerStore
+	quotes       db.QuoteStore
+	blobs        db.BlobStore
+	close        func()
+}
+
+// openStores selects the stores for cfg.DatabaseBackend.
+func openStores(cfg config.Config) (stores, error) {
+	switch cfg.DatabaseBackend {
+	case "memory":
+		return stores{
+			transactions: db.NewMemoryTransactionStore(),
+			customers:    db.NewMemoryCustomerStore(),
+			quotes:       db.NewMemoryQuoteStore(),
+			blobs:        db.NewMemoryBlobStore(),
+			close:        func() {},
+		}, nil
+	case "sqlite":
+		conn, err := db.OpenSQLite(cfg.DatabasePath)
+		if err != nil {
+			return stores{}, err
+		}
+		version, err := db.SchemaVersion(conn)
+		if err != nil {
+			conn.Close()
+			return stores{}, err
+		}
+		if latest := db.LatestSchemaVersion(); version != latest {
+			conn.Close()
+			return stores{}, fmt.Errorf("database %s is at schema version %d, want %d: run `sep-reference migrate`", cfg.DatabasePath, version, latest)
+		}
+		log.Printf("Using SQLite database %s", cfg.DatabasePath)
+		return stores{
+			transactions: db.NewSQLTransactionStore(conn),
+			customers:    db.NewSQLCustomerStore(conn),
+			quotes:       db.NewSQLQuoteStore(conn),
+			blobs:        db.NewSQLBlobStore(conn),
+			close:        func() { conn.Close() },
+		}, nil
+	default:
+		return stores{}, fmt.Errorf("unknown DB_BACKEND %q: use memory or sqlite", cfg.DatabaseBackend)
+	}
+}
+
+func migrate(cfg config.Config) error {
+	if cfg.DatabaseBackend != "sqlite" {
+		return fmt.Errorf("migrate needs DB_BACKEND=sqlite, got %q", cfg.DatabaseBackend)
+	}
+	conn, err := db.OpenSQLite(cfg.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	applied, err := db.Migrate(conn)
+	for _, m := range applied {
+		log.Printf("Applied migration %s", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Database %s is at schema version %d", cfg.DatabasePath, db.LatestSchemaVersion())
+	return nil
+}
+
+// reencrypt seals every stored customer and their files under the primary key.
+func reencrypt(cfg config.Config) error {
+	if cfg.DatabaseBackend != "sqlite" {
+		return fmt.Errorf("reencrypt needs DB_BACKEND=sqlite, got %q", cfg.DatabaseBackend)
+	}
+	if cfg.CustomerKeyringFile == "" {
+		return fmt.Errorf("reencrypt needs CUSTOMER_KEYRING_FILE")
+	}
+	keys, err := keyring.LoadFile(cfg.CustomerKeyringFile)
+	if err != nil {
+		return fmt.Errorf("load customer keyring: %w", err)
+	}
+	opened, err := openStores(cfg)
+	if err != nil {
+		return err
+	}
+	defer opened.close()
+	customers := db.NewEncryptedCustomerStore(opened.customers, keys)
+	rewritten, err := customers.Reencrypt()
+	log.Printf("Re-encrypted %d customers under key %s", rewritten, keys.PrimaryKeyID())
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, customer := range customers.List() {
+		for _, key := range customer.Files {
+			files = append(files, key)
+		}
 	}
+	rewritten, err = db.NewEncryptedBlobStore(opened.blobs, keys).Reencrypt(files)
+	log.Printf("Re-encrypted %d customer files under key %s", rewritten, keys.PrimaryKeyID())
+	return err
 }
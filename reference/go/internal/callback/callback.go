@@ -0,0 +1,211 @@
+// Package callback delivers signed status callbacks to wallets and sending
+// anchors, retrying with exponential backoff and recording deliveries that
+// never succeed as dead letters.
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/keypair"
+)
+
+var ErrQueueFull = errors.New("callback queue is full")
+
+type DeadLetter struct {
+	URL       string          `json:"url"`
+	Body      json.RawMessage `json:"body"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+type DeadLetterStore interface {
+	Add(letter DeadLetter) error
+	List() []DeadLetter
+}
+
+type delivery struct {
+	url  string
+	body []byte
+}
+
+type Dispatcher struct {
+	Client      *http.Client
+	Signer      *keypair.Full
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	DeadLetters DeadLetterStore
+	Now         func() time.Time
+
+	queue chan delivery
+}
+
+func NewDispatcher(signingSeed string, deadLetters DeadLetterStore) (*Dispatcher, error) {
+	signer, err := keypair.ParseFull(signingSeed)
+	if err != nil {
+		return nil, fmt.Errorf("parse callback signing key: %w", err)
+	}
+	return &Dispatcher{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Signer:      signer,
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		DeadLetters: deadLetters,
+		Now:         func() time.Time { return time.Now().UTC() },
+		queue:       make(chan delivery, 256),
+	}, nil
+}
+
+// Enqueue queues payload for delivery to target without blocking the caller.
+func (d *Dispatcher) Enqueue(target string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	select {
+	case d.queue <- delivery{url: target, body: body}:
+		return nil
+	default:
+		d.deadLetter(delivery{url: target, body: body}, 0, ErrQueueFull)
+		return ErrQueueFull
+	}
+}
+
+// Run delivers queued callbacks until ctx is done. Each delivery retries in
+// its own goroutine so a slow receiver does not hold up the others; retries
+// still pending at shutdown are dead-lettered.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case next := <-d.queue:
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				d.deliver(ctx, next)
+			}()
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, next delivery) {
+	var err error
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		if err = d.post(ctx, next); err == nil {
+			return
+		}
+		if attempt == d.MaxAttempts {
+			d.deadLetter(next, attempt, err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			d.deadLetter(next, attempt, err)
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseDelay << (attempt - 1)
+	if delay <= 0 || (d.MaxDelay > 0 && delay > d.MaxDelay) {
+		return d.MaxDelay
+	}
+	return delay
+}
+
+func (d *Dispatcher) post(ctx context.Context, next delivery) error {
+	signature, err := Sign(d.Signer, next.url, next.body, d.Now())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, next.url, bytes.NewReader(next.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Signature", signature)
+	// Deprecated header name still read by older wallets.
+	req.Header.Set("X-Stellar-Signature", signature)
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (d *Dispatcher) deadLetter(next delivery, attempts int, cause error) {
+	log.Printf("callback: giving up on %s after %d attempts: %v", next.url, attempts, cause)
+	if d.DeadLetters == nil {
+		return
+	}
+	letter := DeadLetter{URL: next.url, Body: next.body, Attempts: attempts, LastError: cause.Error(), FailedAt: d.Now()}
+	if err := d.DeadLetters.Add(letter); err != nil {
+		log.Printf("callback: record dead letter for %s: %v", next.url, err)
+	}
+}
+
+// Sign returns the SEP-24 Signature header value "t=<unix>, s=<base64>",
+// signing "<unix>.<host>.<body>" with the server's SEP-10 key.
+func Sign(signer *keypair.Full, target string, body []byte, at time.Time) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("parse callback url: %w", err)
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	sig, err := signer.Sign(payload(timestamp, u.Host, body))
+	if err != nil {
+		return "", err
+	}
+	return "t=" + timestamp + ", s=" + base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks a Signature header produced by Sign against the anchor's
+// SIGNING_KEY account, as a wallet receiving the callback would.
+func Verify(address, header, host string, body []byte) error {
+	var timestamp, encoded string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "s":
+			encoded = value
+		}
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if timestamp == "" || err != nil {
+		return fmt.Errorf("malformed signature header")
+	}
+	kp, err := keypair.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	return kp.Verify(payload(timestamp, host, body), sig)
+}
+
+func payload(timestamp, host string, body []byte) []byte {
+	return append([]byte(timestamp+"."+host+"."), body...)
+}
@@ -0,0 +1,133 @@
+package callback
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+)
+
+func TestDeliversSignedCallback(t *testing.T) {
+	signer := keypair.MustRandom()
+	received := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- Verify(signer.Address(), r.Header.Get("Signature"), r.Host, body)
+	}))
+	defer server.Close()
+
+	dispatcher := testDispatcher(t, signer, NewMemoryDeadLetters())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	if err := dispatcher.Enqueue(server.URL+"/callback", map[string]string{"status": "completed"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	select {
+	case err := <-received:
+		if err != nil {
+			t.Fatalf("signature did not verify: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback was not delivered")
+	}
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		close(done)
+	}))
+	defer server.Close()
+
+	deadLetters := NewMemoryDeadLetters()
+	dispatcher := testDispatcher(t, keypair.MustRandom(), deadLetters)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	if err := dispatcher.Enqueue(server.URL, map[string]string{"status": "pending_anchor"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected delivery on the third attempt, got %d calls", calls.Load())
+	}
+	if letters := deadLetters.List(); len(letters) != 0 {
+		t.Fatalf("expected no dead letters, got %+v", letters)
+	}
+	if got := dispatcher.backoff(3); got != 40*time.Millisecond {
+		t.Fatalf("expected backoff to double per attempt and cap at MaxDelay, got %s", got)
+	}
+}
+
+func TestDeadLettersAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	deadLetters := NewFileDeadLetters(filepath.Join(t.TempDir(), "dead-letters.jsonl"))
+	dispatcher := testDispatcher(t, keypair.MustRandom(), deadLetters)
+	ctx, cancel := context.WithCancel(context.Background())
+	go dispatcher.Run(ctx)
+
+	if err := dispatcher.Enqueue(server.URL, map[string]string{"status": "error"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(deadLetters.List()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	letters := deadLetters.List()
+	if len(letters) != 1 || letters[0].Attempts != 3 || letters[0].URL != server.URL || string(letters[0].Body) != `{"status":"error"}` {
+		t.Fatalf("unexpected dead letters: %+v", letters)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestVerifyRejectsTamperedBody(t *testing.T) {
+	signer := keypair.MustRandom()
+	header, err := Sign(signer, "https://wallet.example.com/cb", []byte(`{"a":1}`), time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	host := "wallet.example.com"
+	if err := Verify(signer.Address(), header, host, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify(signer.Address(), header, host, []byte(`{"a":2}`)); err == nil {
+		t.Fatal("expected tampered body to fail verification")
+	}
+}
+
+func testDispatcher(t *testing.T, signer *keypair.Full, deadLetters DeadLetterStore) *Dispatcher {
+	t.Helper()
+	dispatcher, err := NewDispatcher(signer.Seed(), deadLetters)
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	dispatcher.MaxAttempts = 3
+	dispatcher.BaseDelay = 10 * time.Millisecond
+	dispatcher.MaxDelay = 40 * time.Millisecond
+	return dispatcher
+}
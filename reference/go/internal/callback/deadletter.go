@@ -0,0 +1,82 @@
+package callback
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type MemoryDeadLetters struct {
+	mu      sync.RWMutex
+	letters []DeadLetter
+}
+
+// FileDeadLetters appends one JSON dead letter per line so failed callbacks
+// survive restarts and can be replayed by an operator.
+type FileDeadLetters struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewMemoryDeadLetters() *MemoryDeadLetters {
+	return &MemoryDeadLetters{}
+}
+
+func NewFileDeadLetters(path string) *FileDeadLetters {
+	return &FileDeadLetters{path: path}
+}
+
+func (s *MemoryDeadLetters) Add(letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func (s *MemoryDeadLetters) List() []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]DeadLetter(nil), s.letters...)
+}
+
+func (s *FileDeadLetters) Add(letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("encode dead letter: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create dead letter dir: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open dead letters: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(raw, '\n'))
+	return err
+}
+
+func (s *FileDeadLetters) List() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var letter DeadLetter
+		if json.Unmarshal(scanner.Bytes(), &letter) == nil {
+			letters = append(letters, letter)
+		}
+	}
+	return letters
+}
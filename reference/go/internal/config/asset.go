@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/strkey"
+)
+
+const (
+	SchemeStellar = "stellar"
+	SchemeISO4217 = "iso4217"
+
+	NativeCode = "native"
+)
+
+type AssetID struct {
+	Scheme string
+	Code   string
+	Issuer string
+}
+
+func ParseAssetID(raw string) (AssetID, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == NativeCode {
+		return AssetID{Scheme: SchemeStellar, Code: NativeCode}, nil
+	}
+	parts := strings.Split(raw, ":")
+	switch {
+	case len(parts) == 2 && parts[0] == SchemeStellar && parts[1] == NativeCode:
+		return AssetID{Scheme: SchemeStellar, Code: NativeCode}, nil
+	case len(parts) == 3 && parts[0] == SchemeStellar:
+		id := AssetID{Scheme: SchemeStellar, Code: parts[1], Issuer: parts[2]}
+		return id, id.Validate()
+	case len(parts) == 2 && parts[0] == SchemeISO4217:
+		id := AssetID{Scheme: SchemeISO4217, Code: parts[1]}
+		return id, id.Validate()
+	}
+	return AssetID{}, fmt.Errorf("invalid asset %q", raw)
+}
+
+func (id AssetID) Validate() error {
+	switch id.Scheme {
+	case SchemeStellar:
+		if id.IsNative() {
+			return nil
+		}
+		if len(id.Code) == 0 || len(id.Code) > 12 {
+			return fmt.Errorf("invalid stellar asset code %q", id.Code)
+		}
+		if !strkey.IsValidEd25519PublicKey(id.Issuer) {
+			return fmt.Errorf("invalid issuer for asset %s", id.Code)
+		}
+	case SchemeISO4217:
+		if len(id.Code) != 3 || strings.ToUpper(id.Code) != id.Code {
+			return fmt.Errorf("invalid iso4217 code %q", id.Code)
+		}
+	default:
+		return fmt.Errorf("unknown asset scheme %q", id.Scheme)
+	}
+	return nil
+}
+
+func (id AssetID) IsNative() bool {
+	return id.Scheme == SchemeStellar && id.Code == NativeCode
+}
+
+func (id AssetID) IsStellar() bool {
+	return id.Scheme == SchemeStellar
+}
+
+func (id AssetID) String() string {
+	switch {
+	case id.IsNative():
+		return SchemeStellar + ":" + NativeCode
+	case id.Scheme == SchemeISO4217:
+		return SchemeISO4217 + ":" + id.Code
+	case id.Issuer == "":
+		return SchemeStellar + ":" + id.Code
+	}
+	return SchemeStellar + ":" + id.Code + ":" + id.Issuer
+}
+
+func (a Asset) ID() AssetID {
+	scheme := a.Scheme
+	if scheme == "" {
+		scheme = SchemeStellar
+	}
+	if scheme == SchemeStellar && a.Code == NativeCode {
+		return AssetID{Scheme: SchemeStellar, Code: NativeCode}
+	}
+	return AssetID{Scheme: scheme, Code: a.Code, Issuer: a.Issuer}
+}
+
+func (a Asset) Matches(code, issuer string) bool {
+	if a.Code != code {
+		return false
+	}
+	return issuer == "" || a.Issuer == issuer
+}
@@ -3,19 +3,72 @@ package config
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
 )
 
+const DefaultSignificantDecimals int32 = 7
+
 type Asset struct {
-	Code       string  `json:"asset_code"`
-	Enabled    bool    `json:"enabled"`
-	FeeFixed   float64 `json:"fee_fixed"`
-	FeePercent float64 `json:"fee_percent"`
+	Scheme              string          `json:"scheme,omitempty"`
+	Code                string          `json:"asset_code"`
+	Issuer              string          `json:"asset_issuer,omitempty"`
+	Enabled             bool            `json:"enabled"`
+	SignificantDecimals int32           `json:"significant_decimals"`
+	FeeFixed            decimal.Decimal `json:"fee_fixed"`
+	FeePercent          decimal.Decimal `json:"fee_percent"`
+	Deposit             AssetOperation  `json:"deposit,omitzero"`
+	Withdraw            AssetOperation  `json:"withdraw,omitzero"`
+	Receive             AssetOperation  `json:"receive,omitzero"`
+}
+
+type AssetOperation struct {
+	Enabled    *bool            `json:"enabled,omitempty"`
+	MinAmount  decimal.Decimal  `json:"min_amount,omitzero"`
+	MaxAmount  decimal.Decimal  `json:"max_amount,omitzero"`
+	FeeFixed   *decimal.Decimal `json:"fee_fixed,omitempty"`
+	FeePercent *decimal.Decimal `json:"fee_percent,omitempty"`
+	FeeMinimum decimal.Decimal  `json:"fee_minimum,omitzero"`
+}
+
+func (a Asset) Operation(kind string) AssetOperation {
+	switch kind {
+	case "withdraw", "withdrawal":
+		return a.Withdraw
+	case "receive":
+		return a.Receive
+	}
+	return a.Deposit
+}
+
+func (a Asset) OperationEnabled(kind string) bool {
+	if !a.Enabled {
+		return false
+	}
+	enabled := a.Operation(kind).Enabled
+	return enabled == nil || *enabled
+}
+
+type assetFileEntry struct {
+	Asset
+	Identity            string `json:"asset"`
+	Enabled             *bool  `json:"enabled"`
+	SignificantDecimals *int32 `json:"significant_decimals"`
+}
+
+type QuoteRate struct {
+	Sell  AssetID
+	Buy   AssetID
+	Price decimal.Decimal
 }
 
 type Config struct {
@@ -25,18 +78,45 @@ type Config struct {
 	NetworkPassphrase   string
 	SigningKey          string
 	ServerAccount       string
+	DistributionAccount string
+	DistributionSeed    string
+	WithdrawMemoType    string
 	JWTSecret           string
+	AdminAPIKey         string
+	DatabaseBackend     string
+	DatabasePath        string
+	CustomerKeyringFile string
 	ChallengeTTL        time.Duration
 	TokenTTL            time.Duration
 	TransferServer      string
 	TransferServerSep24 string
+	KYCServer           string
+	DirectPaymentServer string
+	SEP9FieldsFile      string
 	QuoteServer         string
+	QuoteTTL            time.Duration
+	QuoteRates          []QuoteRate
+	SEP31ClientDomains  []string
+	CallbackMaxAttempts int
+	CallbackRetryDelay  time.Duration
+	CallbackDeadLetters string
+	EventsWebhookURL    string
+	EventsWebhookSecret string
+	EventsOutboxFile    string
+	EventsRetention     time.Duration
+	HorizonURL          string
+	ObserverCursorFile  string
+	PaymentPollInterval time.Duration
+	DepositPayouts      string
+	ExpireAfter         map[string]time.Duration
+	ExpirySweepInterval time.Duration
+	RoundingMode        decimal.RoundingMode
+	FeeRulesFile        string
 	Assets              []Asset
 }
 
 func Load() Config {
 	homeDomain := getenv("HOME_DOMAIN", "localhost:8080")
-	transferServer := getenv("TRANSFER_SERVER", "http://localhost:8080/sep24")
 
 	cfg := Config{
 		Addr:                getenv("ADDR", ":8080"),
@@ -45,15 +125,55 @@ func Load() Config {
 		NetworkPassphrase:   getenv("NETWORK_PASSPHRASE", "Test SDF Network ; September 2015"),
 		SigningKey:          getenv("SIGNING_KEY", "SCFDN4SWA4VR2Z2FDMGSQSTIYKNAL7LLWD6LCBZ7OTZ4LORMHXY2HUT4"),
 		JWTSecret:           getenv("JWT_SECRET", "dev-jwt-secret"),
+		AdminAPIKey:         getenv("ADMIN_API_KEY", ""),
+		DatabaseBackend:     strings.ToLower(getenv("DB_BACKEND", "memory")),
+		DatabasePath:        getenv("DATABASE_PATH", "sep-reference.db"),
+		CustomerKeyringFile: getenv("CUSTOMER_KEYRING_FILE", ""),
 		ChallengeTTL:        parseDuration(getenv("CHALLENGE_TTL", "5m"), 5*time.Minute),
 		TokenTTL:            parseDuration(getenv("TOKEN_TTL", "15m"), 15*time.Minute),
-		TransferServer:      transferServer,
-		TransferServerSep24: getenv("TRANSFER_SERVER_SEP0024", transferServer),
-		QuoteServer:         getenv("QUOTE_SERVER", ""),
+		TransferServer:      getenv("TRANSFER_SERVER", "http://localhost:8080/sep6"),
+		TransferServerSep24: getenv("TRANSFER_SERVER_SEP0024", "http://localhost:8080/sep24"),
+		KYCServer:           getenv("KYC_SERVER", "http://localhost:8080/sep12"),
+		SEP9FieldsFile:      getenv("SEP9_FIELDS_FILE", ""),
+		DirectPaymentServer: getenv("DIRECT_PAYMENT_SERVER", "http://localhost:8080/sep31"),
+		SEP31ClientDomains:  parseList(getenv("SEP31_CLIENT_DOMAINS", "")),
+		QuoteServer:         getenv("QUOTE_SERVER", "http://localhost:8080/sep38"),
+		QuoteTTL:            parseDuration(getenv("QUOTE_TTL", "5m"), 5*time.Minute),
+		QuoteRates:          parseQuoteRates(getenv("QUOTE_RATES", "")),
+		CallbackMaxAttempts: parseInt(getenv("CALLBACK_MAX_ATTEMPTS", "5"), 5),
+		CallbackRetryDelay:  parseDuration(getenv("CALLBACK_RETRY_DELAY", "1s"), time.Second),
+		CallbackDeadLetters: getenv("CALLBACK_DEAD_LETTER_FILE", ""),
+		EventsWebhookURL:    getenv("EVENTS_WEBHOOK_URL", ""),
+		EventsWebhookSecret: getenv("EVENTS_WEBHOOK_SECRET", ""),
+		EventsOutboxFile:    getenv("EVENTS_OUTBOX_FILE", ""),
+		EventsRetention:     parseDuration(getenv("EVENTS_RETENTION", "168h"), 7*24*time.Hour),
+		HorizonURL:          getenv("HORIZON_URL", ""),
+		ObserverCursorFile:  getenv("OBSERVER_CURSOR_FILE", ""),
+		PaymentPollInterval: parseDuration(getenv("PAYMENT_POLL_INTERVAL", "10s"), 10*time.Second),
+		DepositPayouts:      parseDepositPayouts(getenv("DEPOSIT_PAYOUTS", PayoutsWorker)),
+		ExpireAfter:         parseStatusDurations(getenv("EXPIRE_AFTER", "incomplete=1h,pending_user_transfer_start=24h")),
+		ExpirySweepInterval: parseDuration(getenv("EXPIRY_SWEEP_INTERVAL", "1m"), time.Minute),
+		WithdrawMemoType:    parseMemoType(getenv("WITHDRAW_MEMO_TYPE", memo.TypeID)),
+		RoundingMode:        parseRoundingMode(getenv("ROUNDING_MODE", "half_even")),
+		FeeRulesFile:        getenv("FEE_RULES_FILE", ""),
 		Assets:              parseAssets(getenv("ASSETS", "USDC")),
 	}
+	if path := getenv("ASSETS_FILE", ""); path != "" {
+		assets, err := LoadAssetsFile(path)
+		if err != nil {
+			log.Printf("config: ignoring ASSETS_FILE: %v", err)
+		} else {
+			cfg.Assets = assets
+		}
+	}
 
 	cfg.ServerAccount = getenv("SERVER_ACCOUNT", derivePseudoAccount(cfg.SigningKey))
+	cfg.DistributionSeed = getenv("DISTRIBUTION_SIGNING_KEY", cfg.SigningKey)
+	distributionAccount := cfg.ServerAccount
+	if cfg.DistributionSeed != cfg.SigningKey {
+		distributionAccount = derivePseudoAccount(cfg.DistributionSeed)
+	}
+	cfg.DistributionAccount = getenv("DISTRIBUTION_ACCOUNT", distributionAccount)
 	return cfg
 }
 
@@ -72,6 +192,152 @@ func parseDuration(raw string, fallback time.Duration) time.Duration {
 	return d
 }
 
+func parseInt(raw string, fallback int) int {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func parseStatusDurations(raw string) map[string]time.Duration {
+	out := map[string]time.Duration{}
+	for _, part := range strings.Split(raw, ",") {
+		status, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			continue
+		}
+		out[strings.TrimSpace(status)] = d
+	}
+	return out
+}
+
+func parseList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if item := strings.TrimSpace(part); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func parseQuoteRates(raw string) []QuoteRate {
+	var rates []QuoteRate
+	for _, part := range strings.Split(raw, ",") {
+		pair, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		sellRaw, buyRaw, ok := strings.Cut(pair, "/")
+		if !ok {
+			continue
+		}
+		sell, err := ParseAssetID(sellRaw)
+		if err != nil {
+			log.Printf("config: ignoring quote rate %q: %v", part, err)
+			continue
+		}
+		buy, err := ParseAssetID(buyRaw)
+		if err != nil {
+			log.Printf("config: ignoring quote rate %q: %v", part, err)
+			continue
+		}
+		price, err := decimal.Parse(strings.TrimSpace(value))
+		if err != nil || price.Sign() <= 0 {
+			log.Printf("config: ignoring quote rate %q: invalid price", part)
+			continue
+		}
+		rates = append(rates, QuoteRate{Sell: sell, Buy: buy, Price: price})
+	}
+	return rates
+}
+
+// DEPOSIT_PAYOUTS values.
+const (
+	PayoutsWorker   = "worker"
+	PayoutsPlatform = "platform"
+)
+
+func parseDepositPayouts(raw string) string {
+	if strings.ToLower(strings.TrimSpace(raw)) == PayoutsPlatform {
+		return PayoutsPlatform
+	}
+	return PayoutsWorker
+}
+
+func parseMemoType(raw string) string {
+	memoType := strings.ToLower(strings.TrimSpace(raw))
+	if !memo.ValidType(memoType) {
+		return memo.TypeID
+	}
+	return memoType
+}
+
+func parseRoundingMode(raw string) decimal.RoundingMode {
+	mode, ok := decimal.ParseRoundingMode(raw)
+	if !ok {
+		return decimal.RoundHalfEven
+	}
+	return mode
+}
+
+func LoadAssetsFile(path string) ([]Asset, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read assets file: %w", err)
+	}
+	var entries []assetFileEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("decode assets file: %w", err)
+	}
+
+	assets := make([]Asset, 0, len(entries))
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		asset := entry.Asset
+		if entry.Identity != "" {
+			id, err := ParseAssetID(entry.Identity)
+			if err != nil {
+				return nil, err
+			}
+			asset.Scheme, asset.Code, asset.Issuer = id.Scheme, id.Code, id.Issuer
+		}
+		if asset.Code == "" {
+			return nil, fmt.Errorf("asset entry is missing asset_code")
+		}
+		if seen[asset.ID().String()] {
+			return nil, fmt.Errorf("asset %s is defined more than once", asset.ID())
+		}
+		seen[asset.ID().String()] = true
+		asset.Enabled = entry.Enabled == nil || *entry.Enabled
+		asset.SignificantDecimals = DefaultSignificantDecimals
+		if entry.SignificantDecimals != nil {
+			asset.SignificantDecimals = *entry.SignificantDecimals
+		}
+		if asset.SignificantDecimals < 0 || asset.SignificantDecimals > DefaultSignificantDecimals {
+			return nil, fmt.Errorf("asset %s: significant_decimals must be between 0 and %d", asset.Code, DefaultSignificantDecimals)
+		}
+		for kind, op := range map[string]AssetOperation{"deposit": asset.Deposit, "withdraw": asset.Withdraw, "receive": asset.Receive} {
+			if op.MinAmount.Sign() < 0 || op.MaxAmount.Sign() < 0 || op.FeeMinimum.Sign() < 0 {
+				return nil, fmt.Errorf("asset %s: %s limits must not be negative", asset.Code, kind)
+			}
+			if !op.MaxAmount.IsZero() && op.MaxAmount.LessThan(op.MinAmount) {
+				return nil, fmt.Errorf("asset %s: %s max_amount must not be below min_amount", asset.Code, kind)
+			}
+		}
+		assets = append(assets, asset)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("assets file defines no assets")
+	}
+	return assets, nil
+}
+
 func parseAssets(raw string) []Asset {
 	parts := strings.Split(raw, ",")
 	assets := make([]Asset, 0, len(parts))
@@ -80,31 +346,63 @@ func parseAssets(raw string) []Asset {
 		if item == "" {
 			continue
 		}
-		code, fixed, percent := parseAssetItem(item)
-		assets = append(assets, Asset{Code: code, Enabled: true, FeeFixed: fixed, FeePercent: percent})
+		id, fixed, percent := parseAssetItem(item)
+		assets = append(assets, Asset{
+			Scheme:              id.Scheme,
+			Code:                id.Code,
+			Issuer:              id.Issuer,
+			Enabled:             true,
+			SignificantDecimals: DefaultSignificantDecimals,
+			FeeFixed:            fixed,
+			FeePercent:          percent,
+		})
 	}
 	if len(assets) == 0 {
-		assets = append(assets, Asset{Code: "USDC", Enabled: true, FeeFixed: 1.0, FeePercent: 0.0})
+		assets = append(assets, Asset{
+			Code:                "USDC",
+			Enabled:             true,
+			SignificantDecimals: DefaultSignificantDecimals,
+			FeeFixed:            decimal.New(1, 0),
+		})
 	}
 	return assets
 }
 
-func parseAssetItem(item string) (string, float64, float64) {
+func parseAssetItem(item string) (AssetID, decimal.Decimal, decimal.Decimal) {
 	chunks := strings.Split(item, ":")
-	code := strings.TrimSpace(chunks[0])
-	fixed := 1.0
-	percent := 0.0
-	if len(chunks) > 1 {
-		if v, err := strconv.ParseFloat(chunks[1], 64); err == nil {
+	for i := range chunks {
+		chunks[i] = strings.TrimSpace(chunks[i])
+	}
+
+	var id AssetID
+	var rest []string
+	switch {
+	case chunks[0] == SchemeStellar && len(chunks) > 1 && chunks[1] == NativeCode:
+		id, rest = AssetID{Scheme: SchemeStellar, Code: NativeCode}, chunks[2:]
+	case chunks[0] == SchemeStellar && len(chunks) > 2:
+		id, rest = AssetID{Scheme: SchemeStellar, Code: chunks[1], Issuer: chunks[2]}, chunks[3:]
+	case chunks[0] == SchemeISO4217 && len(chunks) > 1:
+		id, rest = AssetID{Scheme: SchemeISO4217, Code: chunks[1]}, chunks[2:]
+	default:
+		id, rest = AssetID{Scheme: SchemeStellar, Code: chunks[0]}, chunks[1:]
+		if len(rest) > 2 {
+			id.Issuer = rest[2]
+		}
+	}
+
+	fixed := decimal.New(1, 0)
+	percent := decimal.Zero
+	if len(rest) > 0 {
+		if v, err := decimal.Parse(rest[0]); err == nil {
 			fixed = v
 		}
 	}
-	if len(chunks) > 2 {
-		if v, err := strconv.ParseFloat(chunks[2], 64); err == nil {
+	if len(rest) > 1 {
+		if v, err := decimal.Parse(rest[1]); err == nil {
 			percent = v
 		}
 	}
-	return code, fixed, percent
+	return id, fixed, percent
 }
 
 func derivePseudoAccount(seed string) string {
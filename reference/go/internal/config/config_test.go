@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+func TestParseAssetID(t *testing.T) {
+	issuer := keypair.MustRandom().Address()
+
+	cases := map[string]AssetID{
+		"native":                 {Scheme: SchemeStellar, Code: NativeCode},
+		"stellar:native":         {Scheme: SchemeStellar, Code: NativeCode},
+		"stellar:USDC:" + issuer: {Scheme: SchemeStellar, Code: "USDC", Issuer: issuer},
+		"iso4217:USD":            {Scheme: SchemeISO4217, Code: "USD"},
+	}
+	for raw, want := range cases {
+		got, err := ParseAssetID(raw)
+		if err != nil {
+			t.Fatalf("parse %s: %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("parse %s: expected %+v, got %+v", raw, want, got)
+		}
+		if raw != "native" && got.String() != raw {
+			t.Fatalf("round trip %s: got %s", raw, got)
+		}
+	}
+
+	for _, raw := range []string{"USDC", "stellar:USDC", "stellar:USDC:GBAD", "iso4217:usd", "sep:USDC"} {
+		if _, err := ParseAssetID(raw); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestParseAssetsWithIdentities(t *testing.T) {
+	issuerA := keypair.MustRandom().Address()
+	issuerB := keypair.MustRandom().Address()
+
+	assets := parseAssets("USDC:1:0.1:" + issuerA + ",stellar:USDC:" + issuerB + ":2,stellar:native,iso4217:USD:0:0")
+	if len(assets) != 4 {
+		t.Fatalf("expected 4 assets, got %+v", assets)
+	}
+	if assets[0].ID().String() != "stellar:USDC:"+issuerA || !assets[0].FeePercent.Equal(decimal.MustParse("0.1")) {
+		t.Fatalf("unexpected legacy asset: %+v", assets[0])
+	}
+	if assets[1].ID().String() != "stellar:USDC:"+issuerB || !assets[1].FeeFixed.Equal(decimal.MustParse("2")) {
+		t.Fatalf("unexpected identity asset: %+v", assets[1])
+	}
+	if !assets[2].ID().IsNative() {
+		t.Fatalf("expected native asset, got %+v", assets[2])
+	}
+	if assets[3].ID().String() != "iso4217:USD" || assets[3].ID().IsStellar() {
+		t.Fatalf("unexpected off-chain asset: %+v", assets[3])
+	}
+}
@@ -0,0 +1,287 @@
+package db_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/db/dbtest"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/keyring"
+)
+
+func TestMemoryTransactionStore(t *testing.T) {
+	dbtest.RunTransactionStoreSuite(t, func(t *testing.T) db.TransactionStore {
+		return db.NewMemoryTransactionStore()
+	})
+}
+
+func TestMemoryCustomerStore(t *testing.T) {
+	dbtest.RunCustomerStoreSuite(t, func(t *testing.T) db.CustomerStore {
+		return db.NewMemoryCustomerStore()
+	})
+}
+
+func TestEncryptedCustomerStore(t *testing.T) {
+	dbtest.RunCustomerStoreSuite(t, func(t *testing.T) db.CustomerStore {
+		return db.NewEncryptedCustomerStore(db.NewMemoryCustomerStore(), testKeyring(t, "k1"))
+	})
+}
+
+func TestSQLTransactionStore(t *testing.T) {
+	dbtest.RunTransactionStoreSuite(t, func(t *testing.T) db.TransactionStore {
+		return db.NewSQLTransactionStore(openTestDB(t, filepath.Join(t.TempDir(), "sep.db")))
+	})
+}
+
+func TestSQLCustomerStore(t *testing.T) {
+	dbtest.RunCustomerStoreSuite(t, func(t *testing.T) db.CustomerStore {
+		return db.NewSQLCustomerStore(openTestDB(t, filepath.Join(t.TempDir(), "sep.db")))
+	})
+}
+
+func TestQuoteStores(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		dbtest.RunQuoteStoreSuite(t, func(t *testing.T) db.QuoteStore { return db.NewMemoryQuoteStore() })
+	})
+	t.Run("SQL", func(t *testing.T) {
+		dbtest.RunQuoteStoreSuite(t, func(t *testing.T) db.QuoteStore {
+			return db.NewSQLQuoteStore(openTestDB(t, filepath.Join(t.TempDir(), "sep.db")))
+		})
+	})
+}
+
+func TestBlobStores(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		dbtest.RunBlobStoreSuite(t, func(t *testing.T) db.BlobStore { return db.NewMemoryBlobStore() })
+	})
+	t.Run("SQL", func(t *testing.T) {
+		dbtest.RunBlobStoreSuite(t, func(t *testing.T) db.BlobStore {
+			return db.NewSQLBlobStore(openTestDB(t, filepath.Join(t.TempDir(), "sep.db")))
+		})
+	})
+	t.Run("Encrypted", func(t *testing.T) {
+		dbtest.RunBlobStoreSuite(t, func(t *testing.T) db.BlobStore {
+			return db.NewEncryptedBlobStore(db.NewMemoryBlobStore(), testKeyring(t, "k1"))
+		})
+	})
+}
+
+func TestSQLStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sep.db")
+	conn := openTestDB(t, path)
+	tx := db.Transaction{ID: "tx-1", Account: "GA", Kind: "deposit", Status: "incomplete", Amount: decimal.MustParse("12.5"), StartedAt: time.Now().UTC()}
+	if err := db.NewSQLTransactionStore(conn).Create(tx, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	conn.Close()
+
+	got, ok := db.NewSQLTransactionStore(openTestDB(t, path)).GetByID("tx-1")
+	if !ok || !got.Amount.Equal(tx.Amount) || !got.StartedAt.Equal(tx.StartedAt) {
+		t.Fatalf("expected transaction to survive reopen, got %+v", got)
+	}
+}
+
+func TestSQLHistoryIsAppendOnly(t *testing.T) {
+	conn := openTestDB(t, filepath.Join(t.TempDir(), "sep.db"))
+	store := db.NewSQLTransactionStore(conn)
+	if err := store.Create(db.Transaction{ID: "tx-1", Account: "GA", Status: "incomplete"}, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE transaction_history SET reason = 'rewritten'`); err == nil {
+		t.Fatal("expected history rows to be immutable")
+	}
+	if _, err := conn.Exec(`DELETE FROM transaction_history`); err == nil {
+		t.Fatal("expected history rows not to be deletable")
+	}
+	if history, err := store.History("tx-1"); err != nil || len(history) != 1 || history[0].Reason != "" {
+		t.Fatalf("expected the original entry to survive, got %+v %v", history, err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	conn, err := db.OpenSQLite(filepath.Join(t.TempDir(), "sep.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer conn.Close()
+	applied, err := db.Migrate(conn)
+	if err != nil || len(applied) == 0 {
+		t.Fatalf("expected migrations to apply, got %v %v", applied, err)
+	}
+	if again, err := db.Migrate(conn); err != nil || len(again) != 0 {
+		t.Fatalf("expected no pending migrations, got %v %v", again, err)
+	}
+	if version, err := db.SchemaVersion(conn); err != nil || version != db.LatestSchemaVersion() {
+		t.Fatalf("expected schema version %d, got %d %v", db.LatestSchemaVersion(), version, err)
+	}
+}
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	conn, err := db.OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := db.Migrate(conn); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return conn
+}
+
+func TestEncryptedCustomerStoreSealsPII(t *testing.T) {
+	conn := openTestDB(t, filepath.Join(t.TempDir(), "sep.db"))
+	store := db.NewEncryptedCustomerStore(db.NewSQLCustomerStore(conn), testKeyring(t, "k1"))
+	customer := db.Customer{
+		ID: "c-1", Account: "GA", Status: "NEEDS_INFO",
+		Fields:            map[string]string{"email_address": "ada@example.com"},
+		VerificationCodes: map[string]string{"mobile_number": "123456"},
+	}
+	if err := store.Put(customer); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	var data string
+	if err := conn.QueryRow(`SELECT data FROM customers WHERE id = 'c-1'`).Scan(&data); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if strings.Contains(data, "ada@example.com") || strings.Contains(data, "123456") {
+		t.Fatalf("expected PII to be encrypted at rest, got %s", data)
+	}
+	got, ok := store.Get("GA", "")
+	if !ok || got.Fields["email_address"] != "ada@example.com" || got.VerificationCodes["mobile_number"] != "123456" || got.Sealed != nil {
+		t.Fatalf("expected the customer to decrypt, got %+v", got)
+	}
+
+	// An envelope copied onto another customer does not open: it is bound
+	// to the customer id.
+	if _, err := conn.Exec(`INSERT INTO customers (id, account, memo, data) SELECT 'c-2', 'GB', '', replace(data, '"id":"c-1"', '"id":"c-2"') FROM customers WHERE id = 'c-1'`); err != nil {
+		t.Fatalf("copy row: %v", err)
+	}
+	if _, ok := store.GetByID("c-2"); ok {
+		t.Fatal("expected a moved envelope not to decrypt")
+	}
+}
+
+func TestReencryptRotatesKeys(t *testing.T) {
+	inner := db.NewMemoryCustomerStore()
+	if err := inner.Put(db.Customer{ID: "c-plain", Account: "GA", Fields: map[string]string{"first_name": "Ada"}}); err != nil {
+		t.Fatalf("put plaintext: %v", err)
+	}
+	old := testKeyring(t, "k1")
+	if err := db.NewEncryptedCustomerStore(inner, old).Put(db.Customer{ID: "c-old", Account: "GB", Fields: map[string]string{"first_name": "Grace"}}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	keys, err := keyring.NewFileKeyring("k2", map[string][]byte{"k1": bytes32(1), "k2": bytes32(2)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	store := db.NewEncryptedCustomerStore(inner, keys)
+	if n, err := store.Reencrypt(); err != nil || n != 2 {
+		t.Fatalf("expected both customers to be rewritten, got %d %v", n, err)
+	}
+	for _, c := range inner.List() {
+		if c.Sealed == nil || c.Sealed.KeyID != "k2" || c.Fields != nil {
+			t.Fatalf("expected %s to be sealed under k2, got %+v", c.ID, c)
+		}
+	}
+	if n, err := store.Reencrypt(); err != nil || n != 0 {
+		t.Fatalf("expected a second run to rewrite nothing, got %d %v", n, err)
+	}
+
+	// k1 can be retired once nothing is wrapped under it.
+	current, err := keyring.NewFileKeyring("k2", map[string][]byte{"k2": bytes32(2)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	for id, name := range map[string]string{"c-plain": "Ada", "c-old": "Grace"} {
+		got, ok := db.NewEncryptedCustomerStore(inner, current).GetByID(id)
+		if !ok || got.Fields["first_name"] != name {
+			t.Fatalf("expected %s to decrypt with k2 alone, got %+v", id, got)
+		}
+	}
+}
+
+func TestEncryptedBlobStoreSealsFiles(t *testing.T) {
+	conn := openTestDB(t, filepath.Join(t.TempDir(), "sep.db"))
+	inner := db.NewSQLBlobStore(conn)
+	if err := inner.Put("c-1/plain", db.Blob{ContentType: "image/png", Data: []byte("legacy photo")}); err != nil {
+		t.Fatalf("put plaintext: %v", err)
+	}
+	if err := db.NewEncryptedBlobStore(inner, testKeyring(t, "k1")).Put("c-1/photo", db.Blob{ContentType: "image/jpeg", Data: []byte("passport scan")}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	var data []byte
+	if err := conn.QueryRow(`SELECT data FROM blobs WHERE key = 'c-1/photo'`).Scan(&data); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if strings.Contains(string(data), "passport scan") {
+		t.Fatalf("expected the file to be encrypted at rest, got %s", data)
+	}
+
+	keys, err := keyring.NewFileKeyring("k2", map[string][]byte{"k1": bytes32(1), "k2": bytes32(2)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	store := db.NewEncryptedBlobStore(inner, keys)
+	if blob, ok := store.Get("c-1/plain"); !ok || string(blob.Data) != "legacy photo" {
+		t.Fatalf("expected a file stored before encryption to stay readable, got %+v", blob)
+	}
+	if n, err := store.Reencrypt([]string{"c-1/plain", "c-1/photo", "c-1/missing"}); err != nil || n != 2 {
+		t.Fatalf("expected both files to be rewritten, got %d %v", n, err)
+	}
+	if n, err := store.Reencrypt([]string{"c-1/plain", "c-1/photo"}); err != nil || n != 0 {
+		t.Fatalf("expected a second run to rewrite nothing, got %d %v", n, err)
+	}
+
+	current, err := keyring.NewFileKeyring("k2", map[string][]byte{"k2": bytes32(2)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	for key, want := range map[string]db.Blob{
+		"c-1/plain": {ContentType: "image/png", Data: []byte("legacy photo")},
+		"c-1/photo": {ContentType: "image/jpeg", Data: []byte("passport scan")},
+	} {
+		got, ok := db.NewEncryptedBlobStore(inner, current).Get(key)
+		if !ok || got.ContentType != want.ContentType || string(got.Data) != string(want.Data) {
+			t.Fatalf("expected %s to decrypt with k2 alone, got %+v", key, got)
+		}
+	}
+}
+
+func TestCustomerFormattingRedactsPII(t *testing.T) {
+	customer := db.Customer{ID: "c-1", Fields: map[string]string{"email_address": "ada@example.com", "last_name": ""}}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		if out := fmt.Sprintf(verb, customer); strings.Contains(out, "ada@example.com") || !strings.Contains(out, "email_address") {
+			t.Fatalf("%s: expected redacted field names only, got %s", verb, out)
+		}
+	}
+	if redacted := customer.Redacted(); redacted.Fields["email_address"] != db.RedactedValue || redacted.Fields["last_name"] != "" {
+		t.Fatalf("unexpected redaction: %v", redacted.Fields)
+	}
+	if customer.Fields["email_address"] != "ada@example.com" {
+		t.Fatal("expected Redacted to leave the original untouched")
+	}
+}
+
+func testKeyring(t *testing.T, id string) *keyring.FileKeyring {
+	t.Helper()
+	keys, err := keyring.NewFileKeyring(id, map[string][]byte{id: bytes32(1)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	return keys
+}
+
+func bytes32(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
@@ -0,0 +1,788 @@
+// Package dbtest is a conformance suite for db store implementations. A
+// backend passes by running the suites against a factory that returns an
+// empty store for each subtest:
+//
+//	func TestStore(t *testing.T) {
+//		dbtest.RunTransactionStoreSuite(t, func(t *testing.T) db.TransactionStore {
+//			return newStore(t)
+//		})
+//	}
+package dbtest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+var (
+	start     = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	testAudit = db.Audit{Actor: "GACTOR", Source: db.SourceAPI, Reason: "test write"}
+)
+
+// RunTransactionStoreSuite checks the db.TransactionStore contract. factory
+// must return an empty store; it is called once per subtest.
+func RunTransactionStoreSuite(t *testing.T, factory func(t *testing.T) db.TransactionStore) {
+	t.Run("CreateAndGet", func(t *testing.T) { testCreateAndGet(t, factory(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, factory(t)) })
+	t.Run("DuplicateID", func(t *testing.T) { testDuplicateID(t, factory(t)) })
+	t.Run("IdentifierLookups", func(t *testing.T) { testIdentifierLookups(t, factory(t)) })
+	t.Run("UpdateAndUpdateStatus", func(t *testing.T) { testUpdateAndUpdateStatus(t, factory(t)) })
+	t.Run("ListByAccountOrderAndLimit", func(t *testing.T) { testListOrderAndLimit(t, factory(t)) })
+	t.Run("ListByAccountFilters", func(t *testing.T) { testListFilters(t, factory(t)) })
+	t.Run("CursorPaging", func(t *testing.T) { testCursorPaging(t, factory(t)) })
+	t.Run("ListByStatus", func(t *testing.T) { testListByStatus(t, factory(t)) })
+	t.Run("TimePrecision", func(t *testing.T) { testTimePrecision(t, factory(t)) })
+	t.Run("ConcurrentUpdates", func(t *testing.T) { testConcurrentUpdates(t, factory(t)) })
+	t.Run("History", func(t *testing.T) { testHistory(t, factory(t)) })
+}
+
+// RunCustomerStoreSuite checks the db.CustomerStore contract.
+func RunCustomerStoreSuite(t *testing.T, factory func(t *testing.T) db.CustomerStore) {
+	t.Run("PutAndGet", func(t *testing.T) { testCustomerPutAndGet(t, factory(t)) })
+	t.Run("AccountMemoIsUnique", func(t *testing.T) { testCustomerUniqueness(t, factory(t)) })
+	t.Run("Delete", func(t *testing.T) { testCustomerDelete(t, factory(t)) })
+	t.Run("List", func(t *testing.T) { testCustomerList(t, factory(t)) })
+	t.Run("Replace", func(t *testing.T) { testCustomerReplace(t, factory(t)) })
+}
+
+// RunQuoteStoreSuite checks the db.QuoteStore contract.
+func RunQuoteStoreSuite(t *testing.T, factory func(t *testing.T) db.QuoteStore) {
+	t.Run("CreateGetAndUpdate", func(t *testing.T) { testQuoteCreateGetAndUpdate(t, factory(t)) })
+	t.Run("BindOnce", func(t *testing.T) { testQuoteBindOnce(t, factory(t)) })
+}
+
+// RunBlobStoreSuite checks the db.BlobStore contract.
+func RunBlobStoreSuite(t *testing.T, factory func(t *testing.T) db.BlobStore) {
+	t.Run("PutGetAndDelete", func(t *testing.T) { testBlobPutGetAndDelete(t, factory(t)) })
+	t.Run("Replace", func(t *testing.T) { testBlobReplace(t, factory(t)) })
+}
+
+func testCreateAndGet(t *testing.T, store db.TransactionStore) {
+	tx := sampleTransaction("tx-1", "GA", start)
+	mustCreate(t, store, tx)
+	got, ok := store.GetByID("tx-1")
+	if !ok {
+		t.Fatal("expected created transaction to be found")
+	}
+	if got.Account != tx.Account || got.Kind != tx.Kind || got.Status != tx.Status || !got.Amount.Equal(tx.Amount) ||
+		got.FeeDetails == nil || !got.FeeDetails.Total.Equal(tx.FeeDetails.Total) || len(got.KYCFields) != 1 {
+		t.Fatalf("stored transaction differs:\n got %+v\nwant %+v", got, tx)
+	}
+}
+
+func testNotFound(t *testing.T, store db.TransactionStore) {
+	mustCreate(t, store, sampleTransaction("tx-1", "GA", start))
+	if got, ok := store.GetByID("missing"); ok || got.ID != "" {
+		t.Fatalf("expected GetByID of a missing id to return a zero transaction and false, got %+v %v", got, ok)
+	}
+	if _, ok := store.GetByID(""); ok {
+		t.Fatal("expected GetByID of an empty id to fail")
+	}
+	if got, err := store.UpdateStatus("missing", "incomplete", "completed", start, testAudit); !errors.Is(err, db.ErrNotFound) || got.ID != "" {
+		t.Fatalf("expected UpdateStatus of a missing id to fail with ErrNotFound, got %+v %v", got, err)
+	}
+	if _, err := store.Update(sampleTransaction("missing", "GA", start), testAudit); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("expected Update of a missing id to fail with ErrNotFound, got %v", err)
+	}
+	if _, ok := store.GetByID("missing"); ok {
+		t.Fatal("expected failed updates not to create a transaction")
+	}
+	if got := store.ListByAccount(db.TransactionQuery{Account: "GUNKNOWN"}); len(got) != 0 {
+		t.Fatalf("expected no transactions for an unknown account, got %+v", got)
+	}
+	if got := store.ListByStatus(db.StatusQuery{Status: "no_such_status"}); len(got) != 0 {
+		t.Fatalf("expected no transactions for an unused status, got %+v", got)
+	}
+}
+
+func testDuplicateID(t *testing.T, store db.TransactionStore) {
+	mustCreate(t, store, sampleTransaction("tx-1", "GA", start))
+	duplicate := sampleTransaction("tx-1", "GA", start)
+	duplicate.Status = "completed"
+	if err := store.Create(duplicate, testAudit); !errors.Is(err, db.ErrDuplicate) {
+		t.Fatalf("expected Create of an existing id to fail with ErrDuplicate, got %v", err)
+	}
+	if got, _ := store.GetByID("tx-1"); got.Status != "incomplete" {
+		t.Fatalf("expected a rejected Create to leave the stored transaction alone, got %+v", got)
+	}
+	if got := store.ListByAccount(db.TransactionQuery{Account: "GA"}); len(got) != 1 {
+		t.Fatalf("expected one transaction per id, got %d: %+v", len(got), ids(got))
+	}
+	if got := store.ListByStatus(db.StatusQuery{Status: "incomplete"}); len(got) != 1 {
+		t.Fatalf("expected one transaction per id by status, got %d", len(got))
+	}
+}
+
+func testIdentifierLookups(t *testing.T, store db.TransactionStore) {
+	tx := sampleTransaction("tx-1", "GA", start)
+	tx.ExternalTransactionID = "ext-1"
+	tx.StellarTransactionID = "hash-1"
+	tx.WithdrawMemo = "7"
+	tx.WithdrawMemoType = "id"
+	mustCreate(t, store, tx)
+	mustCreate(t, store, sampleTransaction("tx-2", "GA", start))
+
+	for name, got := range map[string][]db.Transaction{
+		"external": store.ListByExternalID("ext-1"),
+		"stellar":  store.ListByStellarTxID("hash-1"),
+	} {
+		if len(got) != 1 || got[0].ID != "tx-1" {
+			t.Fatalf("%s lookup: expected tx-1, got %v", name, ids(got))
+		}
+	}
+	if got, ok := store.GetByMemo("7", "id"); !ok || got.ID != "tx-1" {
+		t.Fatalf("memo lookup: expected tx-1, got %+v %v", got, ok)
+	}
+	for name, got := range map[string][]db.Transaction{
+		"empty external": store.ListByExternalID(""),
+		"empty stellar":  store.ListByStellarTxID(""),
+		"unknown":        store.ListByExternalID("ext-2"),
+	} {
+		if len(got) != 0 {
+			t.Fatalf("%s lookup: expected no match, got %v", name, ids(got))
+		}
+	}
+	for name, lookup := range map[string]func() (db.Transaction, bool){
+		"empty memo": func() (db.Transaction, bool) { return store.GetByMemo("", "") },
+		"memo type":  func() (db.Transaction, bool) { return store.GetByMemo("7", "text") },
+	} {
+		if got, ok := lookup(); ok {
+			t.Fatalf("%s lookup: expected no match, got %+v", name, got)
+		}
+	}
+
+	// Another account's transaction may carry the same identifiers; both
+	// are returned, newest first.
+	other := sampleTransaction("tx-3", "GB", start.Add(time.Minute))
+	other.ExternalTransactionID = "ext-1"
+	other.StellarTransactionID = "hash-1"
+	mustCreate(t, store, other)
+	for name, got := range map[string][]db.Transaction{
+		"shared external": store.ListByExternalID("ext-1"),
+		"shared stellar":  store.ListByStellarTxID("hash-1"),
+	} {
+		if len(got) != 2 || got[0].ID != "tx-3" || got[1].ID != "tx-1" {
+			t.Fatalf("%s lookup: expected tx-3 and tx-1, got %v", name, ids(got))
+		}
+	}
+
+	tx.ExternalTransactionID = "ext-2"
+	tx.WithdrawMemo = ""
+	mustUpdate(t, store, tx)
+	if got := store.ListByExternalID("ext-1"); len(got) != 1 || got[0].ID != "tx-3" {
+		t.Fatalf("expected a replaced external id to stop resolving to tx-1, got %v", ids(got))
+	}
+	if _, ok := store.GetByMemo("7", "id"); ok {
+		t.Fatal("expected a cleared memo to stop resolving")
+	}
+	if got := store.ListByExternalID("ext-2"); len(got) != 1 || got[0].ID != "tx-1" {
+		t.Fatalf("expected the new external id to resolve, got %v", ids(got))
+	}
+}
+
+func testUpdateAndUpdateStatus(t *testing.T, store db.TransactionStore) {
+	tx := sampleTransaction("tx-1", "GA", start)
+	tx.Version = 7
+	mustCreate(t, store, tx)
+	tx, _ = store.GetByID("tx-1")
+	if tx.Version != 0 {
+		t.Fatalf("expected Create to start at version 0, got %d", tx.Version)
+	}
+
+	stale := tx
+	tx.Status = "pending_user_transfer_start"
+	tx.AmountIn = decimal.MustParse("10.5")
+	tx.Message = "send funds"
+	updated := mustUpdate(t, store, tx)
+	if updated.Version != 1 {
+		t.Fatalf("expected Update to return version 1, got %d", updated.Version)
+	}
+	got, _ := store.GetByID("tx-1")
+	if got.Version != 1 || got.Status != tx.Status || !got.AmountIn.Equal(tx.AmountIn) || got.Message != tx.Message {
+		t.Fatalf("expected Update to replace the record, got %+v", got)
+	}
+
+	stale.Message = "lost update"
+	if _, err := store.Update(stale, testAudit); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected Update from a stale version to fail with ErrConflict, got %v", err)
+	}
+	if got, _ := store.GetByID("tx-1"); got.Message != "send funds" || got.Version != 1 {
+		t.Fatalf("expected a conflicting Update to change nothing, got %+v", got)
+	}
+
+	at := start.Add(time.Minute)
+	if _, err := store.UpdateStatus("tx-1", "incomplete", "pending_anchor", at, testAudit); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected UpdateStatus from the wrong status to fail with ErrConflict, got %v", err)
+	}
+	moved, err := store.UpdateStatus("tx-1", "pending_user_transfer_start", "pending_anchor", at, testAudit)
+	if err != nil || moved.Status != "pending_anchor" || !moved.UpdatedAt.Equal(at) || moved.Version != 2 {
+		t.Fatalf("unexpected UpdateStatus result: %+v %v", moved, err)
+	}
+	got, _ = store.GetByID("tx-1")
+	if got.Status != "pending_anchor" || !got.UpdatedAt.Equal(at) || !got.AmountIn.Equal(tx.AmountIn) || got.Message != tx.Message || got.Version != 2 {
+		t.Fatalf("expected UpdateStatus to change only status, updated_at and version, got %+v", got)
+	}
+	if _, err := store.Update(updated, testAudit); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected UpdateStatus to invalidate earlier reads, got %v", err)
+	}
+}
+
+func testListOrderAndLimit(t *testing.T, store db.TransactionStore) {
+	// Created out of order; tx-b and tx-c share started_at so id breaks the tie.
+	mustCreate(t, store, sampleTransaction("tx-c", "GA", start.Add(time.Minute)))
+	mustCreate(t, store, sampleTransaction("tx-a", "GA", start))
+	mustCreate(t, store, sampleTransaction("tx-d", "GA", start.Add(2*time.Minute)))
+	mustCreate(t, store, sampleTransaction("tx-b", "GA", start.Add(time.Minute)))
+	mustCreate(t, store, sampleTransaction("tx-x", "GB", start.Add(time.Hour)))
+
+	expectIDs(t, "all", store.ListByAccount(db.TransactionQuery{Account: "GA"}), "tx-d", "tx-c", "tx-b", "tx-a")
+	expectIDs(t, "limit", store.ListByAccount(db.TransactionQuery{Account: "GA", Limit: 2}), "tx-d", "tx-c")
+	expectIDs(t, "large limit", store.ListByAccount(db.TransactionQuery{Account: "GA", Limit: 100}), "tx-d", "tx-c", "tx-b", "tx-a")
+
+	moved, _ := store.GetByID("tx-a")
+	moved.StartedAt = start.Add(3 * time.Minute)
+	moved = mustUpdate(t, store, moved)
+	expectIDs(t, "after started_at update", store.ListByAccount(db.TransactionQuery{Account: "GA", Limit: 2}), "tx-a", "tx-d")
+
+	moved.Account = "GB"
+	mustUpdate(t, store, moved)
+	expectIDs(t, "after account update", store.ListByAccount(db.TransactionQuery{Account: "GA"}), "tx-d", "tx-c", "tx-b")
+	expectIDs(t, "new account", store.ListByAccount(db.TransactionQuery{Account: "GB"}), "tx-x", "tx-a")
+}
+
+func testListFilters(t *testing.T, store db.TransactionStore) {
+	for i, spec := range []struct{ protocol, kind, code, issuer string }{
+		{"sep24", "deposit", "USDC", "GISSUER1"},
+		{"sep24", "withdraw", "USDC", "GISSUER1"},
+		{"sep6", "deposit", "USDC", "GISSUER2"},
+		{"sep24", "deposit", "EURC", ""},
+	} {
+		tx := sampleTransaction(fmt.Sprintf("tx-%d", i), "GA", start.Add(time.Duration(i)*time.Minute))
+		tx.Protocol, tx.Kind, tx.AssetCode, tx.AssetIssuer = spec.protocol, spec.kind, spec.code, spec.issuer
+		mustCreate(t, store, tx)
+	}
+	for name, tc := range map[string]struct {
+		query db.TransactionQuery
+		want  []string
+	}{
+		"protocol":      {db.TransactionQuery{Protocol: "sep24"}, []string{"tx-3", "tx-1", "tx-0"}},
+		"kinds":         {db.TransactionQuery{Kinds: []string{"withdraw", "withdrawal"}}, []string{"tx-1"}},
+		"asset code":    {db.TransactionQuery{AssetCode: "USDC"}, []string{"tx-2", "tx-1", "tx-0"}},
+		"asset issuer":  {db.TransactionQuery{AssetCode: "USDC", AssetIssuer: "GISSUER2"}, []string{"tx-2"}},
+		"no older than": {db.TransactionQuery{NoOlderThan: start.Add(2 * time.Minute)}, []string{"tx-3", "tx-2"}},
+		"combined":      {db.TransactionQuery{Protocol: "sep24", Kinds: []string{"deposit"}, Limit: 1}, []string{"tx-3"}},
+		"no match":      {db.TransactionQuery{AssetCode: "BRL"}, nil},
+	} {
+		tc.query.Account = "GA"
+		expectIDs(t, name, store.ListByAccount(tc.query), tc.want...)
+	}
+}
+
+func testCursorPaging(t *testing.T, store db.TransactionStore) {
+	for i := 0; i < 7; i++ {
+		// Pairs share started_at so pages must split ties by id.
+		mustCreate(t, store, sampleTransaction(fmt.Sprintf("tx-%d", i), "GA", start.Add(time.Duration(i/2)*time.Minute)))
+	}
+	mustCreate(t, store, sampleTransaction("tx-other", "GB", start))
+
+	var seen []string
+	cursor := ""
+	for page := 0; ; page++ {
+		got := store.ListByAccount(db.TransactionQuery{Account: "GA", Cursor: cursor, Limit: 3})
+		if len(got) == 0 {
+			break
+		}
+		if page == 0 {
+			// Transactions started after paging began must not shift later pages.
+			mustCreate(t, store, sampleTransaction("tx-new", "GA", start.Add(time.Hour)))
+		}
+		seen = append(seen, ids(got)...)
+		cursor = got[len(got)-1].ID
+	}
+	if fmt.Sprint(seen) != "[tx-6 tx-5 tx-4 tx-3 tx-2 tx-1 tx-0]" {
+		t.Fatalf("expected each transaction once in order, got %v", seen)
+	}
+
+	expectIDs(t, "cursor with filter", store.ListByAccount(db.TransactionQuery{Account: "GA", Cursor: "tx-3", NoOlderThan: start.Add(time.Minute)}), "tx-2")
+	expectIDs(t, "last cursor", store.ListByAccount(db.TransactionQuery{Account: "GA", Cursor: "tx-0"}))
+	expectIDs(t, "unknown cursor", store.ListByAccount(db.TransactionQuery{Account: "GA", Cursor: "missing"}))
+	expectIDs(t, "other account's cursor", store.ListByAccount(db.TransactionQuery{Account: "GA", Cursor: "tx-other"}))
+}
+
+func testListByStatus(t *testing.T, store db.TransactionStore) {
+	for i, status := range []string{"pending_anchor", "incomplete", "pending_anchor", "pending_anchor", "pending_anchor"} {
+		tx := sampleTransaction(fmt.Sprintf("tx-%d", i), "GA", start)
+		tx.Status = status
+		tx.UpdatedAt = start.Add(time.Duration(3-min(i, 3)) * time.Minute)
+		if i == 4 {
+			tx.Protocol, tx.Kind = "sep6", "withdraw"
+		}
+		mustCreate(t, store, tx)
+	}
+	expectIDs(t, "oldest update first", store.ListByStatus(db.StatusQuery{Status: "pending_anchor"}), "tx-3", "tx-4", "tx-2", "tx-0")
+	expectIDs(t, "limit", store.ListByStatus(db.StatusQuery{Status: "pending_anchor", Limit: 1}), "tx-3")
+	expectIDs(t, "kinds", store.ListByStatus(db.StatusQuery{Status: "pending_anchor", Kinds: []string{"withdraw"}}), "tx-4")
+	expectIDs(t, "protocol", store.ListByStatus(db.StatusQuery{Status: "pending_anchor", Protocol: "sep6"}), "tx-4")
+
+	tx3, _ := store.GetByID("tx-3")
+	after := db.StatusCursor{UpdatedAt: tx3.UpdatedAt, ID: tx3.ID}
+	expectIDs(t, "after", store.ListByStatus(db.StatusQuery{Status: "pending_anchor", After: after, Limit: 2}), "tx-4", "tx-2")
+}
+
+func testTimePrecision(t *testing.T, store db.TransactionStore) {
+	precise := time.Date(2025, 3, 4, 5, 6, 7, 123456789, time.UTC)
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	tx := sampleTransaction("tx-1", "GA", precise.In(zone))
+	tx.UpdatedAt = precise.Add(time.Nanosecond)
+	tx.UserActionRequiredBy = precise.Add(time.Hour)
+	mustCreate(t, store, tx)
+	got, _ := store.GetByID("tx-1")
+	if !got.StartedAt.Equal(precise) || !got.UpdatedAt.Equal(tx.UpdatedAt) || !got.UserActionRequiredBy.Equal(tx.UserActionRequiredBy) {
+		t.Fatalf("expected nanosecond times to round-trip, got started_at=%v updated_at=%v user_action_required_by=%v",
+			got.StartedAt, got.UpdatedAt, got.UserActionRequiredBy)
+	}
+
+	// One nanosecond apart must still order and filter correctly.
+	mustCreate(t, store, sampleTransaction("tx-0", "GA", precise.Add(time.Nanosecond)))
+	expectIDs(t, "nanosecond order", store.ListByAccount(db.TransactionQuery{Account: "GA"}), "tx-0", "tx-1")
+	expectIDs(t, "inclusive no_older_than", store.ListByAccount(db.TransactionQuery{Account: "GA", NoOlderThan: precise}), "tx-0", "tx-1")
+	expectIDs(t, "exclusive no_older_than", store.ListByAccount(db.TransactionQuery{Account: "GA", NoOlderThan: precise.Add(time.Nanosecond)}), "tx-0")
+
+	mustCreate(t, store, sampleTransaction("tx-zero", "GB", time.Time{}))
+	if got, ok := store.GetByID("tx-zero"); !ok || !got.StartedAt.IsZero() {
+		t.Fatalf("expected a zero started_at to round-trip, got %v", got.StartedAt)
+	}
+}
+
+// testConcurrentUpdates races writers on one transaction. Each retries on
+// ErrConflict, so no increment may be lost, and exactly one compare-and-set
+// of a shared status may win.
+func testConcurrentUpdates(t *testing.T, store db.TransactionStore) {
+	const workers = 8
+	const rounds = 10
+	mustCreate(t, store, sampleTransaction("counter", "GA", start))
+	mustCreate(t, store, sampleTransaction("cas", "GA", start))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	wins := 0
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				for {
+					tx, ok := store.GetByID("counter")
+					if !ok {
+						fail(fmt.Errorf("worker %d: lost the counter transaction", w))
+						return
+					}
+					n, _ := strconv.Atoi(tx.Message)
+					tx.Message = strconv.Itoa(n + 1)
+					_, err := store.Update(tx, testAudit)
+					if errors.Is(err, db.ErrConflict) {
+						continue
+					}
+					if err != nil {
+						fail(err)
+						return
+					}
+					break
+				}
+			}
+			_, err := store.UpdateStatus("cas", "incomplete", fmt.Sprintf("status-%d", w), start, testAudit)
+			switch {
+			case err == nil:
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			case !errors.Is(err, db.ErrConflict):
+				fail(err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	counter, _ := store.GetByID("counter")
+	if counter.Message != strconv.Itoa(workers*rounds) || counter.Version != workers*rounds {
+		t.Fatalf("expected %d increments with no lost updates, got message %q version %d", workers*rounds, counter.Message, counter.Version)
+	}
+	if wins != 1 {
+		t.Fatalf("expected exactly one compare-and-set to win, got %d", wins)
+	}
+	if cas, _ := store.GetByID("cas"); cas.Status == "incomplete" || cas.Version != 1 {
+		t.Fatalf("expected the winning status to be stored once, got %+v", cas)
+	}
+}
+
+func testHistory(t *testing.T, store db.TransactionStore) {
+	if _, err := store.History("missing"); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("expected History of a missing id to fail with ErrNotFound, got %v", err)
+	}
+	tx := sampleTransaction("tx-1", "GA", start)
+	mustCreate(t, store, tx)
+	tx, _ = store.GetByID("tx-1")
+	stale := tx
+	tx.AmountIn = decimal.MustParse("100")
+	tx.UpdatedAt = start.Add(time.Minute)
+	if _, err := store.Update(tx, db.Audit{Source: db.SourcePlatform, Reason: "funds received"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := store.Update(stale, testAudit); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected a stale update to conflict, got %v", err)
+	}
+	if _, err := store.UpdateStatus("tx-1", "incomplete", "completed", start.Add(2*time.Minute), db.Audit{Source: db.SourceObserver}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+
+	history, err := store.History("tx-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected one entry per successful write, got %+v", history)
+	}
+	created, funded, completed := history[0], history[1], history[2]
+	if created.TransactionID != "tx-1" || created.Version != 0 || created.Audit != testAudit || !created.At.Equal(start) {
+		t.Fatalf("unexpected create entry: %+v", created)
+	}
+	if status := change(created, "status"); status == nil || len(status.Before) != 0 || string(status.After) != `"incomplete"` {
+		t.Fatalf("expected the create entry to record the initial status, got %+v", created.Changes)
+	}
+	if funded.Version != 1 || funded.Source != db.SourcePlatform || funded.Reason != "funds received" || !funded.At.Equal(start.Add(time.Minute)) ||
+		len(funded.Changes) != 1 || funded.Changes[0].Field != "amount_in" || string(funded.Changes[0].After) != `"100"` {
+		t.Fatalf("unexpected update entry: %+v", funded)
+	}
+	status := change(completed, "status")
+	if completed.Version != 2 || completed.Source != db.SourceObserver || len(completed.Changes) != 1 || status == nil ||
+		string(status.Before) != `"incomplete"` || string(status.After) != `"completed"` {
+		t.Fatalf("unexpected status entry: %+v", completed)
+	}
+	if got, _ := store.GetByID("tx-1"); got.Message != tx.Message {
+		t.Fatalf("expected the audit reason to stay out of the transaction, got message %q", got.Message)
+	}
+
+	history[0].Reason = "rewritten"
+	if again, _ := store.History("tx-1"); again[0].Reason != testAudit.Reason {
+		t.Fatal("expected History to return a copy the caller cannot rewrite")
+	}
+}
+
+func testCustomerPutAndGet(t *testing.T, store db.CustomerStore) {
+	customer := db.Customer{
+		ID: "c-1", Account: "GA", Memo: "1", Status: "ACCEPTED",
+		Fields:    map[string]string{"first_name": "Ada"},
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 1, time.UTC),
+	}
+	if err := store.Put(customer); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	for name, lookup := range map[string]func() (db.Customer, bool){
+		"by id":           func() (db.Customer, bool) { return store.GetByID("c-1") },
+		"by account memo": func() (db.Customer, bool) { return store.Get("GA", "1") },
+	} {
+		got, ok := lookup()
+		if !ok || got.ID != "c-1" || got.Fields["first_name"] != "Ada" || !got.CreatedAt.Equal(customer.CreatedAt) {
+			t.Fatalf("%s: unexpected customer %+v %v", name, got, ok)
+		}
+	}
+	if _, ok := store.Get("GA", ""); ok {
+		t.Fatal("expected the memo to be part of the customer key")
+	}
+	if _, ok := store.GetByID("missing"); ok {
+		t.Fatal("expected a missing customer not to be found")
+	}
+	if err := store.Put(db.Customer{Account: "GA"}); err == nil {
+		t.Fatal("expected a customer without an id to be rejected")
+	}
+
+	customer.Status = "REJECTED"
+	customer.Memo = "2"
+	if err := store.Put(customer); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got, ok := store.Get("GA", "2"); !ok || got.Status != "REJECTED" {
+		t.Fatalf("expected the update to be stored, got %+v", got)
+	}
+	if _, ok := store.Get("GA", "1"); ok {
+		t.Fatal("expected the old memo to stop resolving")
+	}
+}
+
+func testCustomerUniqueness(t *testing.T, store db.CustomerStore) {
+	if err := store.Put(db.Customer{ID: "c-1", Account: "GA", Memo: "1"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := store.Put(db.Customer{ID: "c-2", Account: "GA", Memo: "1"}); err == nil {
+		t.Fatal("expected a second customer for the same account and memo to be rejected")
+	}
+	if err := store.Put(db.Customer{ID: "c-2", Account: "GA", Memo: "2"}); err != nil {
+		t.Fatalf("expected another memo on the same account to be accepted: %v", err)
+	}
+}
+
+func testCustomerDelete(t *testing.T, store db.CustomerStore) {
+	if err := store.Put(db.Customer{ID: "c-1", Account: "GA"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := store.Delete("c-1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := store.GetByID("c-1"); ok {
+		t.Fatal("expected the customer to be deleted")
+	}
+	if _, ok := store.Get("GA", ""); ok {
+		t.Fatal("expected the account lookup to forget the customer")
+	}
+	if err := store.Put(db.Customer{ID: "c-2", Account: "GA"}); err != nil {
+		t.Fatalf("expected the account and memo to be free after delete: %v", err)
+	}
+}
+
+func testCustomerList(t *testing.T, store db.CustomerStore) {
+	if got := store.List(); len(got) != 0 {
+		t.Fatalf("expected an empty store to list nothing, got %d", len(got))
+	}
+	for _, id := range []string{"c-2", "c-1", "c-3"} {
+		customer := db.Customer{ID: id, Account: "G" + id, Fields: map[string]string{"first_name": id}}
+		if err := store.Put(customer); err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+	}
+	if err := store.Delete("c-3"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	got := store.List()
+	if len(got) != 2 || got[0].ID != "c-1" || got[1].ID != "c-2" || got[1].Fields["first_name"] != "c-2" {
+		t.Fatalf("expected c-1 and c-2 in id order, got %+v", got)
+	}
+}
+
+func testCustomerReplace(t *testing.T, store db.CustomerStore) {
+	customer := db.Customer{ID: "c-1", Account: "GA", Status: "NEEDS_INFO", Fields: map[string]string{"first_name": "Ada"}, UpdatedAt: start}
+	if err := store.Replace(customer, start); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected replacing a missing customer to conflict, got %v", err)
+	}
+	if err := store.Put(customer); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	updated := customer
+	updated.Status = "ACCEPTED"
+	updated.UpdatedAt = start.Add(time.Minute)
+	if err := store.Put(updated); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	stale := customer
+	stale.Fields = map[string]string{"first_name": "Grace"}
+	if err := store.Replace(stale, start); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected a stale replace to conflict, got %v", err)
+	}
+	if got, _ := store.GetByID("c-1"); got.Status != "ACCEPTED" || got.Fields["first_name"] != "Ada" {
+		t.Fatalf("expected the newer customer to survive, got %+v", got)
+	}
+	updated.Fields = map[string]string{"first_name": "Grace"}
+	if err := store.Replace(updated, updated.UpdatedAt); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got, _ := store.GetByID("c-1"); got.Fields["first_name"] != "Grace" {
+		t.Fatalf("expected the replace to be stored, got %+v", got)
+	}
+}
+
+func testQuoteCreateGetAndUpdate(t *testing.T, store db.QuoteStore) {
+	quote := db.Quote{
+		ID: "q-1", Account: "GA", SellAsset: "iso4217:USD", SellAmount: decimal.MustParse("100"),
+		BuyAsset: "stellar:USDC", BuyAmount: decimal.MustParse("99.5"), Price: decimal.MustParse("1.005"),
+		CreatedAt: start, ExpiresAt: start.Add(time.Minute),
+	}
+	if err := store.Create(quote); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	got, ok := store.GetByID("q-1")
+	if !ok || !got.BuyAmount.Equal(quote.BuyAmount) || !got.ExpiresAt.Equal(quote.ExpiresAt) || got.TransactionID != "" {
+		t.Fatalf("unexpected quote %+v %v", got, ok)
+	}
+	if _, ok := store.GetByID("missing"); ok {
+		t.Fatal("expected a missing quote not to be found")
+	}
+	got.TransactionID = "tx-1"
+	if err := store.Update(got); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got, _ := store.GetByID("q-1"); got.TransactionID != "tx-1" {
+		t.Fatalf("expected the update to be stored, got %+v", got)
+	}
+}
+
+func testQuoteBindOnce(t *testing.T, store db.QuoteStore) {
+	if err := store.Create(db.Quote{ID: "q-1", Account: "GA", CreatedAt: start, ExpiresAt: start.Add(time.Minute)}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := store.Bind("missing", "tx-1"); err == nil {
+		t.Fatal("expected binding a missing quote to fail")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = store.Bind("q-1", fmt.Sprintf("tx-%d", i))
+		}()
+	}
+	wg.Wait()
+	bound := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			bound++
+		case !errors.Is(err, db.ErrConflict):
+			t.Fatalf("expected ErrConflict, got %v", err)
+		}
+	}
+	got, _ := store.GetByID("q-1")
+	if bound != 1 || got.TransactionID == "" {
+		t.Fatalf("expected exactly one bind to win, got %d and %+v", bound, got)
+	}
+	if err := store.Bind("q-1", got.TransactionID); err != nil {
+		t.Fatalf("expected rebinding the same transaction to succeed: %v", err)
+	}
+
+	if err := store.Unbind("q-1", "tx-other"); err != nil {
+		t.Fatalf("unbind: %v", err)
+	}
+	if current, _ := store.GetByID("q-1"); current.TransactionID != got.TransactionID {
+		t.Fatalf("expected unbind by another transaction to be ignored, got %+v", current)
+	}
+	if err := store.Unbind("q-1", got.TransactionID); err != nil {
+		t.Fatalf("unbind: %v", err)
+	}
+	if err := store.Bind("q-1", "tx-next"); err != nil {
+		t.Fatalf("expected a released quote to be bindable: %v", err)
+	}
+}
+
+func testBlobPutGetAndDelete(t *testing.T, store db.BlobStore) {
+	blob := db.Blob{ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G', 0x00}}
+	if err := store.Put("c-1/photo_id_front", blob); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	got, ok := store.Get("c-1/photo_id_front")
+	if !ok || got.ContentType != blob.ContentType || string(got.Data) != string(blob.Data) {
+		t.Fatalf("unexpected blob %+v %v", got, ok)
+	}
+	if err := store.Put("c-1/photo_id_front", db.Blob{ContentType: "image/jpeg", Data: []byte("jpeg")}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got, _ := store.Get("c-1/photo_id_front"); got.ContentType != "image/jpeg" || string(got.Data) != "jpeg" {
+		t.Fatalf("expected the blob to be replaced, got %+v", got)
+	}
+	if err := store.Delete("c-1/photo_id_front"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := store.Get("c-1/photo_id_front"); ok {
+		t.Fatal("expected the blob to be deleted")
+	}
+}
+
+func testBlobReplace(t *testing.T, store db.BlobStore) {
+	original := db.Blob{ContentType: "image/png", Data: []byte("png")}
+	if err := store.Replace("c-1/photo_id_front", original, original); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected replacing a missing blob to conflict, got %v", err)
+	}
+	if err := store.Put("c-1/photo_id_front", original); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	uploaded := db.Blob{ContentType: "image/jpeg", Data: []byte("jpeg")}
+	if err := store.Put("c-1/photo_id_front", uploaded); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := store.Replace("c-1/photo_id_front", original, db.Blob{ContentType: "image/png", Data: []byte("stale")}); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected a stale replace to conflict, got %v", err)
+	}
+	if got, _ := store.Get("c-1/photo_id_front"); string(got.Data) != "jpeg" {
+		t.Fatalf("expected the newer blob to survive, got %+v", got)
+	}
+	if err := store.Replace("c-1/photo_id_front", uploaded, db.Blob{ContentType: "image/jpeg", Data: []byte("jpeg2")}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got, _ := store.Get("c-1/photo_id_front"); string(got.Data) != "jpeg2" {
+		t.Fatalf("expected the replace to be stored, got %+v", got)
+	}
+}
+
+func sampleTransaction(id, account string, startedAt time.Time) db.Transaction {
+	return db.Transaction{
+		ID:        id,
+		Protocol:  "sep24",
+		Kind:      "deposit",
+		Status:    "incomplete",
+		Account:   account,
+		AssetCode: "USDC",
+		Amount:    decimal.MustParse("100.25"),
+		FeeDetails: &db.FeeDetails{
+			Total: decimal.MustParse("1.5"),
+			Asset: "stellar:USDC",
+		},
+		KYCFields: []string{"first_name"},
+		StartedAt: startedAt,
+		UpdatedAt: startedAt,
+	}
+}
+
+func mustCreate(t *testing.T, store db.TransactionStore, tx db.Transaction) {
+	t.Helper()
+	if err := store.Create(tx, testAudit); err != nil {
+		t.Fatalf("create %s: %v", tx.ID, err)
+	}
+}
+
+func mustUpdate(t *testing.T, store db.TransactionStore, tx db.Transaction) db.Transaction {
+	t.Helper()
+	updated, err := store.Update(tx, testAudit)
+	if err != nil {
+		t.Fatalf("update %s: %v", tx.ID, err)
+	}
+	return updated
+}
+
+func change(entry db.HistoryEntry, field string) *db.FieldChange {
+	for i := range entry.Changes {
+		if entry.Changes[i].Field == field {
+			return &entry.Changes[i]
+		}
+	}
+	return nil
+}
+
+func expectIDs(t *testing.T, name string, got []db.Transaction, want ...string) {
+	t.Helper()
+	if fmt.Sprint(ids(got)) != fmt.Sprint(want) {
+		t.Fatalf("%s: expected %v, got %v", name, want, ids(got))
+	}
+}
+
+func ids(txs []db.Transaction) []string {
+	out := []string{}
+	for _, tx := range txs {
+		out = append(out, tx.ID)
+	}
+	return out
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/keyring"
+)
+
+// EncryptedCustomerStore seals each customer's SEP-9 values and verification
+// codes before they reach the wrapped store. The envelope is bound to the
+// customer id, so it cannot be moved onto another customer's record.
+type EncryptedCustomerStore struct {
+	inner CustomerStore
+	keys  keyring.KeyProvider
+}
+
+type sealedFields struct {
+	Fields            map[string]string `json:"fields,omitempty"`
+	VerificationCodes map[string]string `json:"verification_codes,omitempty"`
+}
+
+func NewEncryptedCustomerStore(inner CustomerStore, keys keyring.KeyProvider) *EncryptedCustomerStore {
+	return &EncryptedCustomerStore{inner: inner, keys: keys}
+}
+
+func (s *EncryptedCustomerStore) Put(customer Customer) error {
+	if customer.ID == "" {
+		return fmt.Errorf("customer id is required")
+	}
+	sealed, err := s.seal(customer)
+	if err != nil {
+		return err
+	}
+	return s.inner.Put(sealed)
+}
+
+func (s *EncryptedCustomerStore) Replace(customer Customer, updatedAt time.Time) error {
+	sealed, err := s.seal(customer)
+	if err != nil {
+		return err
+	}
+	return s.inner.Replace(sealed, updatedAt)
+}
+
+func (s *EncryptedCustomerStore) Get(account, memo string) (Customer, bool) {
+	customer, ok := s.inner.Get(account, memo)
+	if !ok {
+		return Customer{}, false
+	}
+	return s.openLogged(customer)
+}
+
+func (s *EncryptedCustomerStore) GetByID(id string) (Customer, bool) {
+	customer, ok := s.inner.GetByID(id)
+	if !ok {
+		return Customer{}, false
+	}
+	return s.openLogged(customer)
+}
+
+func (s *EncryptedCustomerStore) List() []Customer {
+	stored := s.inner.List()
+	items := make([]Customer, 0, len(stored))
+	for _, customer := range stored {
+		if customer, ok := s.openLogged(customer); ok {
+			items = append(items, customer)
+		}
+	}
+	return items
+}
+
+func (s *EncryptedCustomerStore) Delete(id string) error {
+	return s.inner.Delete(id)
+}
+
+// Reencrypt moves every customer onto the primary key and returns how many
+// it rewrote. Customers sealed under an older key only have their data key
+// re-wrapped; customers stored before encryption was enabled are sealed.
+func (s *EncryptedCustomerStore) Reencrypt() (int, error) {
+	rewritten := 0
+	for _, customer := range s.inner.List() {
+		var err error
+		switch {
+		case customer.Sealed == nil:
+			customer, err = s.seal(customer)
+		case customer.Sealed.KeyID != s.keys.PrimaryKeyID():
+			var env keyring.Envelope
+			env, err = keyring.Rewrap(s.keys, *customer.Sealed)
+			customer.Sealed = &env
+		default:
+			continue
+		}
+		if err != nil {
+			return rewritten, fmt.Errorf("reencrypt customer %s: %w", customer.ID, err)
+		}
+		err = s.inner.Replace(customer, customer.UpdatedAt)
+		if errors.Is(err, ErrConflict) {
+			log.Printf("db: customer %s changed during re-encryption, skipped", customer.ID)
+			continue
+		}
+		if err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+func (s *EncryptedCustomerStore) seal(customer Customer) (Customer, error) {
+	plaintext, err := json.Marshal(sealedFields{Fields: customer.Fields, VerificationCodes: customer.VerificationCodes})
+	if err != nil {
+		return customer, fmt.Errorf("encode customer %s: %w", customer.ID, err)
+	}
+	env, err := keyring.Seal(s.keys, plaintext, []byte(customer.ID))
+	if err != nil {
+		return customer, fmt.Errorf("seal customer %s: %w", customer.ID, err)
+	}
+	customer.Fields = nil
+	customer.VerificationCodes = nil
+	customer.Sealed = &env
+	return customer, nil
+}
+
+// open reverses seal. Customers stored before encryption was enabled are
+// returned as they are until Reencrypt seals them.
+func (s *EncryptedCustomerStore) open(customer Customer) (Customer, error) {
+	if customer.Sealed == nil {
+		return customer, nil
+	}
+	plaintext, err := keyring.Open(s.keys, *customer.Sealed, []byte(customer.ID))
+	if err != nil {
+		return Customer{}, fmt.Errorf("open customer %s: %w", customer.ID, err)
+	}
+	var fields sealedFields
+	if err := json.Unmarshal(plaintext, &fields); err != nil {
+		return Customer{}, fmt.Errorf("decode customer %s: %w", customer.ID, err)
+	}
+	customer.Fields = fields.Fields
+	customer.VerificationCodes = fields.VerificationCodes
+	customer.Sealed = nil
+	return customer, nil
+}
+
+// openLogged is open for the lookup methods, which have no error return.
+func (s *EncryptedCustomerStore) openLogged(customer Customer) (Customer, bool) {
+	customer, err := s.open(customer)
+	if err != nil {
+		log.Printf("db: %v", err)
+		return Customer{}, false
+	}
+	return customer, true
+}
+
+// SealedBlobContentType marks a blob stored by an EncryptedBlobStore.
+const SealedBlobContentType = "application/vnd.sep-reference.sealed+json"
+
+// EncryptedBlobStore seals binary customer fields, bound to their key.
+type EncryptedBlobStore struct {
+	inner BlobStore
+	keys  keyring.KeyProvider
+}
+
+type sealedBlob struct {
+	ContentType string           `json:"content_type"`
+	Envelope    keyring.Envelope `json:"envelope"`
+}
+
+func NewEncryptedBlobStore(inner BlobStore, keys keyring.KeyProvider) *EncryptedBlobStore {
+	return &EncryptedBlobStore{inner: inner, keys: keys}
+}
+
+func (s *EncryptedBlobStore) Put(key string, blob Blob) error {
+	env, err := keyring.Seal(s.keys, blob.Data, []byte(key))
+	if err != nil {
+		return fmt.Errorf("seal blob %s: %w", key, err)
+	}
+	sealed, err := sealBlob(key, blob.ContentType, env)
+	if err != nil {
+		return err
+	}
+	return s.inner.Put(key, sealed)
+}
+
+func (s *EncryptedBlobStore) Replace(key string, old, blob Blob) error {
+	stored, ok := s.inner.Get(key)
+	if current, opened := s.Get(key); !ok || !opened || current.ContentType != old.ContentType || !bytes.Equal(current.Data, old.Data) {
+		return fmt.Errorf("%w: blob %s changed since it was read", ErrConflict, key)
+	}
+	env, err := keyring.Seal(s.keys, blob.Data, []byte(key))
+	if err != nil {
+		return fmt.Errorf("seal blob %s: %w", key, err)
+	}
+	sealed, err := sealBlob(key, blob.ContentType, env)
+	if err != nil {
+		return err
+	}
+	return s.inner.Replace(key, stored, sealed)
+}
+
+func (s *EncryptedBlobStore) Get(key string) (Blob, bool) {
+	blob, ok := s.inner.Get(key)
+	if !ok || blob.ContentType != SealedBlobContentType {
+		return blob, ok
+	}
+	var sealed sealedBlob
+	if err := json.Unmarshal(blob.Data, &sealed); err != nil {
+		log.Printf("db: decode blob %s: %v", key, err)
+		return Blob{}, false
+	}
+	data, err := keyring.Open(s.keys, sealed.Envelope, []byte(key))
+	if err != nil {
+		log.Printf("db: open blob %s: %v", key, err)
+		return Blob{}, false
+	}
+	return Blob{ContentType: sealed.ContentType, Data: data}, true
+}
+
+func (s *EncryptedBlobStore) Delete(key string) error {
+	return s.inner.Delete(key)
+}
+
+// Reencrypt moves the blobs stored under keys onto the primary key and
+// returns how many it rewrote.
+func (s *EncryptedBlobStore) Reencrypt(keys []string) (int, error) {
+	rewritten := 0
+	for _, key := range keys {
+		stored, ok := s.inner.Get(key)
+		if !ok {
+			continue
+		}
+		blob := stored
+		var err error
+		if blob.ContentType != SealedBlobContentType {
+			var env keyring.Envelope
+			if env, err = keyring.Seal(s.keys, blob.Data, []byte(key)); err == nil {
+				blob, err = sealBlob(key, blob.ContentType, env)
+			}
+		} else {
+			var sealed sealedBlob
+			if err = json.Unmarshal(blob.Data, &sealed); err == nil {
+				if sealed.Envelope.KeyID == s.keys.PrimaryKeyID() {
+					continue
+				}
+				if sealed.Envelope, err = keyring.Rewrap(s.keys, sealed.Envelope); err == nil {
+					blob, err = sealBlob(key, sealed.ContentType, sealed.Envelope)
+				}
+			}
+		}
+		if err != nil {
+			return rewritten, fmt.Errorf("reencrypt blob %s: %w", key, err)
+		}
+		err = s.inner.Replace(key, stored, blob)
+		if errors.Is(err, ErrConflict) {
+			log.Printf("db: blob %s changed during re-encryption, skipped", key)
+			continue
+		}
+		if err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+func sealBlob(key, contentType string, env keyring.Envelope) (Blob, error) {
+	data, err := json.Marshal(sealedBlob{ContentType: contentType, Envelope: env})
+	if err != nil {
+		return Blob{}, fmt.Errorf("encode blob %s: %w", key, err)
+	}
+	return Blob{ContentType: SealedBlobContentType, Data: data}, nil
+}
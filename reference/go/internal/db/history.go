@@ -0,0 +1,60 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// historyEntry describes the write that turns before into after; before is
+// nil for Create. version and updated_at change on every write and are
+// recorded on the entry itself rather than as field changes.
+func historyEntry(before *Transaction, after Transaction, audit Audit) (HistoryEntry, error) {
+	next, err := transactionFields(after)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	previous := map[string]json.RawMessage{}
+	if before != nil {
+		if previous, err = transactionFields(*before); err != nil {
+			return HistoryEntry{}, err
+		}
+	}
+	keys := make([]string, 0, len(next))
+	for key := range next {
+		keys = append(keys, key)
+	}
+	for key := range previous {
+		if _, ok := next[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]FieldChange, 0)
+	for _, key := range keys {
+		if key == "version" || key == "updated_at" || bytes.Equal(previous[key], next[key]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: key, Before: previous[key], After: next[key]})
+	}
+	at := after.UpdatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return HistoryEntry{TransactionID: after.ID, Version: after.Version, At: at.UTC(), Audit: audit, Changes: changes}, nil
+}
+
+func transactionFields(tx Transaction) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("encode transaction %s: %w", tx.ID, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("encode transaction %s: %w", tx.ID, err)
+	}
+	return fields, nil
+}
@@ -1,41 +1,270 @@
 package db
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/keyring"
+)
+
+var (
+	ErrNotFound  = errors.New("transaction not found")
+	ErrDuplicate = errors.New("transaction already exists")
+	// ErrConflict means the record changed since it was read.
+	ErrConflict = errors.New("transaction was modified concurrently")
+)
 
 type Transaction struct {
-	ID                    string    `json:"id"`
-	Kind                  string    `json:"kind"`
-	Status                string    `json:"status"`
-	Account               string    `json:"account"`
-	From                  string    `json:"from,omitempty"`
-	To                    string    `json:"to,omitempty"`
-	AssetCode             string    `json:"asset_code"`
-	Amount                string    `json:"amount,omitempty"`
-	AmountIn              string    `json:"amount_in,omitempty"`
-	AmountOut             string    `json:"amount_out,omitempty"`
-	AmountFee             string    `json:"amount_fee,omitempty"`
-	StellarTransactionID  string    `json:"stellar_transaction_id,omitempty"`
-	ExternalTransactionID string    `json:"external_transaction_id,omitempty"`
-	URL                   string    `json:"url,omitempty"`
-	StartedAt             time.Time `json:"started_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
-	KYCFields             []string  `json:"kyc_fields,omitempty"`
+	ID                        string          `json:"id"`
+	Protocol                  string          `json:"protocol,omitempty"`
+	Kind                      string          `json:"kind"`
+	Status                    string          `json:"status"`
+	Account                   string          `json:"account"`
+	From                      string          `json:"from,omitempty"`
+	To                        string          `json:"to,omitempty"`
+	AssetCode                 string          `json:"asset_code"`
+	AssetIssuer               string          `json:"asset_issuer,omitempty"`
+	Amount                    decimal.Decimal `json:"amount,omitzero"`
+	AmountIn                  decimal.Decimal `json:"amount_in,omitzero"`
+	AmountInAsset             string          `json:"amount_in_asset,omitempty"`
+	AmountOut                 decimal.Decimal `json:"amount_out,omitzero"`
+	AmountOutAsset            string          `json:"amount_out_asset,omitempty"`
+	AmountFee                 decimal.Decimal `json:"amount_fee,omitzero"`
+	QuoteID                   string          `json:"quote_id,omitempty"`
+	FeeDetails                *FeeDetails     `json:"fee_details,omitempty"`
+	StellarTransactionID      string          `json:"stellar_transaction_id,omitempty"`
+	ExternalTransactionID     string          `json:"external_transaction_id,omitempty"`
+	WithdrawAnchorAccount     string          `json:"withdraw_anchor_account,omitempty"`
+	WithdrawMemo              string          `json:"withdraw_memo,omitempty"`
+	WithdrawMemoType          string          `json:"withdraw_memo_type,omitempty"`
+	ClaimableBalanceSupported bool            `json:"claimable_balance_supported,omitempty"`
+	ClaimableBalanceID        string          `json:"claimable_balance_id,omitempty"`
+	Refunds                   *Refunds        `json:"refunds,omitempty"`
+	URL                       string          `json:"url,omitempty"`
+	StartedAt                 time.Time       `json:"started_at"`
+	UpdatedAt                 time.Time       `json:"updated_at"`
+	UserActionRequiredBy      time.Time       `json:"user_action_required_by,omitzero"`
+	KYCFields                 []string        `json:"kyc_fields,omitempty"`
+	SenderID                  string          `json:"sender_id,omitempty"`
+	ReceiverID                string          `json:"receiver_id,omitempty"`
+	ClientDomain              string          `json:"client_domain,omitempty"`
+	CallbackURL               string          `json:"callback_url,omitempty"`
+	Message                   string          `json:"message,omitempty"`
+	FundingMethod             string          `json:"funding_method,omitempty"`
+	// RequestHash fingerprints the request that created the transaction, so
+	// a retry can be told apart from an idempotency key reused for another
+	// request.
+	RequestHash string `json:"request_hash,omitempty"`
+	// PaymentEnvelope is the signed outgoing payment, recorded before it is
+	// broadcast.
+	PaymentEnvelope   string    `json:"payment_envelope,omitempty"`
+	PaymentValidUntil time.Time `json:"payment_valid_until,omitzero"`
+	// Version counts the writes since Create; Update only succeeds when it
+	// matches the stored version.
+	Version int64 `json:"version"`
 }
 
+type FeeDetails struct {
+	Total   decimal.Decimal `json:"total"`
+	Asset   string          `json:"asset"`
+	Details []FeeDetail     `json:"details,omitempty"`
+}
+
+type FeeDetail struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+type Refunds struct {
+	AmountRefunded decimal.Decimal `json:"amount_refunded"`
+	AmountFee      decimal.Decimal `json:"amount_fee"`
+	Payments       []RefundPayment `json:"payments"`
+}
+
+type RefundPayment struct {
+	ID     string          `json:"id"`
+	IDType string          `json:"id_type"`
+	Amount decimal.Decimal `json:"amount"`
+	Fee    decimal.Decimal `json:"fee"`
+}
+
+// TransactionQuery selects an account's transactions for ListByAccount.
+// Results are ordered newest first by started_at, then by id descending;
+// zero-valued fields do not filter.
+type TransactionQuery struct {
+	Account     string
+	Protocol    string
+	Kinds       []string
+	AssetCode   string
+	AssetIssuer string
+	NoOlderThan time.Time
+	// Cursor is the id of the last transaction already seen; the page starts
+	// strictly after it. An unknown cursor yields no results.
+	Cursor string
+	Limit  int
+}
+
+// StatusQuery selects the transactions in one status for ListByStatus.
+// Results are ordered least recently updated first, then by id; zero-valued
+// fields do not filter.
+type StatusQuery struct {
+	Status   string
+	Protocol string
+	Kinds    []string
+	// After is the position of the last transaction already seen; the page
+	// starts strictly after it.
+	After StatusCursor
+	Limit int
+}
+
+// StatusCursor is a position in a ListByStatus listing.
+type StatusCursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// TransactionStore writes optimistically: Create rejects an existing id
+// with ErrDuplicate, and Update and UpdateStatus return ErrConflict when the
+// stored transaction no longer matches what the caller read. Every write is
+// recorded, with its Audit, in the transaction's append-only history.
 type TransactionStore interface {
-	Create(tx Transaction) error
+	Create(tx Transaction, audit Audit) error
 	GetByID(id string) (Transaction, bool)
-	ListByAccount(account string, limit int, cursor string) []Transaction
-	Update(tx Transaction) error
-	UpdateStatus(id string, status string, updatedAt time.Time) (Transaction, bool)
+	// ListByExternalID and ListByStellarTxID return every match, newest first.
+	ListByExternalID(externalID string) []Transaction
+	ListByStellarTxID(hash string) []Transaction
+	// GetByMemo finds the transaction whose withdraw memo is memo.
+	GetByMemo(memo, memoType string) (Transaction, bool)
+	ListByAccount(query TransactionQuery) []Transaction
+	ListByStatus(query StatusQuery) []Transaction
+	// Update stores tx if tx.Version is still current and returns it with
+	// the new version.
+	Update(tx Transaction, audit Audit) (Transaction, error)
+	// UpdateStatus moves the transaction from status from to status to.
+	UpdateStatus(id, from, to string, updatedAt time.Time, audit Audit) (Transaction, error)
+	// History returns the transaction's writes, oldest first.
+	History(id string) ([]HistoryEntry, error)
+}
+
+// Sources of a transaction write.
+const (
+	SourceAPI       = "api"
+	SourceObserver  = "observer"
+	SourceAdmin     = "admin"
+	SourcePlatform  = "platform"
+	SourceSweeper   = "sweeper"
+	SourceSubmitter = "submitter"
+)
+
+// Audit says who made a write and why. Actor is the account or operator
+// responsible, when one is known.
+type Audit struct {
+	Actor  string `json:"actor,omitempty"`
+	Source string `json:"source"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// HistoryEntry is one write to a transaction. Version and At are the
+// transaction's version and updated_at after the write.
+type HistoryEntry struct {
+	TransactionID string    `json:"transaction_id"`
+	Version       int64     `json:"version"`
+	At            time.Time `json:"at"`
+	Audit
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a transaction field a write changed, named by its JSON
+// key. Before is empty for fields set by Create.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+type Quote struct {
+	ID                 string          `json:"id"`
+	Account            string          `json:"account"`
+	Context            string          `json:"context"`
+	SellAsset          string          `json:"sell_asset"`
+	SellAmount         decimal.Decimal `json:"sell_amount"`
+	SellDeliveryMethod string          `json:"sell_delivery_method,omitempty"`
+	BuyAsset           string          `json:"buy_asset"`
+	BuyAmount          decimal.Decimal `json:"buy_amount"`
+	BuyDeliveryMethod  string          `json:"buy_delivery_method,omitempty"`
+	CountryCode        string          `json:"country_code,omitempty"`
+	Price              decimal.Decimal `json:"price"`
+	TotalPrice         decimal.Decimal `json:"total_price"`
+	Fee                FeeDetails      `json:"fee"`
+	TransactionID      string          `json:"transaction_id,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	ExpiresAt          time.Time       `json:"expires_at"`
+}
+
+type QuoteStore interface {
+	Create(quote Quote) error
+	GetByID(id string) (Quote, bool)
+	Update(quote Quote) error
+	// Bind marks an unused quote as used by transactionID. It fails with
+	// ErrConflict if the quote is bound to another transaction.
+	Bind(id, transactionID string) error
+	// Unbind releases a quote bound to transactionID.
+	Unbind(id, transactionID string) error
 }
 
 type Customer struct {
-	Account string            `json:"account"`
-	Fields  map[string]string `json:"fields"`
+	ID                string            `json:"id"`
+	Account           string            `json:"account"`
+	Memo              string            `json:"memo,omitempty"`
+	MemoType          string            `json:"memo_type,omitempty"`
+	Status            string            `json:"status"`
+	Type              string            `json:"type,omitempty"`
+	Message           string            `json:"message,omitempty"`
+	Fields            map[string]string `json:"fields"`
+	Files             map[string]string `json:"files,omitempty"`
+	FieldStatus       map[string]string `json:"field_status,omitempty"`
+	VerificationCodes map[string]string `json:"verification_codes,omitempty"`
+	// Verifications tracks the expiry and attempts of each verification code.
+	Verifications map[string]Verification `json:"verifications,omitempty"`
+	CallbackURL   string                  `json:"callback_url,omitempty"`
+	// Sealed holds Fields and VerificationCodes when the customer is stored
+	// by an EncryptedCustomerStore; both maps are then nil at rest.
+	Sealed    *keyring.Envelope `json:"sealed,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+type Verification struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Attempts  int       `json:"attempts,omitempty"`
 }
 
+// CustomerStore keys customers by ID; an account and memo pair identifies at
+// most one customer.
 type CustomerStore interface {
 	Put(customer Customer) error
-	Get(account string) (Customer, bool)
+	// Replace stores customer if it is still the one updated at updatedAt.
+	Replace(customer Customer, updatedAt time.Time) error
+	Get(account, memo string) (Customer, bool)
+	GetByID(id string) (Customer, bool)
+	// List returns every customer ordered by id.
+	List() []Customer
+	Delete(id string) error
+}
+
+// Blob is a binary customer field such as an ID photo.
+type Blob struct {
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+type BlobStore interface {
+	Put(key string, blob Blob) error
+	// Replace stores blob if key still holds old.
+	Replace(key string, old, blob Blob) error
+	Get(key string) (Blob, bool)
+	Delete(key string) error
 }
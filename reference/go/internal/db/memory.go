@@ -1,6 +1,9 @@
 package db
 
 import (
+	"bytes"
+	"fmt"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -9,6 +12,13 @@ import (
 type MemoryTransactionStore struct {
 	mu  sync.RWMutex
 	txs map[string]Transaction
+	// byAccount holds each account's transaction ids in TransactionQuery
+	// order so a page is a binary search plus a bounded scan.
+	byAccount     map[string][]string
+	byExternalID  map[string][]string
+	byStellarTxID map[string][]string
+	byMemo        map[string]string
+	history       map[string][]HistoryEntry
 }
 
 type MemoryCustomerStore struct {
@@ -16,18 +26,52 @@ type MemoryCustomerStore struct {
 	customers map[string]Customer
 }
 
+type MemoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string]Blob
+}
+
+type MemoryQuoteStore struct {
+	mu     sync.RWMutex
+	quotes map[string]Quote
+}
+
 func NewMemoryTransactionStore() *MemoryTransactionStore {
-	return &MemoryTransactionStore{txs: map[string]Transaction{}}
+	return &MemoryTransactionStore{
+		txs:           map[string]Transaction{},
+		byAccount:     map[string][]string{},
+		byExternalID:  map[string][]string{},
+		byStellarTxID: map[string][]string{},
+		byMemo:        map[string]string{},
+		history:       map[string][]HistoryEntry{},
+	}
 }
 
 func NewMemoryCustomerStore() *MemoryCustomerStore {
 	return &MemoryCustomerStore{customers: map[string]Customer{}}
 }
 
-func (s *MemoryTransactionStore) Create(tx Transaction) error {
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: map[string]Blob{}}
+}
+
+func NewMemoryQuoteStore() *MemoryQuoteStore {
+	return &MemoryQuoteStore{quotes: map[string]Quote{}}
+}
+
+func (s *MemoryTransactionStore) Create(tx Transaction, audit Audit) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.txs[tx.ID] = tx
+	if _, exists := s.txs[tx.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicate, tx.ID)
+	}
+	tx.Version = 0
+	entry, err := historyEntry(nil, tx, audit)
+	if err != nil {
+		return err
+	}
+	s.put(tx)
+	s.history[tx.ID] = append(s.history[tx.ID], entry)
 	return nil
 }
 
@@ -38,57 +82,394 @@ func (s *MemoryTransactionStore) GetByID(id string) (Transaction, bool) {
 	return tx, ok
 }
 
-func (s *MemoryTransactionStore) ListByAccount(account string, limit int, cursor string) []Transaction {
+func (s *MemoryTransactionStore) ListByExternalID(externalID string) []Transaction {
+	return s.listIndexed(s.byExternalID, externalID)
+}
+
+func (s *MemoryTransactionStore) ListByStellarTxID(hash string) []Transaction {
+	return s.listIndexed(s.byStellarTxID, hash)
+}
+
+func (s *MemoryTransactionStore) GetByMemo(memo, memoType string) (Transaction, bool) {
+	return s.getIndexed(s.byMemo, memoKey(memo, memoType))
+}
+
+func (s *MemoryTransactionStore) getIndexed(index map[string]string, key string) (Transaction, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := index[key]
+	if key == "" || !ok {
+		return Transaction{}, false
+	}
+	tx, ok := s.txs[id]
+	return tx, ok
+}
+
+func (s *MemoryTransactionStore) listIndexed(index map[string][]string, key string) []Transaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key == "" {
+		return nil
+	}
+	var items []Transaction
+	for _, id := range index[key] {
+		items = append(items, s.txs[id])
+	}
+	sort.Slice(items, func(i, j int) bool { return listsBefore(items[i], items[j]) })
+	return items
+}
+
+func (s *MemoryTransactionStore) ListByAccount(query TransactionQuery) []Transaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.byAccount[query.Account]
+	start := 0
+	if query.Cursor != "" {
+		cursor, ok := s.txs[query.Cursor]
+		if !ok || cursor.Account != query.Account {
+			return nil
+		}
+		start = sort.Search(len(ids), func(i int) bool {
+			return listsBefore(cursor, s.txs[ids[i]])
+		})
+	}
+
+	var items []Transaction
+	for _, id := range ids[start:] {
+		tx := s.txs[id]
+		if !query.NoOlderThan.IsZero() && tx.StartedAt.Before(query.NoOlderThan) {
+			break
+		}
+		if !query.matches(tx) {
+			continue
+		}
+		items = append(items, tx)
+		if query.Limit > 0 && len(items) == query.Limit {
+			break
+		}
+	}
+	return items
+}
+
+func (s *MemoryTransactionStore) ListByStatus(query StatusQuery) []Transaction {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	items := make([]Transaction, 0, len(s.txs))
+	items := make([]Transaction, 0)
 	for _, tx := range s.txs {
-		if tx.Account == account {
+		if query.matches(tx) {
 			items = append(items, tx)
 		}
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].StartedAt.After(items[j].StartedAt)
+		return updatedBefore(items[i].UpdatedAt, items[i].ID, items[j])
 	})
 
+	limit := query.Limit
 	if limit <= 0 || limit > len(items) {
 		limit = len(items)
 	}
 	return items[:limit]
 }
 
-func (s *MemoryTransactionStore) Update(tx Transaction) error {
+func (s *MemoryTransactionStore) Update(tx Transaction, audit Audit) (Transaction, error) {
+	return s.write(tx.ID, audit, func(current Transaction) (Transaction, error) {
+		if current.Version != tx.Version {
+			return Transaction{}, fmt.Errorf("%w: %s is at version %d, not %d", ErrConflict, tx.ID, current.Version, tx.Version)
+		}
+		return tx, nil
+	})
+}
+
+func (s *MemoryTransactionStore) UpdateStatus(id, from, to string, updatedAt time.Time, audit Audit) (Transaction, error) {
+	return s.write(id, audit, func(tx Transaction) (Transaction, error) {
+		if tx.Status != from {
+			return Transaction{}, fmt.Errorf("%w: %s is %s, not %s", ErrConflict, id, tx.Status, from)
+		}
+		tx.Status = to
+		tx.UpdatedAt = updatedAt
+		return tx, nil
+	})
+}
+
+func (s *MemoryTransactionStore) History(id string) ([]HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.txs[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return slices.Clone(s.history[id]), nil
+}
+
+// write replaces the transaction with mutate's result under the lock and
+// appends the write to its history.
+func (s *MemoryTransactionStore) write(id string, audit Audit, mutate func(Transaction) (Transaction, error)) (Transaction, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	current, ok := s.txs[id]
+	if !ok {
+		return Transaction{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	tx, err := mutate(current)
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx.ID = id
+	tx.Version = current.Version + 1
+	entry, err := historyEntry(&current, tx, audit)
+	if err != nil {
+		return Transaction{}, err
+	}
+	s.put(tx)
+	s.history[id] = append(s.history[id], entry)
+	return tx, nil
+}
+
+// put stores tx and keeps the account and identifier indexes in step with
+// it. Callers hold s.mu.
+func (s *MemoryTransactionStore) put(tx Transaction) {
+	old, exists := s.txs[tx.ID]
+	if exists {
+		unindexAll(s.byExternalID, old.ExternalTransactionID, old.ID)
+		unindexAll(s.byStellarTxID, old.StellarTransactionID, old.ID)
+		unindex(s.byMemo, memoKey(old.WithdrawMemo, old.WithdrawMemoType), old.ID)
+	}
+	indexAll(s.byExternalID, tx.ExternalTransactionID, tx.ID)
+	indexAll(s.byStellarTxID, tx.StellarTransactionID, tx.ID)
+	index(s.byMemo, memoKey(tx.WithdrawMemo, tx.WithdrawMemoType), tx.ID)
+
+	if exists && old.Account == tx.Account && old.StartedAt.Equal(tx.StartedAt) {
+		s.txs[tx.ID] = tx
+		return
+	}
+	if exists {
+		ids := s.byAccount[old.Account]
+		i := sort.Search(len(ids), func(i int) bool { return !listsBefore(s.txs[ids[i]], old) })
+		s.byAccount[old.Account] = append(ids[:i], ids[i+1:]...)
+	}
 	s.txs[tx.ID] = tx
+	ids := s.byAccount[tx.Account]
+	i := sort.Search(len(ids), func(i int) bool { return !listsBefore(s.txs[ids[i]], tx) })
+	s.byAccount[tx.Account] = slices.Insert(ids, i, tx.ID)
+}
+
+func index(m map[string]string, key, id string) {
+	if key != "" {
+		m[key] = id
+	}
+}
+
+// unindex drops key only while it still points at id, so a later
+// transaction that reused the identifier keeps it.
+func unindex(m map[string]string, key, id string) {
+	if key != "" && m[key] == id {
+		delete(m, key)
+	}
+}
+
+func indexAll(m map[string][]string, key, id string) {
+	if key != "" {
+		m[key] = append(m[key], id)
+	}
+}
+
+func unindexAll(m map[string][]string, key, id string) {
+	if key == "" {
+		return
+	}
+	if ids := slices.DeleteFunc(m[key], func(held string) bool { return held == id }); len(ids) > 0 {
+		m[key] = ids
+	} else {
+		delete(m, key)
+	}
+}
+
+func memoKey(memo, memoType string) string {
+	if memo == "" {
+		return ""
+	}
+	return memoType + ":" + memo
+}
+
+// listsBefore reports whether a comes before b in TransactionQuery order.
+func listsBefore(a, b Transaction) bool {
+	if !a.StartedAt.Equal(b.StartedAt) {
+		return a.StartedAt.After(b.StartedAt)
+	}
+	return a.ID > b.ID
+}
+
+func (q StatusQuery) matches(tx Transaction) bool {
+	if tx.Status != q.Status || (q.Protocol != "" && tx.Protocol != q.Protocol) {
+		return false
+	}
+	if len(q.Kinds) > 0 && !slices.Contains(q.Kinds, tx.Kind) {
+		return false
+	}
+	return q.After == (StatusCursor{}) || updatedBefore(q.After.UpdatedAt, q.After.ID, tx)
+}
+
+// updatedBefore reports whether the position (updatedAt, id) comes before
+// tx in ListByStatus order.
+func updatedBefore(updatedAt time.Time, id string, tx Transaction) bool {
+	if !updatedAt.Equal(tx.UpdatedAt) {
+		return updatedAt.Before(tx.UpdatedAt)
+	}
+	return id < tx.ID
+}
+
+func (q TransactionQuery) matches(tx Transaction) bool {
+	if q.Protocol != "" && tx.Protocol != q.Protocol {
+		return false
+	}
+	if len(q.Kinds) > 0 && !slices.Contains(q.Kinds, tx.Kind) {
+		return false
+	}
+	if q.AssetCode != "" && tx.AssetCode != q.AssetCode {
+		return false
+	}
+	return q.AssetIssuer == "" || tx.AssetIssuer == q.AssetIssuer
+}
+
+func (s *MemoryCustomerStore) Put(customer Customer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(customer)
+}
+
+func (s *MemoryCustomerStore) Replace(customer Customer, updatedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.customers[customer.ID]; !ok || !current.UpdatedAt.Equal(updatedAt) {
+		return fmt.Errorf("%w: customer %s changed since it was read", ErrConflict, customer.ID)
+	}
+	return s.put(customer)
+}
+
+func (s *MemoryCustomerStore) put(customer Customer) error {
+	if customer.ID == "" {
+		return fmt.Errorf("customer id is required")
+	}
+	for id, c := range s.customers {
+		if id != customer.ID && c.Account == customer.Account && c.Memo == customer.Memo {
+			return fmt.Errorf("customer %s already exists for account", id)
+		}
+	}
+	s.customers[customer.ID] = customer
 	return nil
 }
 
-func (s *MemoryTransactionStore) UpdateStatus(id string, status string, updatedAt time.Time) (Transaction, bool) {
+func (s *MemoryCustomerStore) Get(account, memo string) (Customer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.customers {
+		if c.Account == account && c.Memo == memo {
+			return c, true
+		}
+	}
+	return Customer{}, false
+}
+
+func (s *MemoryCustomerStore) GetByID(id string) (Customer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.customers[id]
+	return c, ok
+}
+
+func (s *MemoryCustomerStore) List() []Customer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]Customer, 0, len(s.customers))
+	for _, c := range s.customers {
+		items = append(items, c)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func (s *MemoryCustomerStore) Delete(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	tx, ok := s.txs[id]
-	if !ok {
-		return Transaction{}, false
+	delete(s.customers, id)
+	return nil
+}
+
+func (s *MemoryBlobStore) Put(key string, blob Blob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = Blob{ContentType: blob.ContentType, Data: append([]byte(nil), blob.Data...)}
+	return nil
+}
+
+func (s *MemoryBlobStore) Replace(key string, old, blob Blob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.blobs[key]; !ok || current.ContentType != old.ContentType || !bytes.Equal(current.Data, old.Data) {
+		return fmt.Errorf("%w: blob %s changed since it was read", ErrConflict, key)
 	}
-	tx.Status = status
-	tx.UpdatedAt = updatedAt
-	s.txs[id] = tx
-	return tx, true
+	s.blobs[key] = Blob{ContentType: blob.ContentType, Data: append([]byte(nil), blob.Data...)}
+	return nil
 }
 
-func (s *MemoryCustomerStore) Put(customer Customer) error {
+func (s *MemoryBlobStore) Get(key string) (Blob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blobs[key]
+	return b, ok
+}
+
+func (s *MemoryBlobStore) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.customers[customer.Account] = customer
+	delete(s.blobs, key)
 	return nil
 }
 
-func (s *MemoryCustomerStore) Get(account string) (Customer, bool) {
+func (s *MemoryQuoteStore) Create(quote Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[quote.ID] = quote
+	return nil
+}
+
+func (s *MemoryQuoteStore) GetByID(id string) (Quote, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	c, ok := s.customers[account]
-	return c, ok
+	q, ok := s.quotes[id]
+	return q, ok
+}
+
+func (s *MemoryQuoteStore) Update(quote Quote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[quote.ID] = quote
+	return nil
+}
+
+func (s *MemoryQuoteStore) Bind(id, transactionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quote, ok := s.quotes[id]
+	if !ok {
+		return fmt.Errorf("quote %s not found", id)
+	}
+	if quote.TransactionID != "" && quote.TransactionID != transactionID {
+		return fmt.Errorf("%w: quote %s is bound to another transaction", ErrConflict, id)
+	}
+	quote.TransactionID = transactionID
+	s.quotes[id] = quote
+	return nil
+}
+
+func (s *MemoryQuoteStore) Unbind(id, transactionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if quote, ok := s.quotes[id]; ok && quote.TransactionID == transactionID {
+		quote.TransactionID = ""
+		s.quotes[id] = quote
+	}
+	return nil
 }
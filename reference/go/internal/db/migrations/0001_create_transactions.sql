@@ -0,0 +1,23 @@
+-- Indexed columns mirror fields of the JSON record in data; the record is
+-- the source of truth and the columns exist only for lookups and paging.
+CREATE TABLE transactions (
+	id TEXT PRIMARY KEY,
+	account TEXT NOT NULL,
+	protocol TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	status TEXT NOT NULL,
+	asset_code TEXT NOT NULL,
+	asset_issuer TEXT NOT NULL,
+	external_transaction_id TEXT NOT NULL,
+	stellar_transaction_id TEXT NOT NULL,
+	memo_key TEXT NOT NULL,
+	started_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE INDEX transactions_account_page ON transactions (account, started_at DESC, id DESC);
+CREATE INDEX transactions_status ON transactions (status, updated_at, id);
+CREATE INDEX transactions_external_id ON transactions (external_transaction_id) WHERE external_transaction_id <> '';
+CREATE INDEX transactions_stellar_id ON transactions (stellar_transaction_id) WHERE stellar_transaction_id <> '';
+CREATE INDEX transactions_memo ON transactions (memo_key) WHERE memo_key <> '';
@@ -0,0 +1,7 @@
+CREATE TABLE customers (
+	id TEXT PRIMARY KEY,
+	account TEXT NOT NULL,
+	memo TEXT NOT NULL,
+	data TEXT NOT NULL,
+	UNIQUE (account, memo)
+);
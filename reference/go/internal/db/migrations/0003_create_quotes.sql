@@ -0,0 +1,4 @@
+CREATE TABLE quotes (
+	id TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
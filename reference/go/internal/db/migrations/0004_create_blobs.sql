@@ -0,0 +1,5 @@
+CREATE TABLE blobs (
+	key TEXT PRIMARY KEY,
+	content_type TEXT NOT NULL,
+	data BLOB NOT NULL
+);
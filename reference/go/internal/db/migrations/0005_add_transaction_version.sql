@@ -0,0 +1,2 @@
+-- version backs optimistic concurrency: writes are conditional on it.
+ALTER TABLE transactions ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,24 @@
+-- One row per transaction write. The table is append-only: the triggers
+-- reject any attempt to rewrite or remove an entry.
+CREATE TABLE transaction_history (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	transaction_id TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	at TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	source TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	changes TEXT NOT NULL
+);
+
+CREATE INDEX transaction_history_transaction ON transaction_history (transaction_id, seq);
+
+CREATE TRIGGER transaction_history_no_update BEFORE UPDATE ON transaction_history
+BEGIN
+	SELECT RAISE(ABORT, 'transaction history is append-only');
+END;
+
+CREATE TRIGGER transaction_history_no_delete BEFORE DELETE ON transaction_history
+BEGIN
+	SELECT RAISE(ABORT, 'transaction history is append-only');
+END;
@@ -0,0 +1,43 @@
+package db
+
+import "fmt"
+
+// RedactedValue replaces PII in logs and admin exports.
+const RedactedValue = "[REDACTED]"
+
+// RedactFields masks every value but keeps the names, so a log still shows
+// which fields a customer provided.
+func RedactFields(fields map[string]string) map[string]string {
+	if fields == nil {
+		return nil
+	}
+	out := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if value != "" {
+			value = RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// Redacted returns a copy of c that is safe to log or export: SEP-9 values
+// and verification codes are masked and the sealed envelope is dropped.
+func (c Customer) Redacted() Customer {
+	c.Fields = RedactFields(c.Fields)
+	c.VerificationCodes = RedactFields(c.VerificationCodes)
+	c.Sealed = nil
+	return c
+}
+
+// plainCustomer formats a Customer without its String method.
+type plainCustomer Customer
+
+// String and GoString keep the %v, %+v and %#v verbs from printing PII.
+func (c Customer) String() string {
+	return fmt.Sprintf("%+v", plainCustomer(c.Redacted()))
+}
+
+func (c Customer) GoString() string {
+	return fmt.Sprintf("%#v", plainCustomer(c.Redacted()))
+}
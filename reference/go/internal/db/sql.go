@@ -0,0 +1,575 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqlTime is fixed width so stored timestamps sort as text in time order.
+const sqlTime = "2006-01-02T15:04:05.000000000Z"
+
+// SQLTransactionStore keeps transactions in an embedded SQLite database.
+// Each row holds the JSON record plus the columns used to look it up.
+type SQLTransactionStore struct {
+	db *sql.DB
+}
+
+type SQLCustomerStore struct {
+	db *sql.DB
+}
+
+type SQLQuoteStore struct {
+	db *sql.DB
+}
+
+// SQLBlobStore keeps binary customer fields in the database.
+type SQLBlobStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database file at path, creating it if needed. The
+// schema is not touched; run Migrate before using the stores.
+func OpenSQLite(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	// SQLite allows one writer at a time; a single connection avoids
+	// SQLITE_BUSY between the store's own transactions.
+	conn.SetMaxOpenConns(1)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	return conn, nil
+}
+
+func NewSQLTransactionStore(conn *sql.DB) *SQLTransactionStore {
+	return &SQLTransactionStore{db: conn}
+}
+
+func NewSQLCustomerStore(conn *sql.DB) *SQLCustomerStore {
+	return &SQLCustomerStore{db: conn}
+}
+
+func NewSQLQuoteStore(conn *sql.DB) *SQLQuoteStore {
+	return &SQLQuoteStore{db: conn}
+}
+
+func NewSQLBlobStore(conn *sql.DB) *SQLBlobStore {
+	return &SQLBlobStore{db: conn}
+}
+
+func (s *SQLTransactionStore) Create(tx Transaction, audit Audit) error {
+	tx.Version = 0
+	cols, err := transactionColumns(tx)
+	if err != nil {
+		return err
+	}
+	entry, err := historyEntry(nil, tx, audit)
+	if err != nil {
+		return err
+	}
+	sqlTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	res, err := sqlTx.Exec(`INSERT INTO transactions (id, account, protocol, kind, status, asset_code, asset_issuer,
+			external_transaction_id, stellar_transaction_id, memo_key, started_at, updated_at, data, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`, append([]any{tx.ID}, append(cols, tx.Version)...)...)
+	if err != nil {
+		return fmt.Errorf("create transaction %s: %w", tx.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("create transaction %s: %w", tx.ID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicate, tx.ID)
+	}
+	if err := appendHistory(sqlTx, entry); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+func (s *SQLTransactionStore) Update(tx Transaction, audit Audit) (Transaction, error) {
+	return s.write(tx.ID, audit, func(current Transaction) (Transaction, error) {
+		if current.Version != tx.Version {
+			return Transaction{}, fmt.Errorf("%w: %s is at version %d, not %d", ErrConflict, tx.ID, current.Version, tx.Version)
+		}
+		return tx, nil
+	})
+}
+
+func (s *SQLTransactionStore) GetByID(id string) (Transaction, bool) {
+	return s.getOne(`WHERE id = ?`, id)
+}
+
+func (s *SQLTransactionStore) ListByExternalID(externalID string) []Transaction {
+	if externalID == "" {
+		return nil
+	}
+	return s.list(`WHERE external_transaction_id = ? ORDER BY started_at DESC, id DESC`, externalID)
+}
+
+func (s *SQLTransactionStore) ListByStellarTxID(hash string) []Transaction {
+	if hash == "" {
+		return nil
+	}
+	return s.list(`WHERE stellar_transaction_id = ? ORDER BY started_at DESC, id DESC`, hash)
+}
+
+func (s *SQLTransactionStore) GetByMemo(memo, memoType string) (Transaction, bool) {
+	key := memoKey(memo, memoType)
+	if key == "" {
+		return Transaction{}, false
+	}
+	return s.getOne(`WHERE memo_key = ? ORDER BY started_at DESC, id DESC`, key)
+}
+
+func (s *SQLTransactionStore) ListByAccount(query TransactionQuery) []Transaction {
+	where := []string{"account = ?"}
+	args := []any{query.Account}
+	if query.Cursor != "" {
+		cursor, ok := s.GetByID(query.Cursor)
+		if !ok || cursor.Account != query.Account {
+			return nil
+		}
+		startedAt := formatSQLTime(cursor.StartedAt)
+		where = append(where, "(started_at < ? OR (started_at = ? AND id < ?))")
+		args = append(args, startedAt, startedAt, cursor.ID)
+	}
+	if query.Protocol != "" {
+		where = append(where, "protocol = ?")
+		args = append(args, query.Protocol)
+	}
+	if len(query.Kinds) > 0 {
+		where = append(where, "kind IN (?"+strings.Repeat(", ?", len(query.Kinds)-1)+")")
+		for _, kind := range query.Kinds {
+			args = append(args, kind)
+		}
+	}
+	if query.AssetCode != "" {
+		where = append(where, "asset_code = ?")
+		args = append(args, query.AssetCode)
+	}
+	if query.AssetIssuer != "" {
+		where = append(where, "asset_issuer = ?")
+		args = append(args, query.AssetIssuer)
+	}
+	if !query.NoOlderThan.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, formatSQLTime(query.NoOlderThan))
+	}
+	clause := "WHERE " + strings.Join(where, " AND ") + " ORDER BY started_at DESC, id DESC"
+	if query.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+	return s.list(clause, args...)
+}
+
+func (s *SQLTransactionStore) ListByStatus(query StatusQuery) []Transaction {
+	where := []string{"status = ?"}
+	args := []any{query.Status}
+	if query.Protocol != "" {
+		where = append(where, "protocol = ?")
+		args = append(args, query.Protocol)
+	}
+	if len(query.Kinds) > 0 {
+		where = append(where, "kind IN (?"+strings.Repeat(", ?", len(query.Kinds)-1)+")")
+		for _, kind := range query.Kinds {
+			args = append(args, kind)
+		}
+	}
+	if query.After != (StatusCursor{}) {
+		updatedAt := formatSQLTime(query.After.UpdatedAt)
+		where = append(where, "(updated_at > ? OR (updated_at = ? AND id > ?))")
+		args = append(args, updatedAt, updatedAt, query.After.ID)
+	}
+	clause := "WHERE " + strings.Join(where, " AND ") + " ORDER BY updated_at, id"
+	if query.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+	return s.list(clause, args...)
+}
+
+func (s *SQLTransactionStore) UpdateStatus(id, from, to string, updatedAt time.Time, audit Audit) (Transaction, error) {
+	return s.write(id, audit, func(tx Transaction) (Transaction, error) {
+		if tx.Status != from {
+			return Transaction{}, fmt.Errorf("%w: %s is %s, not %s", ErrConflict, id, tx.Status, from)
+		}
+		tx.Status = to
+		tx.UpdatedAt = updatedAt
+		return tx, nil
+	})
+}
+
+func (s *SQLTransactionStore) History(id string) ([]HistoryEntry, error) {
+	if _, ok := s.GetByID(id); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	rows, err := s.db.Query(`SELECT version, at, actor, source, reason, changes FROM transaction_history
+		WHERE transaction_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, fmt.Errorf("read history of %s: %w", id, err)
+	}
+	defer rows.Close()
+	entries := make([]HistoryEntry, 0)
+	for rows.Next() {
+		entry := HistoryEntry{TransactionID: id}
+		var at, changes string
+		if err := rows.Scan(&entry.Version, &at, &entry.Actor, &entry.Source, &entry.Reason, &changes); err != nil {
+			return nil, fmt.Errorf("read history of %s: %w", id, err)
+		}
+		if entry.At, err = time.Parse(sqlTime, at); err != nil {
+			return nil, fmt.Errorf("read history of %s: %w", id, err)
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, fmt.Errorf("decode history of %s: %w", id, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read history of %s: %w", id, err)
+	}
+	return entries, nil
+}
+
+// write replaces the transaction with mutate's result and appends the write
+// to its history, in one database transaction.
+func (s *SQLTransactionStore) write(id string, audit Audit, mutate func(Transaction) (Transaction, error)) (Transaction, error) {
+	sqlTx, err := s.db.Begin()
+	if err != nil {
+		return Transaction{}, err
+	}
+	defer sqlTx.Rollback()
+	var data string
+	err = sqlTx.QueryRow(`SELECT data FROM transactions WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transaction{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Transaction{}, fmt.Errorf("read transaction %s: %w", id, err)
+	}
+	var current Transaction
+	if err := json.Unmarshal([]byte(data), &current); err != nil {
+		return Transaction{}, fmt.Errorf("decode transaction %s: %w", id, err)
+	}
+	tx, err := mutate(current)
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx.ID = id
+	tx.Version = current.Version + 1
+	cols, err := transactionColumns(tx)
+	if err != nil {
+		return Transaction{}, err
+	}
+	entry, err := historyEntry(&current, tx, audit)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if _, err := sqlTx.Exec(`UPDATE transactions SET account = ?, protocol = ?, kind = ?, status = ?, asset_code = ?,
+			asset_issuer = ?, external_transaction_id = ?, stellar_transaction_id = ?, memo_key = ?, started_at = ?,
+			updated_at = ?, data = ?, version = ?
+		WHERE id = ?`, append(cols, tx.Version, id)...); err != nil {
+		return Transaction{}, fmt.Errorf("update transaction %s: %w", id, err)
+	}
+	if err := appendHistory(sqlTx, entry); err != nil {
+		return Transaction{}, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return Transaction{}, fmt.Errorf("update transaction %s: %w", id, err)
+	}
+	return tx, nil
+}
+
+func appendHistory(sqlTx *sql.Tx, entry HistoryEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("encode history of %s: %w", entry.TransactionID, err)
+	}
+	if _, err := sqlTx.Exec(`INSERT INTO transaction_history (transaction_id, version, at, actor, source, reason, changes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, entry.TransactionID, entry.Version, formatSQLTime(entry.At),
+		entry.Actor, entry.Source, entry.Reason, string(changes)); err != nil {
+		return fmt.Errorf("record history of %s: %w", entry.TransactionID, err)
+	}
+	return nil
+}
+
+// transactionColumns returns the column values after id, in table order.
+func transactionColumns(tx Transaction) ([]any, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("encode transaction %s: %w", tx.ID, err)
+	}
+	return []any{tx.Account, tx.Protocol, tx.Kind, tx.Status, tx.AssetCode, tx.AssetIssuer,
+		tx.ExternalTransactionID, tx.StellarTransactionID, memoKey(tx.WithdrawMemo, tx.WithdrawMemoType),
+		formatSQLTime(tx.StartedAt), formatSQLTime(tx.UpdatedAt), string(data)}, nil
+}
+
+func (s *SQLTransactionStore) getOne(clause string, args ...any) (Transaction, bool) {
+	items := s.list(clause+" LIMIT 1", args...)
+	if len(items) == 0 {
+		return Transaction{}, false
+	}
+	return items[0], true
+}
+
+// list runs a SELECT with clause appended. The interface has no error
+// return, so failures are logged and read as an empty result.
+func (s *SQLTransactionStore) list(clause string, args ...any) []Transaction {
+	rows, err := s.db.Query(`SELECT data FROM transactions `+clause, args...)
+	if err != nil {
+		log.Printf("db: query transactions: %v", err)
+		return nil
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			log.Printf("db: scan transaction: %v", err)
+			return nil
+		}
+		var tx Transaction
+		if err := json.Unmarshal([]byte(data), &tx); err != nil {
+			log.Printf("db: decode transaction: %v", err)
+			return nil
+		}
+		items = append(items, tx)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("db: query transactions: %v", err)
+		return nil
+	}
+	return items
+}
+
+func (s *SQLCustomerStore) Put(customer Customer) error {
+	return s.put(customer, nil)
+}
+
+func (s *SQLCustomerStore) Replace(customer Customer, updatedAt time.Time) error {
+	return s.put(customer, &updatedAt)
+}
+
+// put upserts customer; with updatedAt set it only replaces that version.
+func (s *SQLCustomerStore) put(customer Customer, updatedAt *time.Time) error {
+	if customer.ID == "" {
+		return fmt.Errorf("customer id is required")
+	}
+	data, err := json.Marshal(customer)
+	if err != nil {
+		return fmt.Errorf("encode customer %s: %w", customer.ID, err)
+	}
+	sqlTx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	if updatedAt != nil {
+		var stored string
+		var current Customer
+		err := sqlTx.QueryRow(`SELECT data FROM customers WHERE id = ?`, customer.ID).Scan(&stored)
+		if err == nil {
+			err = json.Unmarshal([]byte(stored), &current)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("save customer %s: %w", customer.ID, err)
+		}
+		if err != nil || !current.UpdatedAt.Equal(*updatedAt) {
+			return fmt.Errorf("%w: customer %s changed since it was read", ErrConflict, customer.ID)
+		}
+	}
+	var existing string
+	err = sqlTx.QueryRow(`SELECT id FROM customers WHERE account = ? AND memo = ? AND id <> ?`, customer.Account, customer.Memo, customer.ID).Scan(&existing)
+	if err == nil {
+		return fmt.Errorf("customer %s already exists for account", existing)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("save customer %s: %w", customer.ID, err)
+	}
+	if _, err := sqlTx.Exec(`INSERT INTO customers (id, account, memo, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET account = excluded.account, memo = excluded.memo, data = excluded.data`,
+		customer.ID, customer.Account, customer.Memo, string(data)); err != nil {
+		return fmt.Errorf("save customer %s: %w", customer.ID, err)
+	}
+	return sqlTx.Commit()
+}
+
+func (s *SQLCustomerStore) Get(account, memo string) (Customer, bool) {
+	return s.getOne(`WHERE account = ? AND memo = ?`, account, memo)
+}
+
+func (s *SQLCustomerStore) GetByID(id string) (Customer, bool) {
+	return s.getOne(`WHERE id = ?`, id)
+}
+
+func (s *SQLCustomerStore) List() []Customer {
+	rows, err := s.db.Query(`SELECT data FROM customers ORDER BY id`)
+	if err != nil {
+		log.Printf("db: query customers: %v", err)
+		return nil
+	}
+	defer rows.Close()
+	var items []Customer
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			log.Printf("db: scan customer: %v", err)
+			return nil
+		}
+		var customer Customer
+		if err := json.Unmarshal([]byte(data), &customer); err != nil {
+			log.Printf("db: decode customer: %v", err)
+			return nil
+		}
+		items = append(items, customer)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("db: query customers: %v", err)
+		return nil
+	}
+	return items
+}
+
+func (s *SQLCustomerStore) Delete(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM customers WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete customer %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *SQLCustomerStore) getOne(clause string, args ...any) (Customer, bool) {
+	var data string
+	if err := s.db.QueryRow(`SELECT data FROM customers `+clause, args...).Scan(&data); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("db: query customer: %v", err)
+		}
+		return Customer{}, false
+	}
+	var customer Customer
+	if err := json.Unmarshal([]byte(data), &customer); err != nil {
+		log.Printf("db: decode customer: %v", err)
+		return Customer{}, false
+	}
+	return customer, true
+}
+
+func (s *SQLQuoteStore) Create(quote Quote) error {
+	return s.put(quote)
+}
+
+func (s *SQLQuoteStore) GetByID(id string) (Quote, bool) {
+	var data string
+	if err := s.db.QueryRow(`SELECT data FROM quotes WHERE id = ?`, id).Scan(&data); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("db: query quote: %v", err)
+		}
+		return Quote{}, false
+	}
+	var quote Quote
+	if err := json.Unmarshal([]byte(data), &quote); err != nil {
+		log.Printf("db: decode quote: %v", err)
+		return Quote{}, false
+	}
+	return quote, true
+}
+
+func (s *SQLQuoteStore) Update(quote Quote) error {
+	return s.put(quote)
+}
+
+func (s *SQLQuoteStore) Bind(id, transactionID string) error {
+	result, err := s.db.Exec(`UPDATE quotes SET data = json_set(data, '$.transaction_id', ?)
+		WHERE id = ? AND coalesce(json_extract(data, '$.transaction_id'), '') IN ('', ?)`, transactionID, id, transactionID)
+	if err != nil {
+		return fmt.Errorf("bind quote %s: %w", id, err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+	if _, ok := s.GetByID(id); !ok {
+		return fmt.Errorf("quote %s not found", id)
+	}
+	return fmt.Errorf("%w: quote %s is bound to another transaction", ErrConflict, id)
+}
+
+func (s *SQLQuoteStore) Unbind(id, transactionID string) error {
+	if _, err := s.db.Exec(`UPDATE quotes SET data = json_remove(data, '$.transaction_id')
+		WHERE id = ? AND json_extract(data, '$.transaction_id') = ?`, id, transactionID); err != nil {
+		return fmt.Errorf("unbind quote %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *SQLQuoteStore) put(quote Quote) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("encode quote %s: %w", quote.ID, err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO quotes (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data`, quote.ID, string(data)); err != nil {
+		return fmt.Errorf("save quote %s: %w", quote.ID, err)
+	}
+	return nil
+}
+
+func (s *SQLBlobStore) Put(key string, blob Blob) error {
+	if _, err := s.db.Exec(`INSERT INTO blobs (key, content_type, data) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET content_type = excluded.content_type, data = excluded.data`,
+		key, blob.ContentType, blob.Data); err != nil {
+		return fmt.Errorf("save blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *SQLBlobStore) Replace(key string, old, blob Blob) error {
+	result, err := s.db.Exec(`UPDATE blobs SET content_type = ?, data = ? WHERE key = ? AND content_type = ? AND data = ?`,
+		blob.ContentType, blob.Data, key, old.ContentType, old.Data)
+	if err != nil {
+		return fmt.Errorf("save blob %s: %w", key, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("save blob %s: %w", key, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: blob %s changed since it was read", ErrConflict, key)
+	}
+	return nil
+}
+
+func (s *SQLBlobStore) Get(key string) (Blob, bool) {
+	var blob Blob
+	if err := s.db.QueryRow(`SELECT content_type, data FROM blobs WHERE key = ?`, key).Scan(&blob.ContentType, &blob.Data); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("db: query blob: %v", err)
+		}
+		return Blob{}, false
+	}
+	return blob, true
+}
+
+func (s *SQLBlobStore) Delete(key string) error {
+	if _, err := s.db.Exec(`DELETE FROM blobs WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("delete blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func formatSQLTime(t time.Time) string {
+	return t.UTC().Format(sqlTime)
+}
@@ -0,0 +1,299 @@
+package decimal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundDown
+	RoundUp
+)
+
+var roundingModeNames = map[string]RoundingMode{
+	"half_even": RoundHalfEven,
+	"half_up":   RoundHalfUp,
+	"down":      RoundDown,
+	"up":        RoundUp,
+}
+
+func ParseRoundingMode(raw string) (RoundingMode, bool) {
+	mode, ok := roundingModeNames[strings.ToLower(strings.TrimSpace(raw))]
+	return mode, ok
+}
+
+func (m RoundingMode) String() string {
+	for name, mode := range roundingModeNames {
+		if mode == m {
+			return name
+		}
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// Decimal is an exact base-10 fixed-point number: coef * 10^-scale. The zero
+// value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+var Zero = Decimal{}
+
+func New(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{coef: big.NewInt(unscaled), scale: scale}
+}
+
+func Parse(raw string) (Decimal, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", raw)
+	}
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	if digits == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", raw)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", raw)
+		}
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", raw)
+	}
+	if negative {
+		coef.Neg(coef)
+	}
+	return Decimal{coef: coef, scale: int32(len(fracPart))}, nil
+}
+
+func MustParse(raw string) Decimal {
+	d, err := Parse(raw)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Shift multiplies d by 10^places without losing precision.
+func (d Decimal) Shift(places int32) Decimal {
+	if places >= 0 && d.scale >= places {
+		return Decimal{coef: new(big.Int).Set(d.int()), scale: d.scale - places}
+	}
+	if places >= 0 {
+		coef := new(big.Int).Mul(d.int(), pow10(places-d.scale))
+		return Decimal{coef: coef}
+	}
+	return Decimal{coef: new(big.Int).Set(d.int()), scale: d.scale - places}
+}
+
+// Quo returns d / o rounded to places decimal digits.
+func (d Decimal) Quo(o Decimal, places int32, mode RoundingMode) (Decimal, error) {
+	if o.IsZero() {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(o.int())
+	shift := places + o.scale - d.scale
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return Decimal{coef: roundQuo(num, den, mode), scale: places}, nil
+}
+
+// Round returns d with exactly places decimal digits, applying mode when
+// digits have to be dropped.
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if d.scale <= places {
+		return Decimal{coef: new(big.Int).Mul(d.int(), pow10(places-d.scale)), scale: places}
+	}
+	return Decimal{coef: roundQuo(new(big.Int).Set(d.int()), pow10(d.scale-places), mode), scale: places}
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := align(d, o)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+func (d Decimal) LessThan(o Decimal) bool {
+	return d.Cmp(o) < 0
+}
+
+func (d Decimal) GreaterThan(o Decimal) bool {
+	return d.Cmp(o) > 0
+}
+
+func Max(a, b Decimal) Decimal {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func Min(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func (d Decimal) String() string {
+	coef := d.int()
+	digits := new(big.Int).Abs(coef).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if coef.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (d Decimal) StringFixed(places int32, mode RoundingMode) string {
+	return d.Round(places, mode).String()
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Decimal) UnmarshalJSON(raw []byte) error {
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+	value := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		if strings.TrimSpace(value) == "" {
+			*d = Decimal{}
+			return nil
+		}
+	}
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	switch {
+	case a.scale == b.scale:
+		return a.int(), b.int(), a.scale
+	case a.scale > b.scale:
+		return a.int(), new(big.Int).Mul(b.int(), pow10(a.scale-b.scale)), a.scale
+	default:
+		return new(big.Int).Mul(a.int(), pow10(b.scale-a.scale)), b.int(), b.scale
+	}
+}
+
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	direction := int64(num.Sign() * den.Sign())
+	twiceRem := new(big.Int).Abs(r)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.Cmp(new(big.Int).Abs(den))
+
+	increment := false
+	switch mode {
+	case RoundUp:
+		increment = true
+	case RoundHalfUp:
+		increment = half >= 0
+	case RoundHalfEven:
+		increment = half > 0 || half == 0 && q.Bit(0) == 1
+	}
+	if increment {
+		q.Add(q, big.NewInt(direction))
+	}
+	return q
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,99 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAndString(t *testing.T) {
+	cases := map[string]string{
+		"0":          "0",
+		"1.50":       "1.50",
+		"-0.0000001": "-0.0000001",
+		".5":         "0.5",
+		"+12":        "12",
+		"100.":       "100",
+	}
+	for input, want := range cases {
+		d, err := Parse(input)
+		if err != nil {
+			t.Fatalf("parse %q: %v", input, err)
+		}
+		if got := d.String(); got != want {
+			t.Fatalf("parse %q: expected %s, got %s", input, want, got)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1e5", "1.2.3", "-", ".", "NaN"} {
+		if _, err := Parse(input); err == nil {
+			t.Fatalf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	sum := MustParse("0.1").Add(MustParse("0.2"))
+	if !sum.Equal(MustParse("0.3")) {
+		t.Fatalf("expected 0.3, got %s", sum)
+	}
+
+	fee := MustParse("1.0000000").Add(MustParse("1234.5678901").Mul(MustParse("0.1")).Shift(-2))
+	if got := fee.Round(7, RoundHalfEven).String(); got != "2.2345679" {
+		t.Fatalf("unexpected fee: %s", got)
+	}
+
+	quo, err := MustParse("10").Quo(MustParse("3"), 7, RoundHalfEven)
+	if err != nil || quo.String() != "3.3333333" {
+		t.Fatalf("unexpected quotient %s err=%v", quo, err)
+	}
+	if _, err := MustParse("1").Quo(Zero, 2, RoundHalfEven); err == nil {
+		t.Fatalf("expected division by zero error")
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+	cases := []struct {
+		input string
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"2.345", RoundHalfUp, "2.35"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"2.349", RoundDown, "2.34"},
+		{"2.341", RoundUp, "2.35"},
+		{"-2.341", RoundUp, "-2.35"},
+		{"2.3", RoundHalfEven, "2.30"},
+	}
+	for _, tc := range cases {
+		if got := MustParse(tc.input).Round(2, tc.mode).String(); got != tc.want {
+			t.Fatalf("round %s with %s: expected %s, got %s", tc.input, tc.mode, tc.want, got)
+		}
+	}
+
+	if mode, ok := ParseRoundingMode("HALF_UP"); !ok || mode != RoundHalfUp {
+		t.Fatalf("expected half_up rounding mode")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var payload struct {
+		Amount Decimal `json:"amount"`
+		Fee    Decimal `json:"fee"`
+		Empty  Decimal `json:"empty"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"100.25","fee":0.1,"empty":""}`), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Amount.String() != "100.25" || payload.Fee.String() != "0.1" || !payload.Empty.IsZero() {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(raw) != `{"amount":"100.25","fee":"0.1","empty":"0"}` {
+		t.Fatalf("unexpected json: %s", raw)
+	}
+}
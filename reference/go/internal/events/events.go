@@ -0,0 +1,298 @@
+// Package events publishes typed events about transactions, customers and
+// quotes to the anchor's business server. Events are written to an outbox
+// before they are acknowledged to the caller and delivered in order to a
+// webhook, at least once, with an HMAC signature.
+//
+// Events are emitted after the database commit, so a crash in between loses
+// the event; business servers reconcile against GET /platform/transactions.
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TypeTransactionCreated       = "transaction_created"
+	TypeTransactionStatusChanged = "transaction_status_changed"
+	TypeCustomerUpdated          = "customer_updated"
+	TypeQuoteCreated             = "quote_created"
+)
+
+// compactInterval is how often Run compacts the outbox.
+const compactInterval = time.Hour
+
+// SignatureHeader carries "t=<unix>, v1=<hex hmac-sha256>" over
+// "<unix>.<body>", keyed with the webhook secret.
+const SignatureHeader = "X-Event-Signature"
+
+var ErrEventNotFound = errors.New("event not found")
+
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Publisher appends events to its outbox and delivers them to URL. Delivery
+// stops at the first failure and retries from that event, so the receiver
+// sees events in order and may see an event more than once. An event
+// rejected MaxRejections times is dead-lettered.
+type Publisher struct {
+	URL           string
+	Secret        []byte
+	Client        *http.Client
+	Outbox        Outbox
+	RetryDelay    time.Duration
+	MaxDelay      time.Duration
+	MaxRejections int
+	Retention     time.Duration
+	Now           func() time.Time
+
+	wake        chan struct{}
+	rejections  map[string]int
+	compactedAt time.Time
+}
+
+// rejectedError is a 4xx response that retrying will not fix.
+type rejectedError struct {
+	status int
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("webhook rejected the event with %d", e.status)
+}
+
+func NewPublisher(url, secret string, outbox Outbox) *Publisher {
+	return &Publisher{
+		URL:           url,
+		Secret:        []byte(secret),
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		Outbox:        outbox,
+		RetryDelay:    time.Second,
+		MaxDelay:      5 * time.Minute,
+		MaxRejections: 5,
+		Retention:     7 * 24 * time.Hour,
+		Now:           func() time.Time { return time.Now().UTC() },
+		wake:          make(chan struct{}, 1),
+		rejections:    map[string]int{},
+	}
+}
+
+// Emit records an event; it is delivered by Run.
+func (p *Publisher) Emit(eventType string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	id, err := newEventID()
+	if err != nil {
+		return Event{}, err
+	}
+	event := Event{ID: id, Type: eventType, Timestamp: p.Now(), Data: raw}
+	if err := p.Outbox.Append(event); err != nil {
+		return Event{}, err
+	}
+	p.signal()
+	return event, nil
+}
+
+// Replay delivers the event with the given id again, followed by every
+// event recorded after it.
+func (p *Publisher) Replay(id string) error {
+	if err := p.Outbox.Requeue(id); err != nil {
+		return err
+	}
+	p.signal()
+	return nil
+}
+
+// Run delivers pending events until ctx is done, backing off exponentially
+// while the webhook is failing.
+func (p *Publisher) Run(ctx context.Context) {
+	failures := 0
+	for {
+		var retry <-chan time.Time
+		if err := p.deliverPending(ctx); err != nil {
+			failures++
+			delay := p.backoff(failures)
+			log.Printf("events: delivery failed, retrying in %s: %v", delay, err)
+			retry = time.After(delay)
+		} else {
+			failures = 0
+			p.compact()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-retry:
+		}
+	}
+}
+
+func (p *Publisher) deliverPending(ctx context.Context) error {
+	for _, event := range p.Outbox.Pending() {
+		err := p.post(ctx, event)
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			p.rejections[event.ID]++
+			if p.rejections[event.ID] >= p.MaxRejections {
+				if err := p.Outbox.DeadLetter(event.ID, rejected.Error()); err != nil {
+					return fmt.Errorf("dead-letter event %s: %w", event.ID, err)
+				}
+				log.Printf("events: giving up on event %s after %d rejections: %v", event.ID, p.rejections[event.ID], rejected)
+				delete(p.rejections, event.ID)
+				continue
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.ID, err)
+		}
+		delete(p.rejections, event.ID)
+		if err := p.Outbox.Ack(event.ID); err != nil {
+			return fmt.Errorf("ack event %s: %w", event.ID, err)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) compact() {
+	now := p.Now()
+	if p.Retention <= 0 || now.Sub(p.compactedAt) < compactInterval {
+		return
+	}
+	p.compactedAt = now
+	if err := p.Outbox.Compact(now.Add(-p.Retention)); err != nil {
+		log.Printf("events: compact outbox: %v", err)
+	}
+}
+
+func (p *Publisher) post(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(p.Secret, body, p.Now()))
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch status := resp.StatusCode; {
+	case status >= 200 && status < 300:
+		return nil
+	case status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests:
+		return &rejectedError{status: status}
+	}
+	return fmt.Errorf("webhook returned %d", resp.StatusCode)
+}
+
+func (p *Publisher) backoff(failures int) time.Duration {
+	delay := p.RetryDelay << (failures - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+func (p *Publisher) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func Sign(secret, body []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ", v1=" + hex.EncodeToString(mac(secret, timestamp, body))
+}
+
+// Verify checks a SignatureHeader value as the business server would.
+func Verify(secret []byte, header string, body []byte) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	sig, err := hex.DecodeString(signature)
+	if timestamp == "" || err != nil {
+		return fmt.Errorf("malformed signature header")
+	}
+	if !hmac.Equal(sig, mac(secret, timestamp, body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret []byte, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(timestamp + "."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+func newEventID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+func (p *Publisher) RegisterAdminRoutes(mux *http.ServeMux, adminMiddleware func(http.Handler) http.Handler) {
+	mux.Handle("/admin/events/replay", adminMiddleware(http.HandlerFunc(p.handleReplay)))
+}
+
+// handleReplay serves POST /admin/events/replay with {"id": "<event id>"}.
+func (p *Publisher) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	err := p.Replay(body.ID)
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		writeError(w, http.StatusNotFound, "event not found")
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to replay event")
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
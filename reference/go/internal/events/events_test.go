@@ -0,0 +1,233 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "webhook-secret"
+
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	// reject answers 422 to events of this type.
+	reject   string
+	rejected int
+	events   []Event
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := Verify([]byte(testSecret), req.Header.Get(SignatureHeader), body); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var event Event
+	_ = json.Unmarshal(body, &event)
+	if event.Type == r.reject {
+		r.rejected++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+	r.events = append(r.events, event)
+}
+
+func (r *receiver) waitFor(t *testing.T, n int) []Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		got := append([]Event(nil), r.events...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d events, got %d", n, len(got))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliversSignedEventsInOrderWithRetries(t *testing.T) {
+	recv := &receiver{failures: 2}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	publisher := testPublisher(server.URL, NewMemoryOutbox())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(ctx)
+
+	first, err := publisher.Emit(TypeTransactionCreated, map[string]string{"id": "tx-1"})
+	if err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	second, _ := publisher.Emit(TypeTransactionStatusChanged, map[string]string{"id": "tx-1"})
+
+	got := recv.waitFor(t, 2)
+	if got[0].ID != first.ID || got[0].Type != TypeTransactionCreated || got[1].ID != second.ID {
+		t.Fatalf("expected events in emission order, got %+v", got)
+	}
+	if string(got[0].Data) != `{"id":"tx-1"}` {
+		t.Fatalf("unexpected event data: %s", got[0].Data)
+	}
+	if pending := publisher.Outbox.Pending(); len(pending) != 0 {
+		t.Fatalf("expected delivered events to be acked, got %+v", pending)
+	}
+}
+
+func TestFileOutboxSurvivesRestartAndReplays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	outbox, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	// No webhook is running yet, so both events stay pending on disk.
+	offline := testPublisher("http://127.0.0.1:0", outbox)
+	first, _ := offline.Emit(TypeQuoteCreated, map[string]string{"id": "q-1"})
+	second, _ := offline.Emit(TypeCustomerUpdated, map[string]string{"id": "c-1"})
+
+	reopened, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	if pending := reopened.Pending(); len(pending) != 2 || pending[0].ID != first.ID || pending[1].ID != second.ID {
+		t.Fatalf("expected both events pending after restart, got %+v", pending)
+	}
+
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	publisher := testPublisher(server.URL, reopened)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(ctx)
+	recv.waitFor(t, 2)
+
+	if err := publisher.Replay("missing"); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound, got %v", err)
+	}
+	if err := publisher.Replay(second.ID); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got := recv.waitFor(t, 3); got[2].ID != second.ID {
+		t.Fatalf("expected replayed event %s, got %+v", second.ID, got[2])
+	}
+
+	final, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	if pending := final.Pending(); len(pending) != 0 {
+		t.Fatalf("expected acks to be journaled, got %+v", pending)
+	}
+}
+
+func TestRejectedEventIsDeadLetteredAndDoesNotBlockTheQueue(t *testing.T) {
+	recv := &receiver{reject: TypeCustomerUpdated}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	publisher := testPublisher(server.URL, NewMemoryOutbox())
+	publisher.MaxRejections = 3
+	poison, _ := publisher.Emit(TypeCustomerUpdated, map[string]string{"id": "c-1"})
+	next, _ := publisher.Emit(TypeQuoteCreated, map[string]string{"id": "q-1"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(ctx)
+
+	if got := recv.waitFor(t, 1); got[0].ID != next.ID {
+		t.Fatalf("expected the next event to be delivered, got %+v", got)
+	}
+	recv.mu.Lock()
+	rejected := recv.rejected
+	recv.reject = ""
+	recv.mu.Unlock()
+	if rejected != 3 {
+		t.Fatalf("expected the event to be tried 3 times, got %d", rejected)
+	}
+	if pending := publisher.Outbox.Pending(); len(pending) != 0 {
+		t.Fatalf("expected the rejected event to leave the queue, got %+v", pending)
+	}
+
+	if err := publisher.Replay(poison.ID); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got := recv.waitFor(t, 3); got[1].ID != poison.ID || got[2].ID != next.ID {
+		t.Fatalf("expected the dead letter and the events after it to be redelivered, got %+v", got)
+	}
+}
+
+func TestFileOutboxCompactsDeliveredEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	outbox, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	now := time.Now().UTC()
+	old := Event{ID: "e-old", Type: TypeQuoteCreated, Timestamp: now.Add(-48 * time.Hour), Data: json.RawMessage(`{}`)}
+	recent := Event{ID: "e-recent", Type: TypeQuoteCreated, Timestamp: now.Add(-time.Hour), Data: json.RawMessage(`{}`)}
+	stuck := Event{ID: "e-stuck", Type: TypeQuoteCreated, Timestamp: now.Add(-72 * time.Hour), Data: json.RawMessage(`{}`)}
+	for _, event := range []Event{stuck, old, recent} {
+		if err := outbox.Append(event); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	for _, id := range []string{"e-old", "e-recent"} {
+		if err := outbox.Ack(id); err != nil {
+			t.Fatalf("ack: %v", err)
+		}
+	}
+	if err := outbox.Compact(now.Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	reopened, err := NewFileOutbox(path)
+	if err != nil {
+		t.Fatalf("reopen outbox: %v", err)
+	}
+	if pending := reopened.Pending(); len(pending) != 1 || pending[0].ID != "e-stuck" {
+		t.Fatalf("expected only the undelivered event to be pending, got %+v", pending)
+	}
+	if err := reopened.Requeue("e-old"); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("expected the old delivered event to be forgotten, got %v", err)
+	}
+	if err := reopened.Requeue("e-recent"); err != nil {
+		t.Fatalf("expected a recent delivered event to be kept for replay: %v", err)
+	}
+	if pending := reopened.Pending(); len(pending) != 2 || pending[0].ID != "e-stuck" || pending[1].ID != "e-recent" {
+		t.Fatalf("unexpected pending events %+v", pending)
+	}
+}
+
+func TestVerifyRejectsWrongSecret(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	header := Sign([]byte(testSecret), body, time.Now())
+	if err := Verify([]byte(testSecret), header, body); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify([]byte("other"), header, body); err == nil {
+		t.Fatal("expected a signature from another secret to fail")
+	}
+}
+
+func testPublisher(url string, outbox Outbox) *Publisher {
+	p := NewPublisher(url, testSecret, outbox)
+	p.RetryDelay = 10 * time.Millisecond
+	p.MaxDelay = 40 * time.Millisecond
+	return p
+}
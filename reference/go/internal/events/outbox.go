@@ -0,0 +1,305 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox keeps every event in emission order with its delivery state.
+type Outbox interface {
+	Append(event Event) error
+	Ack(id string) error
+	// Pending returns undelivered events in emission order.
+	Pending() []Event
+	// Requeue marks the event with id and every later event undelivered.
+	Requeue(id string) error
+	// DeadLetter sets aside an event until Requeue covers it again.
+	DeadLetter(id, reason string) error
+	// Compact forgets delivered events emitted before before.
+	Compact(before time.Time) error
+}
+
+type entry struct {
+	event     Event
+	delivered bool
+	dead      bool
+}
+
+type MemoryOutbox struct {
+	mu      sync.RWMutex
+	entries []entry
+	index   map[string]int
+	first   int
+}
+
+// FileOutbox journals outbox changes as JSON lines so undelivered events
+// survive restarts. The journal is replayed into memory when it is opened.
+type FileOutbox struct {
+	mu   sync.Mutex
+	path string
+	mem  *MemoryOutbox
+}
+
+// record is one journal line; exactly one of its actions is set.
+type record struct {
+	Event      *Event `json:"event,omitempty"`
+	Ack        string `json:"ack,omitempty"`
+	Requeue    string `json:"requeue,omitempty"`
+	DeadLetter string `json:"dead_letter,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{index: map[string]int{}}
+}
+
+func NewFileOutbox(path string) (*FileOutbox, error) {
+	o := &FileOutbox{path: path, mem: NewMemoryOutbox()}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open event outbox: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("event outbox line %d: %w", line, err)
+		}
+		if err := o.mem.apply(r); err != nil {
+			return nil, fmt.Errorf("event outbox line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read event outbox: %w", err)
+	}
+	return o, nil
+}
+
+func (o *MemoryOutbox) Append(event Event) error {
+	return o.apply(record{Event: &event})
+}
+
+func (o *MemoryOutbox) Ack(id string) error {
+	return o.apply(record{Ack: id})
+}
+
+func (o *MemoryOutbox) Requeue(id string) error {
+	return o.apply(record{Requeue: id})
+}
+
+func (o *MemoryOutbox) DeadLetter(id, reason string) error {
+	return o.apply(record{DeadLetter: id, Reason: reason})
+}
+
+func (o *MemoryOutbox) Compact(before time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.compact(before)
+	return nil
+}
+
+func (o *MemoryOutbox) Pending() []Event {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var pending []Event
+	for _, e := range o.entries[o.first:] {
+		if !e.delivered && !e.dead {
+			pending = append(pending, e.event)
+		}
+	}
+	return pending
+}
+
+func (o *MemoryOutbox) apply(r record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch {
+	case r.Event != nil:
+		if _, exists := o.index[r.Event.ID]; exists {
+			return fmt.Errorf("duplicate event %s", r.Event.ID)
+		}
+		o.index[r.Event.ID] = len(o.entries)
+		o.entries = append(o.entries, entry{event: *r.Event})
+	case r.Ack != "":
+		i, ok := o.index[r.Ack]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrEventNotFound, r.Ack)
+		}
+		o.entries[i].delivered = true
+		o.advance()
+	case r.Requeue != "":
+		i, ok := o.index[r.Requeue]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrEventNotFound, r.Requeue)
+		}
+		o.first = min(o.first, i)
+		for ; i < len(o.entries); i++ {
+			o.entries[i].delivered = false
+			o.entries[i].dead = false
+		}
+	case r.DeadLetter != "":
+		i, ok := o.index[r.DeadLetter]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrEventNotFound, r.DeadLetter)
+		}
+		o.entries[i].dead = true
+		o.advance()
+	}
+	return nil
+}
+
+func (o *MemoryOutbox) advance() {
+	for o.first < len(o.entries) && (o.entries[o.first].delivered || o.entries[o.first].dead) {
+		o.first++
+	}
+}
+
+func (o *MemoryOutbox) compact(before time.Time) {
+	kept := o.entries[:0]
+	for _, e := range o.entries {
+		if e.delivered && e.event.Timestamp.Before(before) {
+			delete(o.index, e.event.ID)
+			continue
+		}
+		o.index[e.event.ID] = len(kept)
+		kept = append(kept, e)
+	}
+	clear(o.entries[len(kept):])
+	o.entries = kept
+	o.first = 0
+	o.advance()
+}
+
+func (o *MemoryOutbox) records() []record {
+	var records []record
+	for _, e := range o.entries {
+		records = append(records, record{Event: &e.event})
+		if e.delivered {
+			records = append(records, record{Ack: e.event.ID})
+		}
+		if e.dead {
+			records = append(records, record{DeadLetter: e.event.ID})
+		}
+	}
+	return records
+}
+
+func (o *FileOutbox) Append(event Event) error {
+	return o.write(record{Event: &event})
+}
+
+func (o *FileOutbox) Ack(id string) error {
+	return o.write(record{Ack: id})
+}
+
+func (o *FileOutbox) Requeue(id string) error {
+	return o.write(record{Requeue: id})
+}
+
+func (o *FileOutbox) DeadLetter(id, reason string) error {
+	return o.write(record{DeadLetter: id, Reason: reason})
+}
+
+// Compact rewrites the journal and renames it over the old one.
+func (o *FileOutbox) Compact(before time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.mem.mu.Lock()
+	o.mem.compact(before)
+	records := o.mem.records()
+	o.mem.mu.Unlock()
+
+	tmp := o.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open event outbox: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range records {
+		raw, err := json.Marshal(r)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("encode outbox record: %w", err)
+		}
+		w.Write(append(raw, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write event outbox: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync event outbox: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return fmt.Errorf("replace event outbox: %w", err)
+	}
+	return nil
+}
+
+func (o *FileOutbox) Pending() []Event {
+	return o.mem.Pending()
+}
+
+// write validates r against the in-memory state, then journals it before
+// applying it, so a caller only sees success once the change is on disk.
+func (o *FileOutbox) write(r record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.mem.check(r); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encode outbox record: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0o755); err != nil {
+		return fmt.Errorf("create outbox dir: %w", err)
+	}
+	f, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open event outbox: %w", err)
+	}
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write event outbox: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync event outbox: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return o.mem.apply(r)
+}
+
+func (o *MemoryOutbox) check(r record) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	id := r.Ack + r.Requeue + r.DeadLetter
+	if r.Event != nil {
+		if _, exists := o.index[r.Event.ID]; exists {
+			return fmt.Errorf("duplicate event %s", r.Event.ID)
+		}
+		return nil
+	}
+	if _, ok := o.index[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrEventNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package fees
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+const (
+	OperationDeposit  = "deposit"
+	OperationWithdraw = "withdraw"
+	OperationReceive  = "receive"
+)
+
+var ErrNoMatchingRule = errors.New("no fee rule matches request")
+
+type Request struct {
+	Operation    string
+	Type         string
+	CustomerType string
+	Asset        config.AssetID
+	Amount       decimal.Decimal
+}
+
+type Detail struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+type Result struct {
+	Total   decimal.Decimal `json:"total"`
+	Asset   string          `json:"asset"`
+	Details []Detail        `json:"details,omitempty"`
+}
+
+type Summary struct {
+	Fixed   decimal.Decimal
+	Percent decimal.Decimal
+	Minimum decimal.Decimal
+}
+
+type Calculator interface {
+	Calculate(req Request) (Result, error)
+	Summary(operation string, asset config.AssetID) (Summary, bool)
+}
+
+type Rule struct {
+	AssetCode    string          `json:"asset_code,omitempty"`
+	AssetIssuer  string          `json:"asset_issuer,omitempty"`
+	Operation    string          `json:"operation,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	CustomerType string          `json:"customer_type,omitempty"`
+	MinAmount    decimal.Decimal `json:"min_amount,omitzero"`
+	MaxAmount    decimal.Decimal `json:"max_amount,omitzero"`
+	Fixed        decimal.Decimal `json:"fixed,omitzero"`
+	Percent      decimal.Decimal `json:"percent,omitzero"`
+	Minimum      decimal.Decimal `json:"minimum,omitzero"`
+	Maximum      decimal.Decimal `json:"maximum,omitzero"`
+}
+
+type RulesCalculator struct {
+	Rules    []Rule
+	Assets   []config.Asset
+	Rounding decimal.RoundingMode
+}
+
+func NewRulesCalculator(assets []config.Asset, rounding decimal.RoundingMode, rules []Rule) *RulesCalculator {
+	return &RulesCalculator{Rules: rules, Assets: assets, Rounding: rounding}
+}
+
+func RulesFromAssets(assets []config.Asset) []Rule {
+	rules := make([]Rule, 0, 3*len(assets))
+	for _, asset := range assets {
+		for _, operation := range []string{OperationDeposit, OperationWithdraw, OperationReceive} {
+			settings := asset.Operation(operation)
+			rule := Rule{
+				AssetCode:   asset.Code,
+				AssetIssuer: asset.Issuer,
+				Operation:   operation,
+				Fixed:       asset.FeeFixed,
+				Percent:     asset.FeePercent,
+				Minimum:     settings.FeeMinimum,
+			}
+			if settings.FeeFixed != nil {
+				rule.Fixed = *settings.FeeFixed
+			}
+			if settings.FeePercent != nil {
+				rule.Percent = *settings.FeePercent
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func LoadRules(path string) ([]Rule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fee rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("decode fee rules: %w", err)
+	}
+	for i, rule := range rules {
+		if rule.Operation != "" && rule.Operation != OperationDeposit && rule.Operation != OperationWithdraw && rule.Operation != OperationReceive {
+			return nil, fmt.Errorf("fee rule %d: invalid operation %q", i, rule.Operation)
+		}
+		if !rule.MaxAmount.IsZero() && rule.MaxAmount.Cmp(rule.MinAmount) <= 0 {
+			return nil, fmt.Errorf("fee rule %d: max_amount must exceed min_amount", i)
+		}
+		if !rule.Maximum.IsZero() && rule.Maximum.LessThan(rule.Minimum) {
+			return nil, fmt.Errorf("fee rule %d: maximum must not be below minimum", i)
+		}
+	}
+	return rules, nil
+}
+
+func (c *RulesCalculator) Calculate(req Request) (Result, error) {
+	rule, ok := c.match(req)
+	if !ok {
+		return Result{}, ErrNoMatchingRule
+	}
+	decimals := c.decimals(req.Asset)
+
+	fixed := rule.Fixed.Round(decimals, c.Rounding)
+	percent := req.Amount.Mul(rule.Percent).Shift(-2).Round(decimals, c.Rounding)
+	total := fixed.Add(percent)
+
+	result := Result{Asset: req.Asset.String()}
+	if !fixed.IsZero() {
+		result.Details = append(result.Details, Detail{Name: "Fixed fee", Amount: fixed})
+	}
+	if !percent.IsZero() {
+		result.Details = append(result.Details, Detail{
+			Name:        "Percentage fee",
+			Description: rule.Percent.String() + "% of amount",
+			Amount:      percent,
+		})
+	}
+
+	if !rule.Minimum.IsZero() && total.LessThan(rule.Minimum) {
+		adjustment := rule.Minimum.Round(decimals, c.Rounding).Sub(total)
+		result.Details = append(result.Details, Detail{Name: "Minimum fee adjustment", Amount: adjustment})
+		total = total.Add(adjustment)
+	}
+	if !rule.Maximum.IsZero() && total.GreaterThan(rule.Maximum) {
+		adjustment := rule.Maximum.Round(decimals, c.Rounding).Sub(total)
+		result.Details = append(result.Details, Detail{Name: "Maximum fee cap", Amount: adjustment})
+		total = total.Add(adjustment)
+	}
+	result.Total = total
+	return result, nil
+}
+
+func (c *RulesCalculator) Summary(operation string, asset config.AssetID) (Summary, bool) {
+	for _, rule := range c.Rules {
+		if !matchesAsset(rule, asset) || !matches(rule.Operation, operation) {
+			continue
+		}
+		if !matches(rule.Type, "") || !matches(rule.CustomerType, "") ||
+			!rule.MinAmount.IsZero() || !rule.MaxAmount.IsZero() || !rule.Maximum.IsZero() {
+			return Summary{}, false
+		}
+		return Summary{Fixed: rule.Fixed, Percent: rule.Percent, Minimum: rule.Minimum}, true
+	}
+	return Summary{}, false
+}
+
+func (c *RulesCalculator) match(req Request) (Rule, bool) {
+	for _, rule := range c.Rules {
+		if !matchesAsset(rule, req.Asset) ||
+			!matches(rule.Operation, req.Operation) ||
+			!matches(rule.Type, req.Type) ||
+			!matches(rule.CustomerType, req.CustomerType) {
+			continue
+		}
+		if req.Amount.LessThan(rule.MinAmount) {
+			continue
+		}
+		if !rule.MaxAmount.IsZero() && !req.Amount.LessThan(rule.MaxAmount) {
+			continue
+		}
+		return rule, true
+	}
+	return Rule{}, false
+}
+
+func (c *RulesCalculator) decimals(id config.AssetID) int32 {
+	for _, asset := range c.Assets {
+		if asset.ID() == id {
+			return asset.SignificantDecimals
+		}
+	}
+	return config.DefaultSignificantDecimals
+}
+
+func matchesAsset(rule Rule, asset config.AssetID) bool {
+	return matches(rule.AssetCode, asset.Code) && matches(rule.AssetIssuer, asset.Issuer)
+}
+
+func matches(pattern, value string) bool {
+	return pattern == "" || pattern == "*" || pattern == value
+}
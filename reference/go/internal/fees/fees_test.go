@@ -0,0 +1,128 @@
+package fees
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+var testUSDC = config.AssetID{Scheme: config.SchemeStellar, Code: "USDC"}
+
+func testAssets() []config.Asset {
+	return []config.Asset{{Code: "USDC", Enabled: true, SignificantDecimals: 2}}
+}
+
+func TestRulesFromAssets(t *testing.T) {
+	assets := testAssets()
+	assets[0].FeeFixed = decimal.MustParse("1")
+	assets[0].FeePercent = decimal.MustParse("0.1")
+	calc := NewRulesCalculator(assets, decimal.RoundHalfEven, RulesFromAssets(assets))
+
+	result, err := calc.Calculate(Request{Operation: OperationDeposit, Asset: testUSDC, Amount: decimal.MustParse("105")})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+	if !result.Total.Equal(decimal.MustParse("1.10")) {
+		t.Fatalf("expected total 1.10, got %s", result.Total)
+	}
+	if len(result.Details) != 2 || result.Asset != "stellar:USDC" {
+		t.Fatalf("unexpected breakdown: %+v", result)
+	}
+
+	summary, ok := calc.Summary(OperationWithdraw, testUSDC)
+	if !ok || !summary.Fixed.Equal(decimal.MustParse("1")) || !summary.Percent.Equal(decimal.MustParse("0.1")) {
+		t.Fatalf("unexpected summary: %+v ok=%v", summary, ok)
+	}
+}
+
+func TestRulesMatchDirectionTiersAndCustomerType(t *testing.T) {
+	rules := []Rule{
+		{AssetCode: "USDC", Operation: OperationDeposit, CustomerType: "business", Percent: decimal.MustParse("0.5")},
+		{AssetCode: "USDC", Operation: OperationDeposit, MaxAmount: decimal.MustParse("1000"), Fixed: decimal.MustParse("2")},
+		{AssetCode: "USDC", Operation: OperationDeposit, MinAmount: decimal.MustParse("1000"), Percent: decimal.MustParse("0.1")},
+		{AssetCode: "USDC", Operation: OperationWithdraw, Percent: decimal.MustParse("1"), Minimum: decimal.MustParse("3"), Maximum: decimal.MustParse("10")},
+	}
+	calc := NewRulesCalculator(testAssets(), decimal.RoundHalfEven, rules)
+
+	cases := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{"business", Request{Operation: OperationDeposit, CustomerType: "business", Amount: decimal.MustParse("100")}, "0.50"},
+		{"low tier", Request{Operation: OperationDeposit, Amount: decimal.MustParse("999.99")}, "2.00"},
+		{"high tier", Request{Operation: OperationDeposit, Amount: decimal.MustParse("1000")}, "1.00"},
+		{"minimum", Request{Operation: OperationWithdraw, Amount: decimal.MustParse("50")}, "3.00"},
+		{"in range", Request{Operation: OperationWithdraw, Amount: decimal.MustParse("500")}, "5.00"},
+		{"maximum", Request{Operation: OperationWithdraw, Amount: decimal.MustParse("5000")}, "10.00"},
+	}
+	for _, tc := range cases {
+		tc.req.Asset = testUSDC
+		result, err := calc.Calculate(tc.req)
+		if err != nil {
+			t.Fatalf("%s: calculate: %v", tc.name, err)
+		}
+		if !result.Total.Equal(decimal.MustParse(tc.want)) {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, result.Total)
+		}
+	}
+
+	if _, err := calc.Calculate(Request{Operation: OperationDeposit, Asset: config.AssetID{Scheme: config.SchemeStellar, Code: "EURC"}, Amount: decimal.MustParse("1")}); !errors.Is(err, ErrNoMatchingRule) {
+		t.Fatalf("expected ErrNoMatchingRule, got %v", err)
+	}
+	if _, ok := calc.Summary(OperationDeposit, testUSDC); ok {
+		t.Fatalf("expected no flat summary for customer-type rule")
+	}
+	if _, ok := calc.Summary(OperationWithdraw, testUSDC); ok {
+		t.Fatalf("expected no flat summary for capped rule")
+	}
+}
+
+func TestRulesDistinguishIssuers(t *testing.T) {
+	const issuerA = "GAXQ3AQ5UXBFBZZ4QQJ2SW4PZRZUDQGDBG6KKHAE6UXAS6IHLJUVHH5I"
+	const issuerB = "GBL4D5ZKTHJDUG6MSRMYWXMJCR4GK3ECGD3DIUOYFLXPC3MSSNBWZWFU"
+	assets := []config.Asset{
+		{Code: "USDC", Issuer: issuerA, Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("1")},
+		{Code: "USDC", Issuer: issuerB, Enabled: true, SignificantDecimals: 4, FeeFixed: decimal.MustParse("2")},
+	}
+	calc := NewRulesCalculator(assets, decimal.RoundHalfEven, RulesFromAssets(assets))
+
+	result, err := calc.Calculate(Request{Operation: OperationDeposit, Asset: assets[1].ID(), Amount: decimal.MustParse("10")})
+	if err != nil {
+		t.Fatalf("calculate: %v", err)
+	}
+	if !result.Total.Equal(decimal.MustParse("2")) || result.Asset != "stellar:USDC:"+issuerB {
+		t.Fatalf("unexpected result for second issuer: %+v", result)
+	}
+	summary, ok := calc.Summary(OperationDeposit, assets[0].ID())
+	if !ok || !summary.Fixed.Equal(decimal.MustParse("1")) {
+		t.Fatalf("unexpected summary for first issuer: %+v", summary)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`[{"asset_code":"USDC","operation":"withdraw","fixed":"1.5","minimum":"2"}]`), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	rules, err := LoadRules(valid)
+	if err != nil {
+		t.Fatalf("load rules: %v", err)
+	}
+	if len(rules) != 1 || !rules[0].Fixed.Equal(decimal.MustParse("1.5")) {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`[{"operation":"swap"}]`), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	if _, err := LoadRules(invalid); err == nil {
+		t.Fatalf("expected invalid operation to be rejected")
+	}
+}
@@ -0,0 +1,191 @@
+// Package keyring seals data with envelope encryption: each value gets a
+// fresh data key, and only that data key is encrypted under a master key
+// held by a KeyProvider. Rotating the master key re-wraps data keys without
+// touching the ciphertext they protect.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const keySize = 32
+
+var (
+	ErrUnknownKey = errors.New("unknown key")
+	ErrDecrypt    = errors.New("decryption failed")
+)
+
+// KeyProvider wraps data keys under a master key. A KMS-backed provider can
+// implement it without ever exposing the master key.
+type KeyProvider interface {
+	// PrimaryKeyID names the key WrapKey uses.
+	PrimaryKeyID() string
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Envelope is a sealed value and the wrapped data key that opens it.
+type Envelope struct {
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal encrypts plaintext under a new data key. aad is authenticated but not
+// stored; Open must be given the same aad.
+func Seal(keys KeyProvider, plaintext, aad []byte) (Envelope, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Envelope{}, err
+	}
+	nonce, ciphertext, err := encrypt(dataKey, plaintext, aad)
+	if err != nil {
+		return Envelope{}, err
+	}
+	keyID, wrapped, err := keys.WrapKey(dataKey)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{KeyID: keyID, WrappedKey: wrapped, Nonce: nonce, Ciphertext: ciphertext}, nil
+}
+
+func Open(keys KeyProvider, env Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := keys.UnwrapKey(env.KeyID, env.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(dataKey, env.Nonce, env.Ciphertext, aad)
+}
+
+// Rewrap moves env's data key to the primary key. The ciphertext is kept.
+func Rewrap(keys KeyProvider, env Envelope) (Envelope, error) {
+	dataKey, err := keys.UnwrapKey(env.KeyID, env.WrappedKey)
+	if err != nil {
+		return env, err
+	}
+	keyID, wrapped, err := keys.WrapKey(dataKey)
+	if err != nil {
+		return env, err
+	}
+	env.KeyID = keyID
+	env.WrappedKey = wrapped
+	return env, nil
+}
+
+// FileKeyring is a KeyProvider backed by master keys in a local JSON file. It
+// is meant for development; production deployments should keep master keys
+// in a KMS.
+type FileKeyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+type keyringFile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LoadFile reads a keyring of the form
+// {"primary": "2026-10", "keys": {"2026-10": "<base64 32-byte key>"}}.
+// Older keys stay listed until nothing is wrapped under them.
+func LoadFile(path string) (*FileKeyring, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keyringFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse keyring %s: %w", path, err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("keyring %s: key %s must be %d base64-encoded bytes", path, id, keySize)
+		}
+		keys[id] = key
+	}
+	return NewFileKeyring(file.Primary, keys)
+}
+
+func NewFileKeyring(primary string, keys map[string][]byte) (*FileKeyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: primary key %q", ErrUnknownKey, primary)
+	}
+	for id, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %s must be %d bytes", id, keySize)
+		}
+	}
+	return &FileKeyring{primary: primary, keys: keys}, nil
+}
+
+func (k *FileKeyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+func (k *FileKeyring) WrapKey(dataKey []byte) (string, []byte, error) {
+	nonce, ciphertext, err := encrypt(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return "", nil, err
+	}
+	return k.primary, append(nonce, ciphertext...), nil
+}
+
+func (k *FileKeyring) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	return decrypt(key, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], []byte(keyID))
+}
+
+func encrypt(key, plaintext, aad []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
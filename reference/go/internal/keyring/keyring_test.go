@@ -0,0 +1,102 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealAndOpen(t *testing.T) {
+	keys := writeKeyring(t, "k1", "k1")
+	env, err := Seal(keys, []byte("ada@example.com"), []byte("c-1"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if env.KeyID != "k1" || bytes.Contains(env.Ciphertext, []byte("ada@example.com")) {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	got, err := Open(keys, env, []byte("c-1"))
+	if err != nil || string(got) != "ada@example.com" {
+		t.Fatalf("expected plaintext back, got %q %v", got, err)
+	}
+
+	if _, err := Open(keys, env, []byte("c-2")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected a different aad to fail, got %v", err)
+	}
+	tampered := env
+	tampered.Ciphertext = append([]byte(nil), env.Ciphertext...)
+	tampered.Ciphertext[0] ^= 1
+	if _, err := Open(keys, tampered, []byte("c-1")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected tampered ciphertext to fail, got %v", err)
+	}
+	env.KeyID = "k9"
+	if _, err := Open(keys, env, []byte("c-1")); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected an unknown key id to fail, got %v", err)
+	}
+}
+
+func TestRewrapKeepsCiphertext(t *testing.T) {
+	old := writeKeyring(t, "k1", "k1")
+	env, err := Seal(old, []byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	rotated := writeKeyring(t, "k2", "k1", "k2")
+	moved, err := Rewrap(rotated, env)
+	if err != nil {
+		t.Fatalf("rewrap: %v", err)
+	}
+	if moved.KeyID != "k2" || !bytes.Equal(moved.Ciphertext, env.Ciphertext) {
+		t.Fatalf("expected only the data key to move, got %+v", moved)
+	}
+	if got, err := Open(rotated, moved, nil); err != nil || string(got) != "secret" {
+		t.Fatalf("expected rewrapped envelope to open, got %q %v", got, err)
+	}
+}
+
+func TestLoadFileRejectsBadKeyrings(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"missing primary": `{"primary": "k2", "keys": {"k1": "` + encodedKey(1) + `"}}`,
+		"short key":       `{"primary": "k1", "keys": {"k1": "` + base64.StdEncoding.EncodeToString([]byte("short")) + `"}}`,
+		"not json":        `primary=k1`,
+	} {
+		path := filepath.Join(dir, "keyring.json")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+// writeKeyring writes a keyring file holding ids, where key i is filled with
+// byte i+1, and loads it.
+func writeKeyring(t *testing.T, primary string, ids ...string) *FileKeyring {
+	t.Helper()
+	body := `{"primary": "` + primary + `", "keys": {`
+	for i, id := range ids {
+		if i > 0 {
+			body += ","
+		}
+		body += `"` + id + `": "` + encodedKey(byte(i+1)) + `"`
+	}
+	body += "}}"
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	return keys
+}
+
+func encodedKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
@@ -0,0 +1,103 @@
+package memo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	TypeID   = "id"
+	TypeText = "text"
+	TypeHash = "hash"
+)
+
+const maxAttempts = 8
+
+type Memo struct {
+	Value string `json:"memo"`
+	Type  string `json:"memo_type"`
+}
+
+type Allocator interface {
+	Allocate(txID string) (Memo, error)
+}
+
+// MemoryAllocator generates random memos and keeps no state of its own: the
+// transaction store, consulted through InUse, is the record of which memos
+// are taken.
+type MemoryAllocator struct {
+	// InUse reports memos already on a stored transaction, which Allocate
+	// skips.
+	InUse func(m Memo) bool
+
+	memoType string
+	random   io.Reader
+}
+
+func ValidType(memoType string) bool {
+	switch memoType {
+	case TypeID, TypeText, TypeHash:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewMemoryAllocator(memoType string) *MemoryAllocator {
+	if !ValidType(memoType) {
+		memoType = TypeID
+	}
+	return &MemoryAllocator{
+		memoType: memoType,
+		random:   rand.Reader,
+	}
+}
+
+func (a *MemoryAllocator) Allocate(txID string) (Memo, error) {
+	if txID == "" {
+		return Memo{}, fmt.Errorf("transaction id is required")
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		m, err := a.generate()
+		if err != nil {
+			return Memo{}, err
+		}
+		if a.InUse != nil && a.InUse(m) {
+			continue
+		}
+		return m, nil
+	}
+	return Memo{}, fmt.Errorf("allocate %s memo: exhausted %d attempts", a.memoType, maxAttempts)
+}
+
+func (a *MemoryAllocator) generate() (Memo, error) {
+	switch a.memoType {
+	case TypeText:
+		buf := make([]byte, 14)
+		if _, err := io.ReadFull(a.random, buf); err != nil {
+			return Memo{}, fmt.Errorf("generate text memo: %w", err)
+		}
+		return Memo{Value: hex.EncodeToString(buf), Type: TypeText}, nil
+	case TypeHash:
+		buf := make([]byte, 32)
+		if _, err := io.ReadFull(a.random, buf); err != nil {
+			return Memo{}, fmt.Errorf("generate hash memo: %w", err)
+		}
+		return Memo{Value: base64.StdEncoding.EncodeToString(buf), Type: TypeHash}, nil
+	default:
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(a.random, buf); err != nil {
+			return Memo{}, fmt.Errorf("generate id memo: %w", err)
+		}
+		id := binary.BigEndian.Uint64(buf) >> 1
+		if id == 0 {
+			id = 1
+		}
+		return Memo{Value: strconv.FormatUint(id, 10), Type: TypeID}, nil
+	}
+}
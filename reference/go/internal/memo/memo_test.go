@@ -0,0 +1,80 @@
+package memo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func TestAllocateUniqueMemosPerType(t *testing.T) {
+	for _, memoType := range []string{TypeID, TypeText, TypeHash} {
+		allocator := NewMemoryAllocator(memoType)
+		seen := map[string]bool{}
+		for i := 0; i < 50; i++ {
+			txID := "tx-" + strconv.Itoa(i)
+			m, err := allocator.Allocate(txID)
+			if err != nil {
+				t.Fatalf("allocate %s memo: %v", memoType, err)
+			}
+			if m.Type != memoType {
+				t.Fatalf("expected memo type %s, got %s", memoType, m.Type)
+			}
+			if seen[m.Value] {
+				t.Fatalf("duplicate %s memo %s", memoType, m.Value)
+			}
+			seen[m.Value] = true
+		}
+	}
+}
+
+func TestAllocateMemoFormats(t *testing.T) {
+	m, err := NewMemoryAllocator(TypeID).Allocate("tx-id")
+	if err != nil {
+		t.Fatalf("allocate id memo: %v", err)
+	}
+	if _, err := strconv.ParseUint(m.Value, 10, 64); err != nil {
+		t.Fatalf("expected numeric id memo, got %q", m.Value)
+	}
+
+	m, err = NewMemoryAllocator(TypeText).Allocate("tx-text")
+	if err != nil {
+		t.Fatalf("allocate text memo: %v", err)
+	}
+	if len(m.Value) > 28 {
+		t.Fatalf("text memo exceeds 28 bytes: %q", m.Value)
+	}
+
+	m, err = NewMemoryAllocator(TypeHash).Allocate("tx-hash")
+	if err != nil {
+		t.Fatalf("allocate hash memo: %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(m.Value)
+	if err != nil || len(raw) != 32 {
+		t.Fatalf("expected base64 encoded 32 byte hash memo, got %q", m.Value)
+	}
+}
+
+func TestAllocateGivesUpWhenEveryMemoIsInUse(t *testing.T) {
+	allocator := NewMemoryAllocator(TypeID)
+	allocator.InUse = func(Memo) bool { return true }
+	if _, err := allocator.Allocate("tx-1"); err == nil {
+		t.Fatal("expected allocation to fail once every attempt is in use")
+	}
+}
+
+func TestAllocateSkipsMemosInUse(t *testing.T) {
+	allocator := NewMemoryAllocator(TypeText)
+	allocator.random = bytes.NewReader(append(bytes.Repeat([]byte{0x01}, 14), bytes.Repeat([]byte{0x02}, 14)...))
+	stored := Memo{Value: hex.EncodeToString(bytes.Repeat([]byte{0x01}, 14)), Type: TypeText}
+	allocator.InUse = func(m Memo) bool { return m == stored }
+
+	m, err := allocator.Allocate("tx-1")
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	if m == stored {
+		t.Fatalf("expected a memo already on a stored transaction to be skipped, got %s", m.Value)
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"strings"
@@ -11,7 +12,10 @@ import (
 
 type contextKey string
 
-const accountKey contextKey = "sep10-account"
+const (
+	accountKey      contextKey = "sep10-account"
+	clientDomainKey contextKey = "sep10-client-domain"
+)
 
 func AccountFromContext(ctx context.Context) string {
 	if v, ok := ctx.Value(accountKey).(string); ok {
@@ -20,6 +24,15 @@ func AccountFromContext(ctx context.Context) string {
 	return ""
 }
 
+// ClientDomainFromContext returns the client_domain claim of the SEP-10 token,
+// which identifies the wallet or sending anchor behind the request.
+func ClientDomainFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(clientDomainKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 func SEP10Auth(jwtSecret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -35,11 +48,29 @@ func SEP10Auth(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 			ctx := context.WithValue(r.Context(), accountKey, claims.Subject)
+			ctx = context.WithValue(ctx, clientDomainKey, claims.ClientDomain)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+func AdminAuth(apiKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := strings.TrimSpace(r.Header.Get("Authorization"))
+			if apiKey == "" || !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+				writeJSONError(w, http.StatusForbidden, "missing admin credentials")
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(auth[7:])), []byte(apiKey)) != 1 {
+				writeJSONError(w, http.StatusForbidden, "invalid admin credentials")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func writeJSONError(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
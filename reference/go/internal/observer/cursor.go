@@ -0,0 +1,93 @@
+package observer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type CursorStore interface {
+	LoadCursor(name string) (string, error)
+	SaveCursor(name, cursor string) error
+}
+
+type MemoryCursorStore struct {
+	mu      sync.RWMutex
+	cursors map[string]string
+}
+
+type FileCursorStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: map[string]string{}}
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+func (s *MemoryCursorStore) LoadCursor(name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cursors[name], nil
+}
+
+func (s *MemoryCursorStore) SaveCursor(name, cursor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[name] = cursor
+	return nil
+}
+
+func (s *FileCursorStore) LoadCursor(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursors, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	return cursors[name], nil
+}
+
+func (s *FileCursorStore) SaveCursor(name, cursor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursors, err := s.read()
+	if err != nil {
+		return err
+	}
+	cursors[name] = cursor
+
+	raw, err := json.Marshal(cursors)
+	if err != nil {
+		return fmt.Errorf("encode cursors: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create cursor dir: %w", err)
+	}
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write cursors: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileCursorStore) read() (map[string]string, error) {
+	cursors := map[string]string{}
+	raw, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return cursors, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cursors: %w", err)
+	}
+	if err := json.Unmarshal(raw, &cursors); err != nil {
+		return nil, fmt.Errorf("decode cursors: %w", err)
+	}
+	return cursors, nil
+}
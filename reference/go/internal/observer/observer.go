@@ -0,0 +1,110 @@
+package observer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type Payment struct {
+	ID              string `json:"id"`
+	Cursor          string `json:"paging_token"`
+	TransactionHash string `json:"transaction_hash"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	AssetCode       string `json:"asset_code"`
+	AssetIssuer     string `json:"asset_issuer,omitempty"`
+	Amount          string `json:"amount"`
+	Memo            string `json:"memo,omitempty"`
+	MemoType        string `json:"memo_type,omitempty"`
+}
+
+type LedgerSource interface {
+	StreamPayments(ctx context.Context, account, cursor string, handler func(Payment) error) error
+}
+
+type PaymentHandler interface {
+	HandlePayment(ctx context.Context, payment Payment) error
+}
+
+// Handlers fans a payment out to several handlers, each of which ignores
+// payments for transactions it does not own.
+type Handlers []PaymentHandler
+
+func (h Handlers) HandlePayment(ctx context.Context, payment Payment) error {
+	for _, handler := range h {
+		if err := handler.HandlePayment(ctx, payment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Observer struct {
+	Name       string
+	Account    string
+	Source     LedgerSource
+	Cursors    CursorStore
+	Handler    PaymentHandler
+	RetryDelay time.Duration
+}
+
+func New(name, account string, source LedgerSource, cursors CursorStore, handler PaymentHandler) *Observer {
+	return &Observer{
+		Name:       name,
+		Account:    account,
+		Source:     source,
+		Cursors:    cursors,
+		Handler:    handler,
+		RetryDelay: 5 * time.Second,
+	}
+}
+
+func (o *Observer) Run(ctx context.Context) error {
+	if o.Account == "" {
+		return fmt.Errorf("observer account is required")
+	}
+	if o.Source == nil || o.Cursors == nil || o.Handler == nil {
+		return fmt.Errorf("observer source, cursor store, and handler are required")
+	}
+
+	for {
+		cursor, err := o.Cursors.LoadCursor(o.Name)
+		if err != nil {
+			return fmt.Errorf("load cursor: %w", err)
+		}
+
+		err = o.Source.StreamPayments(ctx, o.Account, cursor, func(p Payment) error {
+			return o.process(ctx, p)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("observer %s: stream interrupted: %v", o.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(o.RetryDelay):
+		}
+	}
+}
+
+func (o *Observer) process(ctx context.Context, p Payment) error {
+	if p.To == o.Account {
+		if err := o.Handler.HandlePayment(ctx, p); err != nil {
+			return fmt.Errorf("handle payment %s: %w", p.ID, err)
+		}
+	}
+	if p.Cursor == "" {
+		return nil
+	}
+	if err := o.Cursors.SaveCursor(o.Name, p.Cursor); err != nil {
+		return fmt.Errorf("save cursor: %w", err)
+	}
+	return nil
+}
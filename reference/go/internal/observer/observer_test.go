@@ -0,0 +1,103 @@
+package observer
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const distributionAccount = "GDISTRIBUTIONAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+type recordingHandler struct {
+	mu       sync.Mutex
+	payments []Payment
+	seen     chan Payment
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{seen: make(chan Payment, 16)}
+}
+
+func (h *recordingHandler) HandlePayment(ctx context.Context, p Payment) error {
+	h.mu.Lock()
+	h.payments = append(h.payments, p)
+	h.mu.Unlock()
+	h.seen <- p
+	return nil
+}
+
+func TestObserverDeliversPaymentsAndCheckpointsCursor(t *testing.T) {
+	source := NewMemorySource()
+	cursors := NewMemoryCursorStore()
+	handler := newRecordingHandler()
+
+	source.Publish(Payment{To: distributionAccount, AssetCode: "USDC", Amount: "10", Memo: "1", MemoType: "id"})
+	source.Publish(Payment{From: distributionAccount, To: "GOTHERACCOUNT", AssetCode: "USDC", Amount: "5"})
+	third := source.Publish(Payment{To: distributionAccount, AssetCode: "USDC", Amount: "20", Memo: "2", MemoType: "id"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runObserver(ctx, New("test", distributionAccount, source, cursors, handler))
+
+	waitForPayment(t, handler.seen, "1")
+	waitForPayment(t, handler.seen, "2")
+	cancel()
+	<-done
+
+	cursor, _ := cursors.LoadCursor("test")
+	if cursor != third.Cursor {
+		t.Fatalf("expected cursor %s, got %s", third.Cursor, cursor)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	done = runObserver(ctx, New("test", distributionAccount, source, cursors, handler))
+	source.Publish(Payment{To: distributionAccount, AssetCode: "USDC", Amount: "30", Memo: "3", MemoType: "id"})
+	waitForPayment(t, handler.seen, "3")
+	cancel()
+	<-done
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.payments) != 3 {
+		t.Fatalf("expected 3 payments after resume, got %d", len(handler.payments))
+	}
+}
+
+func TestFileCursorStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursors.json")
+	store := NewFileCursorStore(path)
+	if cursor, err := store.LoadCursor("sep24"); err != nil || cursor != "" {
+		t.Fatalf("expected empty cursor, got %q err=%v", cursor, err)
+	}
+	if err := store.SaveCursor("sep24", "12345"); err != nil {
+		t.Fatalf("save cursor: %v", err)
+	}
+	cursor, err := NewFileCursorStore(path).LoadCursor("sep24")
+	if err != nil || cursor != "12345" {
+		t.Fatalf("expected persisted cursor, got %q err=%v", cursor, err)
+	}
+}
+
+func runObserver(ctx context.Context, o *Observer) chan struct{} {
+	o.RetryDelay = 10 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = o.Run(ctx)
+	}()
+	return done
+}
+
+func waitForPayment(t *testing.T, seen chan Payment, memo string) {
+	t.Helper()
+	select {
+	case p := <-seen:
+		if p.Memo != memo {
+			t.Fatalf("expected payment with memo %s, got %s", memo, p.Memo)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for payment with memo %s", memo)
+	}
+}
@@ -0,0 +1,135 @@
+package observer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+type HorizonSource struct {
+	Client *horizonclient.Client
+}
+
+func NewHorizonSource(horizonURL string) *HorizonSource {
+	return &HorizonSource{Client: &horizonclient.Client{HorizonURL: horizonURL}}
+}
+
+func (s *HorizonSource) StreamPayments(ctx context.Context, account, cursor string, handler func(Payment) error) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var handlerErr error
+	err := s.Client.StreamPayments(streamCtx, horizonclient.OperationRequest{
+		ForAccount: account,
+		Cursor:     cursor,
+		Join:       "transactions",
+	}, func(op operations.Operation) {
+		if handlerErr != nil {
+			return
+		}
+		p, ok := paymentFromOperation(op)
+		if !ok {
+			p = Payment{ID: op.GetID(), Cursor: op.PagingToken()}
+		}
+		if err := handler(p); err != nil {
+			handlerErr = err
+			cancel()
+		}
+	})
+	if handlerErr != nil {
+		return handlerErr
+	}
+	return err
+}
+
+func paymentFromOperation(op operations.Operation) (Payment, bool) {
+	var payment operations.Payment
+	switch v := op.(type) {
+	case operations.Payment:
+		payment = v
+	case operations.PathPayment:
+		payment = v.Payment
+	case operations.PathPaymentStrictSend:
+		payment = v.Payment
+	default:
+		return Payment{}, false
+	}
+	if !payment.TransactionSuccessful {
+		return Payment{}, false
+	}
+
+	p := Payment{
+		ID:              payment.ID,
+		Cursor:          payment.PT,
+		TransactionHash: payment.TransactionHash,
+		From:            payment.From,
+		To:              payment.To,
+		AssetCode:       payment.Code,
+		AssetIssuer:     payment.Issuer,
+		Amount:          payment.Amount,
+	}
+	if payment.Asset.Type == "native" {
+		p.AssetCode = "native"
+	}
+	if payment.Transaction != nil && payment.Transaction.MemoType != "none" {
+		p.Memo = payment.Transaction.Memo
+		p.MemoType = payment.Transaction.MemoType
+	}
+	return p, true
+}
+
+type MemorySource struct {
+	mu       sync.Mutex
+	payments []Payment
+	notify   chan struct{}
+}
+
+func NewMemorySource() *MemorySource {
+	return &MemorySource{notify: make(chan struct{})}
+}
+
+func (s *MemorySource) Publish(p Payment) Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := strconv.Itoa(len(s.payments) + 1)
+	if p.ID == "" {
+		p.ID = seq
+	}
+	p.Cursor = seq
+	s.payments = append(s.payments, p)
+	close(s.notify)
+	s.notify = make(chan struct{})
+	return p
+}
+
+func (s *MemorySource) StreamPayments(ctx context.Context, account, cursor string, handler func(Payment) error) error {
+	next, _ := strconv.Atoi(cursor)
+	for {
+		s.mu.Lock()
+		if next > len(s.payments) {
+			next = len(s.payments)
+		}
+		pending := append([]Payment(nil), s.payments[next:]...)
+		wait := s.notify
+		s.mu.Unlock()
+
+		for _, p := range pending {
+			next++
+			if p.To != account && p.From != account {
+				continue
+			}
+			if err := handler(p); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package submitter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+)
+
+type HorizonLedger struct {
+	Client *horizonclient.Client
+}
+
+func NewHorizonLedger(horizonURL string) *HorizonLedger {
+	return &HorizonLedger{Client: &horizonclient.Client{HorizonURL: horizonURL}}
+}
+
+func (l *HorizonLedger) Account(ctx context.Context, accountID string) (Account, error) {
+	detail, err := l.Client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+	if err != nil {
+		if horizonclient.IsNotFoundError(err) {
+			return Account{ID: accountID}, nil
+		}
+		return Account{}, err
+	}
+	sequence, err := detail.GetSequenceNumber()
+	if err != nil {
+		return Account{}, err
+	}
+	account := Account{ID: accountID, Sequence: sequence, Exists: true, Trustlines: map[string]bool{}}
+	for _, balance := range detail.Balances {
+		if balance.Code == "" {
+			continue
+		}
+		if balance.IsAuthorized != nil && !*balance.IsAuthorized {
+			continue
+		}
+		account.Trustlines[TrustlineKey(balance.Code, balance.Issuer)] = true
+	}
+	return account, nil
+}
+
+func (l *HorizonLedger) Submit(ctx context.Context, envelopeXDR string) (string, error) {
+	tx, err := l.Client.SubmitTransactionXDR(envelopeXDR)
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash, nil
+}
+
+func (l *HorizonLedger) Status(ctx context.Context, hash string) (string, error) {
+	tx, err := l.Client.TransactionDetail(hash)
+	if err != nil {
+		if horizonclient.IsNotFoundError(err) {
+			return StatusPending, nil
+		}
+		return "", err
+	}
+	if tx.Successful {
+		return StatusSuccess, nil
+	}
+	return StatusFailed, nil
+}
+
+type MemoryLedger struct {
+	NetworkPassphrase string
+
+	mu        sync.Mutex
+	accounts  map[string]Account
+	submitted map[string]string
+	statuses  map[string]string
+}
+
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		accounts:          map[string]Account{},
+		submitted:         map[string]string{},
+		statuses:          map[string]string{},
+	}
+}
+
+func (l *MemoryLedger) SetAccount(account Account) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	account.Exists = true
+	if account.Trustlines == nil {
+		account.Trustlines = map[string]bool{}
+	}
+	l.accounts[account.ID] = account
+}
+
+func (l *MemoryLedger) SetStatus(hash, status string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statuses[hash] = status
+}
+
+func (l *MemoryLedger) Envelope(hash string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	envelope, ok := l.submitted[hash]
+	return envelope, ok
+}
+
+func (l *MemoryLedger) Account(ctx context.Context, accountID string) (Account, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	account, ok := l.accounts[accountID]
+	if !ok {
+		return Account{ID: accountID}, nil
+	}
+	return account, nil
+}
+
+func (l *MemoryLedger) Submit(ctx context.Context, envelopeXDR string) (string, error) {
+	parsed, err := txnbuild.TransactionFromXDR(envelopeXDR)
+	if err != nil {
+		return "", fmt.Errorf("parse envelope: %w", err)
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		return "", fmt.Errorf("fee bump transactions are not supported")
+	}
+
+	hash, err := tx.HashHex(l.NetworkPassphrase)
+	if err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.submitted[hash]; ok {
+		return hash, nil
+	}
+	source := tx.SourceAccount()
+	account, ok := l.accounts[source.AccountID]
+	if !ok {
+		return "", fmt.Errorf("source account %s does not exist", source.AccountID)
+	}
+	if source.Sequence != account.Sequence+1 {
+		return "", fmt.Errorf("bad sequence: expected %d, got %d", account.Sequence+1, source.Sequence)
+	}
+	account.Sequence = source.Sequence
+	l.accounts[source.AccountID] = account
+
+	l.submitted[hash] = envelopeXDR
+	l.statuses[hash] = StatusPending
+	return hash, nil
+}
+
+func (l *MemoryLedger) Status(ctx context.Context, hash string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	status, ok := l.statuses[hash]
+	if !ok {
+		return StatusPending, nil
+	}
+	return status, nil
+}
@@ -0,0 +1,227 @@
+package submitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+var ErrNoTrustline = errors.New("destination has no trustline for asset")
+
+type Account struct {
+	ID         string
+	Sequence   int64
+	Exists     bool
+	Trustlines map[string]bool
+}
+
+type Ledger interface {
+	Account(ctx context.Context, accountID string) (Account, error)
+	Submit(ctx context.Context, envelopeXDR string) (string, error)
+	Status(ctx context.Context, hash string) (string, error)
+}
+
+type PaymentRequest struct {
+	Destination               string
+	AssetCode                 string
+	AssetIssuer               string
+	Amount                    string
+	ClaimableBalanceSupported bool
+}
+
+type Receipt struct {
+	Hash               string
+	ClaimableBalanceID string
+}
+
+// sequenceGrace allows for Horizon ingesting a ledger after it closed.
+const sequenceGrace = time.Minute
+
+type Submitter struct {
+	Ledger            Ledger
+	SigningKey        string
+	NetworkPassphrase string
+	BaseFee           int64
+	TimeoutSeconds    int64
+
+	// sequence is the last sequence number Prepare used.
+	mu            sync.Mutex
+	sequence      int64
+	reservedUntil time.Time
+}
+
+func New(ledger Ledger, signingKey, networkPassphrase string) *Submitter {
+	return &Submitter{
+		Ledger:            ledger,
+		SigningKey:        signingKey,
+		NetworkPassphrase: networkPassphrase,
+		BaseFee:           txnbuild.MinBaseFee,
+		TimeoutSeconds:    300,
+	}
+}
+
+func TrustlineKey(code, issuer string) string {
+	return code + ":" + issuer
+}
+
+// Payment is a signed payment that has not been broadcast yet.
+type Payment struct {
+	Envelope           string
+	Hash               string
+	ClaimableBalanceID string
+	Sequence           int64
+	ValidUntil         time.Time
+}
+
+// Pay builds, signs and submits a payment.
+func (s *Submitter) Pay(ctx context.Context, req PaymentRequest) (Receipt, error) {
+	payment, err := s.Prepare(ctx, req)
+	if err != nil {
+		return Receipt{}, err
+	}
+	if err := s.Submit(ctx, payment.Envelope); err != nil {
+		return Receipt{}, err
+	}
+	return Receipt{Hash: payment.Hash, ClaimableBalanceID: payment.ClaimableBalanceID}, nil
+}
+
+// Prepare builds and signs a payment without submitting it.
+func (s *Submitter) Prepare(ctx context.Context, req PaymentRequest) (Payment, error) {
+	if req.Destination == "" || req.AssetCode == "" || req.Amount == "" {
+		return Payment{}, fmt.Errorf("destination, asset code, and amount are required")
+	}
+	kp, err := keypair.ParseFull(s.SigningKey)
+	if err != nil {
+		return Payment{}, fmt.Errorf("parse distribution signing key: %w", err)
+	}
+
+	asset, err := buildAsset(req.AssetCode, req.AssetIssuer)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	destination, err := s.Ledger.Account(ctx, req.Destination)
+	if err != nil {
+		return Payment{}, fmt.Errorf("load destination account: %w", err)
+	}
+
+	var op txnbuild.Operation = &txnbuild.Payment{
+		Destination: req.Destination,
+		Amount:      req.Amount,
+		Asset:       asset,
+	}
+	claimable := false
+	if !canReceive(destination, req) {
+		if !req.ClaimableBalanceSupported {
+			return Payment{}, ErrNoTrustline
+		}
+		op = &txnbuild.CreateClaimableBalance{
+			Amount:       req.Amount,
+			Asset:        asset,
+			Destinations: []txnbuild.Claimant{txnbuild.NewClaimant(req.Destination, nil)},
+		}
+		claimable = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	source, err := s.Ledger.Account(ctx, kp.Address())
+	if err != nil {
+		return Payment{}, fmt.Errorf("load distribution account: %w", err)
+	}
+	if !source.Exists {
+		return Payment{}, fmt.Errorf("distribution account %s does not exist", kp.Address())
+	}
+	sequence := source.Sequence
+	if s.sequence > sequence && time.Now().Before(s.reservedUntil.Add(sequenceGrace)) {
+		sequence = s.sequence
+	}
+
+	timeBounds := txnbuild.NewTimeout(s.TimeoutSeconds)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: kp.Address(), Sequence: sequence},
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{op},
+		BaseFee:              s.BaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: timeBounds},
+	})
+	if err != nil {
+		return Payment{}, fmt.Errorf("build payment tx: %w", err)
+	}
+	tx, err = tx.Sign(s.NetworkPassphrase, kp)
+	if err != nil {
+		return Payment{}, fmt.Errorf("sign payment tx: %w", err)
+	}
+
+	payment := Payment{Sequence: sequence + 1, ValidUntil: time.Unix(timeBounds.MaxTime, 0).UTC()}
+	if claimable {
+		payment.ClaimableBalanceID, err = tx.ClaimableBalanceID(0)
+		if err != nil {
+			return Payment{}, fmt.Errorf("derive claimable balance id: %w", err)
+		}
+	}
+	payment.Hash, err = tx.HashHex(s.NetworkPassphrase)
+	if err != nil {
+		return Payment{}, fmt.Errorf("hash payment tx: %w", err)
+	}
+	payment.Envelope, err = tx.Base64()
+	if err != nil {
+		return Payment{}, fmt.Errorf("encode payment tx: %w", err)
+	}
+	s.sequence, s.reservedUntil = payment.Sequence, payment.ValidUntil
+	return payment, nil
+}
+
+// Release gives back the sequence number of the last prepared payment when
+// it will never be submitted.
+func (s *Submitter) Release(payment Payment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if payment.Sequence == s.sequence {
+		s.sequence--
+	}
+}
+
+// Submit broadcasts a prepared envelope. Submitting the same envelope again
+// cannot pay twice: its sequence number is consumed by the first success.
+func (s *Submitter) Submit(ctx context.Context, envelope string) error {
+	if _, err := s.Ledger.Submit(ctx, envelope); err != nil {
+		return fmt.Errorf("submit payment tx: %w", err)
+	}
+	return nil
+}
+
+func (s *Submitter) Status(ctx context.Context, hash string) (string, error) {
+	return s.Ledger.Status(ctx, hash)
+}
+
+func buildAsset(code, issuer string) (txnbuild.Asset, error) {
+	if code == "native" || (code == "XLM" && issuer == "") {
+		return txnbuild.NativeAsset{}, nil
+	}
+	if issuer == "" {
+		return nil, fmt.Errorf("asset %s has no issuer configured", code)
+	}
+	return txnbuild.CreditAsset{Code: code, Issuer: issuer}, nil
+}
+
+func canReceive(destination Account, req PaymentRequest) bool {
+	if !destination.Exists {
+		return false
+	}
+	if req.AssetIssuer == "" || req.AssetIssuer == destination.ID {
+		return true
+	}
+	return destination.Trustlines[TrustlineKey(req.AssetCode, req.AssetIssuer)]
+}
@@ -0,0 +1,127 @@
+package submitter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+)
+
+func TestPayRequiresTrustline(t *testing.T) {
+	ledger, sub, issuer, user := testLedger(t)
+
+	_, err := sub.Pay(context.Background(), PaymentRequest{Destination: user, AssetCode: "USDC", AssetIssuer: issuer, Amount: "10"})
+	if !errors.Is(err, ErrNoTrustline) {
+		t.Fatalf("expected ErrNoTrustline, got %v", err)
+	}
+
+	ledger.SetAccount(Account{ID: user, Trustlines: map[string]bool{TrustlineKey("USDC", issuer): true}})
+	receipt, err := sub.Pay(context.Background(), PaymentRequest{Destination: user, AssetCode: "USDC", AssetIssuer: issuer, Amount: "10"})
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if receipt.ClaimableBalanceID != "" {
+		t.Fatalf("expected direct payment, got claimable balance %s", receipt.ClaimableBalanceID)
+	}
+	op := submittedOperation(t, ledger, receipt.Hash)
+	payment, ok := op.(*txnbuild.Payment)
+	if !ok || payment.Destination != user || payment.Amount != "10.0000000" {
+		t.Fatalf("unexpected payment operation: %#v", op)
+	}
+
+	status, err := sub.Status(context.Background(), receipt.Hash)
+	if err != nil || status != StatusPending {
+		t.Fatalf("expected pending status, got %s err=%v", status, err)
+	}
+}
+
+func TestPayFallsBackToClaimableBalance(t *testing.T) {
+	ledger, sub, issuer, user := testLedger(t)
+
+	receipt, err := sub.Pay(context.Background(), PaymentRequest{
+		Destination:               user,
+		AssetCode:                 "USDC",
+		AssetIssuer:               issuer,
+		Amount:                    "10",
+		ClaimableBalanceSupported: true,
+	})
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if receipt.ClaimableBalanceID == "" {
+		t.Fatalf("expected claimable balance id")
+	}
+	if _, ok := submittedOperation(t, ledger, receipt.Hash).(*txnbuild.CreateClaimableBalance); !ok {
+		t.Fatalf("expected create claimable balance operation")
+	}
+}
+
+func TestPrepareNeverReusesASequence(t *testing.T) {
+	ledger, sub, issuer, user := testLedger(t)
+	ledger.SetAccount(Account{ID: user, Trustlines: map[string]bool{TrustlineKey("USDC", issuer): true}})
+	ctx := context.Background()
+	req := PaymentRequest{Destination: user, AssetCode: "USDC", AssetIssuer: issuer, Amount: "10"}
+	prepare := func() Payment {
+		t.Helper()
+		payment, err := sub.Prepare(ctx, req)
+		if err != nil {
+			t.Fatalf("prepare: %v", err)
+		}
+		return payment
+	}
+
+	first, second := prepare(), prepare()
+	if first.Sequence != 101 || second.Sequence != 102 {
+		t.Fatalf("expected consecutive sequences before the first payment lands, got %d and %d", first.Sequence, second.Sequence)
+	}
+	if err := sub.Submit(ctx, second.Envelope); err == nil {
+		t.Fatal("expected the second payment to wait for the first")
+	}
+	for _, payment := range []Payment{first, second} {
+		if err := sub.Submit(ctx, payment.Envelope); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	released := prepare()
+	sub.Release(released)
+	if next := prepare(); next.Sequence != released.Sequence {
+		t.Fatalf("expected a released sequence to be reused, got %d after %d", next.Sequence, released.Sequence)
+	}
+	sub.reservedUntil = time.Now().Add(-2 * sequenceGrace)
+	if next := prepare(); next.Sequence != 103 {
+		t.Fatalf("expected the ledger sequence once prepared payments expired, got %d", next.Sequence)
+	}
+}
+
+func testLedger(t *testing.T) (*MemoryLedger, *Submitter, string, string) {
+	t.Helper()
+	distribution := keypair.MustRandom()
+	issuer := keypair.MustRandom().Address()
+	user := keypair.MustRandom().Address()
+
+	ledger := NewMemoryLedger()
+	ledger.SetAccount(Account{ID: distribution.Address(), Sequence: 100})
+	return ledger, New(ledger, distribution.Seed(), network.TestNetworkPassphrase), issuer, user
+}
+
+func submittedOperation(t *testing.T, ledger *MemoryLedger, hash string) txnbuild.Operation {
+	t.Helper()
+	envelope, ok := ledger.Envelope(hash)
+	if !ok {
+		t.Fatalf("expected submitted envelope for %s", hash)
+	}
+	parsed, err := txnbuild.TransactionFromXDR(envelope)
+	if err != nil {
+		t.Fatalf("parse envelope: %v", err)
+	}
+	tx, _ := parsed.Transaction()
+	if len(tx.Operations()) != 1 {
+		t.Fatalf("expected a single operation, got %d", len(tx.Operations()))
+	}
+	return tx.Operations()[0]
+}
@@ -0,0 +1,66 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+var (
+	ErrAmountTooSmall = errors.New("amount is below the minimum")
+	ErrAmountTooLarge = errors.New("amount exceeds the maximum")
+)
+
+func FormatAmount(asset config.Asset, value decimal.Decimal, mode decimal.RoundingMode) string {
+	return value.StringFixed(asset.SignificantDecimals, mode)
+}
+
+// CheckLimits validates amount against the asset's per-direction limits. A
+// zero amount is not checked.
+func CheckLimits(asset config.Asset, kind string, amount decimal.Decimal, mode decimal.RoundingMode) error {
+	if amount.IsZero() {
+		return nil
+	}
+	limits := asset.Operation(kind)
+	if !limits.MinAmount.IsZero() && amount.LessThan(limits.MinAmount) {
+		return fmt.Errorf("%w of %s", ErrAmountTooSmall, FormatAmount(asset, limits.MinAmount, mode))
+	}
+	if !limits.MaxAmount.IsZero() && amount.GreaterThan(limits.MaxAmount) {
+		return fmt.Errorf("%w of %s", ErrAmountTooLarge, FormatAmount(asset, limits.MaxAmount, mode))
+	}
+	return nil
+}
+
+// LimitStatus maps a CheckLimits error to the too_small or too_large status.
+func LimitStatus(err error) string {
+	switch {
+	case errors.Is(err, ErrAmountTooSmall):
+		return StatusTooSmall
+	case errors.Is(err, ErrAmountTooLarge):
+		return StatusTooLarge
+	}
+	return ""
+}
+
+// ParseAmount parses an amount of asset. An empty amount is zero; amounts
+// with more decimal places than the asset's significant_decimals are
+// rejected, since they could not be paid exactly.
+func ParseAmount(asset config.Asset, raw string) (decimal.Decimal, error) {
+	if strings.TrimSpace(raw) == "" {
+		return decimal.Zero, nil
+	}
+	amount, err := decimal.Parse(raw)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if amount.Sign() <= 0 {
+		return decimal.Zero, fmt.Errorf("amount must be positive")
+	}
+	if !amount.Round(asset.SignificantDecimals, decimal.RoundDown).Equal(amount) {
+		return decimal.Zero, fmt.Errorf("amount has more than %d decimal places", asset.SignificantDecimals)
+	}
+	return amount, nil
+}
@@ -0,0 +1,57 @@
+package transfer
+
+import (
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+)
+
+// FindAsset resolves an enabled Stellar asset. Without an issuer the code
+// must be unambiguous across the configured assets.
+func FindAsset(assets []config.Asset, code, issuer string) (config.Asset, bool) {
+	var found config.Asset
+	matches := 0
+	for _, a := range assets {
+		if a.Enabled && a.ID().IsStellar() && a.Matches(code, issuer) {
+			found = a
+			matches++
+		}
+	}
+	return found, matches == 1
+}
+
+// OperationAsset is FindAsset restricted to assets enabled for kind.
+func OperationAsset(assets []config.Asset, code, issuer, kind string) (config.Asset, bool) {
+	asset, ok := FindAsset(assets, code, issuer)
+	if !ok || !asset.OperationEnabled(kind) {
+		return config.Asset{}, false
+	}
+	return asset, true
+}
+
+// TxAsset returns the configured Stellar asset of tx, falling back to default
+// precision when the asset is no longer configured.
+func TxAsset(assets []config.Asset, tx db.Transaction) config.Asset {
+	for _, a := range assets {
+		if a.ID().IsStellar() && a.Code == tx.AssetCode && a.Issuer == tx.AssetIssuer {
+			return a
+		}
+	}
+	return config.Asset{Code: tx.AssetCode, Issuer: tx.AssetIssuer, SignificantDecimals: config.DefaultSignificantDecimals}
+}
+
+// AssetForID looks up any configured asset by its SEP-38 identity.
+func AssetForID(assets []config.Asset, raw string, fallback config.Asset) config.Asset {
+	if raw == "" {
+		return fallback
+	}
+	id, err := config.ParseAssetID(raw)
+	if err != nil {
+		return fallback
+	}
+	for _, asset := range assets {
+		if asset.ID() == id {
+			return asset
+		}
+	}
+	return config.Asset{Scheme: id.Scheme, Code: id.Code, Issuer: id.Issuer, SignificantDecimals: config.DefaultSignificantDecimals}
+}
@@ -0,0 +1,45 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+)
+
+// ErrFeeExceedsAmount rejects a fee that leaves nothing to deliver.
+var ErrFeeExceedsAmount = errors.New("fee exceeds the amount")
+
+// ApplyFee prices tx.Amount with req and stores the fee and the net
+// amount_out on tx.
+func ApplyFee(calc fees.Calculator, tx *db.Transaction, req fees.Request) error {
+	if tx.Amount.IsZero() {
+		return nil
+	}
+	req.Amount = tx.Amount
+	result, err := calc.Calculate(req)
+	if err != nil {
+		return err
+	}
+	if result.Total.Cmp(tx.Amount) >= 0 {
+		return fmt.Errorf("%w: fee %s is not less than amount %s", ErrFeeExceedsAmount, result.Total, tx.Amount)
+	}
+
+	details := make([]db.FeeDetail, 0, len(result.Details))
+	for _, detail := range result.Details {
+		details = append(details, db.FeeDetail{Name: detail.Name, Description: detail.Description, Amount: detail.Amount})
+	}
+	tx.AmountFee = result.Total
+	tx.FeeDetails = &db.FeeDetails{Total: result.Total, Asset: result.Asset, Details: details}
+	tx.AmountOut = tx.Amount.Sub(result.Total)
+	return nil
+}
+
+// FeeErrorMessage is the client-facing text for an ApplyFee error.
+func FeeErrorMessage(err error) string {
+	if errors.Is(err, ErrFeeExceedsAmount) {
+		return err.Error()
+	}
+	return "fee is not available for this request"
+}
@@ -0,0 +1,33 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+)
+
+var completedJSON, _ = json.Marshal(StatusCompleted)
+
+// CompletedAt returns when tx entered completed, read from its history, or
+// the zero time if it has not completed.
+func CompletedAt(store db.TransactionStore, tx db.Transaction) time.Time {
+	if tx.Status != StatusCompleted {
+		return time.Time{}
+	}
+	history, err := store.History(tx.ID)
+	if err != nil {
+		log.Printf("transfer: history of %s: %v", tx.ID, err)
+		return tx.UpdatedAt
+	}
+	for _, entry := range history {
+		for _, change := range entry.Changes {
+			if change.Field == "status" && bytes.Equal(change.After, completedJSON) {
+				return entry.At
+			}
+		}
+	}
+	return tx.UpdatedAt
+}
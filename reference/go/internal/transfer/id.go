@@ -0,0 +1,57 @@
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
+)
+
+// idempotencyNamespace scopes the name-based UUIDs of IdempotentID.
+var idempotencyNamespace = uuid.MustParse("5f0c7a52-3b8e-4f7d-9c1e-6a2d8b4e0f13")
+
+// NewUUID returns a random (version 4) UUID. Services use it as their
+// default id generator.
+func NewUUID() string {
+	return uuid.NewString()
+}
+
+// IdempotentID derives the id of the transaction created by an operation
+// carrying an Idempotency-Key, so every retry of the request names the same
+// transaction. Keys are scoped to the account and operation.
+func IdempotentID(account, operation, key string) string {
+	return uuid.NewSHA1(idempotencyNamespace, []byte(account+"|"+operation+"|"+key)).String()
+}
+
+// NewMemoAllocator returns an allocator that skips memos already stored.
+func NewMemoAllocator(cfg config.Config, txStore db.TransactionStore) *memo.MemoryAllocator {
+	allocator := memo.NewMemoryAllocator(cfg.WithdrawMemoType)
+	allocator.InUse = func(m memo.Memo) bool {
+		_, ok := txStore.GetByMemo(m.Value, m.Type)
+		return ok
+	}
+	return allocator
+}
+
+// AssignWithdrawMemo allocates the memo the user must attach to the Stellar
+// payment that funds a withdrawal.
+func AssignWithdrawMemo(memos memo.Allocator, cfg config.Config, tx *db.Transaction) error {
+	if memos == nil {
+		return fmt.Errorf("memo allocator is not configured")
+	}
+	m, err := memos.Allocate(tx.ID)
+	if err != nil {
+		return err
+	}
+	account := cfg.DistributionAccount
+	if account == "" {
+		account = cfg.ServerAccount
+	}
+	tx.WithdrawAnchorAccount = account
+	tx.WithdrawMemo = m.Value
+	tx.WithdrawMemoType = m.Type
+	return nil
+}
@@ -0,0 +1,4 @@
+package transfer
+
+// MaxListLimit caps the page a GET /transactions request can ask for.
+const MaxListLimit = 200
@@ -0,0 +1,72 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+)
+
+var (
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrConflictingIdentifiers = errors.New("conflicting transaction identifiers")
+)
+
+// Lookup names a transaction by any of the identifiers a GET /transaction
+// request may carry; every identifier given must name the same transaction.
+type Lookup struct {
+	ID                    string
+	ExternalTransactionID string
+	StellarTransactionID  string
+}
+
+// FindTransaction resolves lookup through the store's indexes and returns
+// the transaction only if it belongs to account and protocol.
+func FindTransaction(store db.TransactionStore, account, protocol string, lookup Lookup) (db.Transaction, error) {
+	var found db.Transaction
+	resolve := func(tx db.Transaction, ok bool) error {
+		if !ok || tx.Account != account || tx.Protocol != protocol {
+			return ErrTransactionNotFound
+		}
+		if found.ID != "" && found.ID != tx.ID {
+			return fmt.Errorf("%w: they match transactions %s and %s", ErrConflictingIdentifiers, found.ID, tx.ID)
+		}
+		found = tx
+		return nil
+	}
+	resolveAny := func(txs []db.Transaction) error {
+		var match db.Transaction
+		ok := false
+		for _, tx := range txs {
+			if tx.Account != account || tx.Protocol != protocol {
+				continue
+			}
+			if tx.ID == found.ID {
+				return nil
+			}
+			if !ok {
+				match, ok = tx, true
+			}
+		}
+		return resolve(match, ok)
+	}
+	if lookup.ID != "" {
+		if err := resolve(store.GetByID(lookup.ID)); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+	if lookup.ExternalTransactionID != "" {
+		if err := resolveAny(store.ListByExternalID(lookup.ExternalTransactionID)); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+	if lookup.StellarTransactionID != "" {
+		if err := resolveAny(store.ListByStellarTxID(lookup.StellarTransactionID)); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+	if found.ID == "" {
+		return db.Transaction{}, ErrTransactionNotFound
+	}
+	return found, nil
+}
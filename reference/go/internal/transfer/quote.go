@@ -0,0 +1,109 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+var ErrInvalidQuote = errors.New("invalid quote")
+
+// QuoteRequest is what a transfer request claims about the SEP-38 quote it
+// references.
+type QuoteRequest struct {
+	ID            string
+	Context       string
+	Asset         config.Asset
+	OffchainAsset string
+	Now           time.Time
+}
+
+// ApplyQuote checks that the quote belongs to tx.Account, is unused and
+// unexpired, and matches the request, then copies its amounts onto tx.
+func ApplyQuote(quotes db.QuoteStore, tx *db.Transaction, req QuoteRequest, mode decimal.RoundingMode) error {
+	if quotes == nil {
+		return fmt.Errorf("%w: quotes are not supported", ErrInvalidQuote)
+	}
+	quote, ok := quotes.GetByID(req.ID)
+	if !ok || quote.Account != tx.Account {
+		return fmt.Errorf("%w: quote not found", ErrInvalidQuote)
+	}
+	if quote.TransactionID != "" {
+		return fmt.Errorf("%w: quote has already been used", ErrInvalidQuote)
+	}
+	if !req.Now.Before(quote.ExpiresAt) {
+		return fmt.Errorf("%w: quote has expired", ErrInvalidQuote)
+	}
+	if quote.Context != req.Context {
+		return fmt.Errorf("%w: quote was not requested for %s", ErrInvalidQuote, req.Context)
+	}
+
+	stellarAsset, offchainAsset := quote.BuyAsset, quote.SellAsset
+	stellarAmount := quote.BuyAmount
+	// Withdrawals and SEP-31 receives sell the Stellar asset.
+	if tx.Kind == "withdraw" || tx.Kind == "receive" {
+		stellarAsset, offchainAsset = quote.SellAsset, quote.BuyAsset
+		stellarAmount = quote.SellAmount
+	}
+	if stellarAsset != req.Asset.ID().String() {
+		return fmt.Errorf("%w: quote does not match asset %s", ErrInvalidQuote, req.Asset.ID())
+	}
+	if req.OffchainAsset != "" && req.OffchainAsset != offchainAsset {
+		return fmt.Errorf("%w: quote does not match %s", ErrInvalidQuote, req.OffchainAsset)
+	}
+	if !tx.Amount.IsZero() && !tx.Amount.Equal(quote.SellAmount) {
+		return fmt.Errorf("%w: amount does not match the quote sell_amount", ErrInvalidQuote)
+	}
+	if err := CheckLimits(req.Asset, tx.Kind, stellarAmount, mode); err != nil {
+		return err
+	}
+
+	fee := quote.Fee
+	tx.QuoteID = quote.ID
+	tx.Amount = quote.SellAmount
+	tx.AmountInAsset = quote.SellAsset
+	tx.AmountOut = quote.BuyAmount
+	tx.AmountOutAsset = quote.BuyAsset
+	tx.AmountFee = fee.Total
+	tx.FeeDetails = &fee
+	return nil
+}
+
+// CreateTransaction binds tx's quote, then creates tx.
+func CreateTransaction(store db.TransactionStore, quotes db.QuoteStore, tx db.Transaction, audit db.Audit) error {
+	if err := bindQuote(quotes, tx); err != nil {
+		return err
+	}
+	err := store.Create(tx, audit)
+	if err != nil {
+		if existing, ok := store.GetByID(tx.ID); !ok || existing.QuoteID != tx.QuoteID {
+			releaseQuote(quotes, tx)
+		}
+	}
+	return err
+}
+
+func bindQuote(quotes db.QuoteStore, tx db.Transaction) error {
+	if tx.QuoteID == "" || quotes == nil {
+		return nil
+	}
+	err := quotes.Bind(tx.QuoteID, tx.ID)
+	if errors.Is(err, db.ErrConflict) {
+		return fmt.Errorf("%w: quote has already been used", ErrInvalidQuote)
+	}
+	return err
+}
+
+func releaseQuote(quotes db.QuoteStore, tx db.Transaction) {
+	if tx.QuoteID == "" || quotes == nil {
+		return
+	}
+	if err := quotes.Unbind(tx.QuoteID, tx.ID); err != nil {
+		log.Printf("transfer: release quote %s: %v", tx.QuoteID, err)
+	}
+}
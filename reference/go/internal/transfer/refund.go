@@ -0,0 +1,70 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+var ErrInvalidRefund = errors.New("invalid refund")
+
+// ApplyRefund records payment in tx.Refunds and reports whether amount_in has
+// now been refunded in full, in which case the caller moves tx to refunded.
+// An empty IDType defaults to external for deposits and stellar otherwise.
+func ApplyRefund(tx *db.Transaction, payment db.RefundPayment, asset config.Asset, mode decimal.RoundingMode) (bool, error) {
+	if tx.Status == StatusRefunded || ValidateTransition(tx.Status, StatusRefunded) != nil {
+		return false, fmt.Errorf("transaction %s cannot be refunded in status %s", tx.ID, tx.Status)
+	}
+	if strings.TrimSpace(payment.ID) == "" {
+		return false, fmt.Errorf("%w: missing refund payment id", ErrInvalidRefund)
+	}
+	if payment.IDType == "" {
+		payment.IDType = "stellar"
+		if tx.Kind == "deposit" {
+			payment.IDType = "external"
+		}
+	}
+	if payment.IDType != "stellar" && payment.IDType != "external" {
+		return false, fmt.Errorf("%w: id_type must be stellar or external", ErrInvalidRefund)
+	}
+	if payment.Amount.Sign() <= 0 {
+		return false, fmt.Errorf("%w: invalid amount", ErrInvalidRefund)
+	}
+	if payment.Fee.Sign() < 0 {
+		return false, fmt.Errorf("%w: invalid fee", ErrInvalidRefund)
+	}
+
+	received := tx.AmountIn
+	if received.IsZero() {
+		received = tx.Amount
+	}
+	if received.Sign() <= 0 {
+		return false, fmt.Errorf("%w: transaction has no amount_in to refund", ErrInvalidRefund)
+	}
+
+	refunds := db.Refunds{}
+	if tx.Refunds != nil {
+		refunds = *tx.Refunds
+		refunds.Payments = append([]db.RefundPayment(nil), tx.Refunds.Payments...)
+	}
+	for _, existing := range refunds.Payments {
+		if existing.ID == payment.ID && existing.IDType == payment.IDType {
+			return false, fmt.Errorf("%w: refund payment %s already recorded", ErrInvalidRefund, payment.ID)
+		}
+	}
+
+	total := refunds.AmountRefunded.Add(payment.Amount).Add(payment.Fee)
+	if total.GreaterThan(received) {
+		return false, fmt.Errorf("%w: refund total %s exceeds amount_in %s", ErrInvalidRefund, FormatAmount(asset, total, mode), FormatAmount(asset, received, mode))
+	}
+
+	refunds.AmountRefunded = total
+	refunds.AmountFee = refunds.AmountFee.Add(payment.Fee)
+	refunds.Payments = append(refunds.Payments, payment)
+	tx.Refunds = &refunds
+	return total.Equal(received), nil
+}
@@ -0,0 +1,48 @@
+package transfer
+
+import (
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+// RenderFeeDetails formats fee_details for SEP-6 and SEP-24 transactions.
+func RenderFeeDetails(asset config.Asset, details db.FeeDetails, mode decimal.RoundingMode) map[string]any {
+	items := make([]map[string]string, 0, len(details.Details))
+	for _, detail := range details.Details {
+		item := map[string]string{
+			"name":   detail.Name,
+			"amount": FormatAmount(asset, detail.Amount, mode),
+		}
+		if detail.Description != "" {
+			item["description"] = detail.Description
+		}
+		items = append(items, item)
+	}
+	out := map[string]any{
+		"total": FormatAmount(asset, details.Total, mode),
+		"asset": details.Asset,
+	}
+	if len(items) > 0 {
+		out["details"] = items
+	}
+	return out
+}
+
+// RenderRefunds formats the refunds object for SEP-6 and SEP-24 transactions.
+func RenderRefunds(asset config.Asset, refunds db.Refunds, mode decimal.RoundingMode) map[string]any {
+	payments := make([]map[string]string, 0, len(refunds.Payments))
+	for _, payment := range refunds.Payments {
+		payments = append(payments, map[string]string{
+			"id":      payment.ID,
+			"id_type": payment.IDType,
+			"amount":  FormatAmount(asset, payment.Amount, mode),
+			"fee":     FormatAmount(asset, payment.Fee, mode),
+		})
+	}
+	return map[string]any{
+		"amount_refunded": FormatAmount(asset, refunds.AmountRefunded, mode),
+		"amount_fee":      FormatAmount(asset, refunds.AmountFee, mode),
+		"payments":        payments,
+	}
+}
@@ -0,0 +1,147 @@
+// Package transfer holds the transaction rules shared by the SEP-6, SEP-24
+// and SEP-31 services: the status state machine, amount limits, fees and
+// quotes.
+package transfer
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ProtocolSEP6  = "sep6"
+	ProtocolSEP24 = "sep24"
+	ProtocolSEP31 = "sep31"
+)
+
+const (
+	StatusIncomplete                = "incomplete"
+	StatusPendingUserTransferStart  = "pending_user_transfer_start"
+	StatusPendingCustomerInfoUpdate = "pending_customer_info_update"
+	StatusPendingAnchor             = "pending_anchor"
+	StatusPendingStellar            = "pending_stellar"
+	StatusPendingTrust              = "pending_trust"
+	StatusCompleted                 = "completed"
+	StatusRefunded                  = "refunded"
+	StatusError                     = "error"
+	StatusExpired                   = "expired"
+	StatusTooSmall                  = "too_small"
+	StatusTooLarge                  = "too_large"
+
+	// SEP-31 statuses.
+	StatusPendingSender                = "pending_sender"
+	StatusPendingReceiver              = "pending_receiver"
+	StatusPendingExternal              = "pending_external"
+	StatusPendingTransactionInfoUpdate = "pending_transaction_info_update"
+)
+
+var ErrInvalidTransition = errors.New("invalid transition")
+
+var allowedTransitions = map[string]map[string]bool{
+	StatusIncomplete: {
+		StatusPendingUserTransferStart: true,
+		StatusExpired:                  true,
+		StatusError:                    true,
+	},
+	StatusPendingUserTransferStart: {
+		StatusPendingAnchor:             true,
+		StatusPendingCustomerInfoUpdate: true,
+		StatusTooSmall:                  true,
+		StatusTooLarge:                  true,
+		StatusExpired:                   true,
+		StatusError:                     true,
+	},
+	StatusPendingCustomerInfoUpdate: {
+		StatusPendingUserTransferStart: true,
+		StatusPendingAnchor:            true,
+		StatusPendingReceiver:          true,
+		StatusExpired:                  true,
+		StatusError:                    true,
+	},
+	StatusPendingAnchor: {
+		StatusPendingStellar:            true,
+		StatusPendingExternal:           true,
+		StatusPendingTrust:              true,
+		StatusPendingCustomerInfoUpdate: true,
+		StatusTooSmall:                  true,
+		StatusTooLarge:                  true,
+		StatusRefunded:                  true,
+		StatusError:                     true,
+	},
+	StatusPendingTrust: {
+		StatusPendingStellar: true,
+		StatusError:          true,
+	},
+	StatusPendingStellar: {
+		StatusCompleted: true,
+		StatusError:     true,
+	},
+	StatusTooSmall: {
+		StatusRefunded: true,
+		StatusError:    true,
+	},
+	StatusTooLarge: {
+		StatusRefunded: true,
+		StatusError:    true,
+	},
+	StatusError: {
+		StatusRefunded: true,
+	},
+	StatusExpired: {
+		StatusError: true,
+	},
+	StatusPendingSender: {
+		StatusPendingReceiver: true,
+		StatusExpired:         true,
+		StatusError:           true,
+	},
+	StatusPendingReceiver: {
+		StatusPendingExternal:              true,
+		StatusPendingCustomerInfoUpdate:    true,
+		StatusPendingTransactionInfoUpdate: true,
+		StatusCompleted:                    true,
+		StatusRefunded:                     true,
+		StatusError:                        true,
+	},
+	StatusPendingTransactionInfoUpdate: {
+		StatusPendingReceiver: true,
+		StatusError:           true,
+	},
+	StatusPendingExternal: {
+		StatusCompleted: true,
+		StatusRefunded:  true,
+		StatusError:     true,
+	},
+}
+
+func ValidateTransition(from, to string) error {
+	if from == to {
+		return nil
+	}
+	if next, ok := allowedTransitions[from]; ok && next[to] {
+		return nil
+	}
+	return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+}
+
+// Statuses lists every status a transaction can be in.
+func Statuses() []string {
+	return []string{
+		StatusIncomplete,
+		StatusPendingUserTransferStart,
+		StatusPendingCustomerInfoUpdate,
+		StatusPendingTransactionInfoUpdate,
+		StatusPendingSender,
+		StatusPendingReceiver,
+		StatusPendingAnchor,
+		StatusPendingTrust,
+		StatusPendingStellar,
+		StatusPendingExternal,
+		StatusCompleted,
+		StatusRefunded,
+		StatusError,
+		StatusExpired,
+		StatusTooSmall,
+		StatusTooLarge,
+	}
+}
@@ -0,0 +1,388 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrInvalidParams       = errors.New("invalid params")
+	ErrActionNotAllowed    = errors.New("action not allowed")
+)
+
+// JSON-RPC 2.0 error codes; the -320xx codes are application errors.
+const (
+	codeParseError          = -32700
+	codeInvalidRequest      = -32600
+	codeMethodNotFound      = -32601
+	codeInvalidParams       = -32602
+	codeInternalError       = -32603
+	codeTransactionNotFound = -32001
+	codeActionNotAllowed    = -32002
+	codeConflict            = -32003
+)
+
+// conflictRetries is how many times Do reloads the transaction and re-runs
+// an action that lost an optimistic-concurrency race.
+const conflictRetries = 3
+
+// sideEffects lists actions that Do never re-runs after a conflict.
+var sideEffects = map[string]bool{
+	"do_stellar_payment": true,
+}
+
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ActionParams carries the parameters of every action; each action reads
+// the fields it needs. Amounts are in the asset recorded on the transaction.
+type ActionParams struct {
+	TransactionID         string          `json:"transaction_id"`
+	Message               string          `json:"message,omitempty"`
+	AmountIn              decimal.Decimal `json:"amount_in,omitzero"`
+	AmountOut             decimal.Decimal `json:"amount_out,omitzero"`
+	AmountFee             decimal.Decimal `json:"amount_fee,omitzero"`
+	ExternalTransactionID string          `json:"external_transaction_id,omitempty"`
+	Refund                *RefundParams   `json:"refund,omitempty"`
+
+	// audit attributes the action's writes in the transaction history.
+	audit db.Audit
+}
+
+type RefundParams struct {
+	ID        string          `json:"id"`
+	IDType    string          `json:"id_type,omitempty"`
+	Amount    decimal.Decimal `json:"amount"`
+	AmountFee decimal.Decimal `json:"amount_fee"`
+}
+
+type action func(s *Service, ctx context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error)
+
+var actions = map[string]action{
+	"request_offchain_funds":         (*Service).requestOffchainFunds,
+	"notify_offchain_funds_received": (*Service).notifyOffchainFundsReceived,
+	"do_stellar_payment":             (*Service).doStellarPayment,
+	"notify_offchain_funds_pending":  (*Service).notifyOffchainFundsPending,
+	"notify_offchain_funds_sent":     (*Service).notifyOffchainFundsSent,
+	"notify_refund_sent":             (*Service).notifyRefundSent,
+	"notify_transaction_error":       (*Service).notifyTransactionError,
+}
+
+// handleAction serves POST /platform/actions. JSON-RPC failures are
+// reported in the response body with HTTP 200.
+func (s *Service) handleAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	defer r.Body.Close()
+
+	var req RPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPCError(w, nil, codeParseError, "parse error")
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPCError(w, req.ID, codeInvalidRequest, "invalid request")
+		return
+	}
+	if _, ok := actions[req.Method]; !ok {
+		writeRPCError(w, req.ID, codeMethodNotFound, "method not found")
+		return
+	}
+
+	var params ActionParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+
+	tx, err := s.Do(r.Context(), req.Method, params)
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		writeRPCError(w, req.ID, codeTransactionNotFound, err.Error())
+	case errors.Is(err, ErrInvalidParams), errors.Is(err, transfer.ErrInvalidRefund):
+		writeRPCError(w, req.ID, codeInvalidParams, err.Error())
+	case errors.Is(err, ErrActionNotAllowed), errors.Is(err, transfer.ErrInvalidTransition):
+		writeRPCError(w, req.ID, codeActionNotAllowed, err.Error())
+	case errors.Is(err, db.ErrConflict):
+		writeRPCError(w, req.ID, codeConflict, err.Error())
+	case err != nil:
+		writeRPCError(w, req.ID, codeInternalError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: s.toPlatformTransaction(tx)})
+	}
+}
+
+// Do runs the named action against the transaction in params. If the
+// transaction changes underneath the action, Do reloads it and runs the
+// action again, giving up with db.ErrConflict after a few attempts.
+func (s *Service) Do(ctx context.Context, method string, params ActionParams) (db.Transaction, error) {
+	run, ok := actions[method]
+	if !ok {
+		return db.Transaction{}, fmt.Errorf("%w: unknown action %s", ErrInvalidParams, method)
+	}
+	params.audit = db.Audit{Source: db.SourcePlatform, Reason: params.Message}
+	if params.audit.Reason == "" {
+		params.audit.Reason = strings.ReplaceAll(method, "_", " ")
+	}
+	for attempt := 1; ; attempt++ {
+		tx, err := s.do(ctx, run, params)
+		if !errors.Is(err, db.ErrConflict) || attempt == conflictRetries || sideEffects[method] {
+			return tx, err
+		}
+	}
+}
+
+func (s *Service) do(ctx context.Context, run action, params ActionParams) (db.Transaction, error) {
+	tx, ok := s.TxStore.GetByID(params.TransactionID)
+	if params.TransactionID == "" || !ok {
+		return db.Transaction{}, ErrTransactionNotFound
+	}
+	if params.AmountIn.Sign() < 0 || params.AmountOut.Sign() < 0 || params.AmountFee.Sign() < 0 {
+		return tx, fmt.Errorf("%w: amounts must not be negative", ErrInvalidParams)
+	}
+	if params.Message != "" {
+		tx.Message = params.Message
+	}
+	return run(s, ctx, tx, params)
+}
+
+// requestOffchainFunds asks the user to send a deposit: amount_in is the
+// amount expected from the user.
+func (s *Service) requestOffchainFunds(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if err := requireKind(tx, "deposit"); err != nil {
+		return tx, err
+	}
+	if p.AmountIn.IsZero() && tx.Amount.IsZero() {
+		return tx, fmt.Errorf("%w: amount_in is required", ErrInvalidParams)
+	}
+	if err := s.setAmounts(&tx, decimal.Decimal{}, p); err != nil {
+		return tx, err
+	}
+	if !p.AmountIn.IsZero() {
+		tx.Amount = p.AmountIn
+	}
+	return s.transition(tx, transfer.StatusPendingUserTransferStart, p.audit)
+}
+
+// notifyOffchainFundsReceived records the user's off-chain deposit payment.
+func (s *Service) notifyOffchainFundsReceived(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if err := requireKind(tx, "deposit"); err != nil {
+		return tx, err
+	}
+	received := p.AmountIn
+	if received.IsZero() {
+		received = tx.Amount
+	}
+	if received.IsZero() {
+		return tx, fmt.Errorf("%w: amount_in is required", ErrInvalidParams)
+	}
+	if err := s.setAmounts(&tx, received, p); err != nil {
+		return tx, err
+	}
+	if p.ExternalTransactionID != "" {
+		tx.ExternalTransactionID = p.ExternalTransactionID
+	}
+	return s.transition(tx, transfer.StatusPendingAnchor, p.audit)
+}
+
+// doStellarPayment pays out a deposit in pending_anchor or pending_trust.
+func (s *Service) doStellarPayment(ctx context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if err := requireKind(tx, "deposit"); err != nil {
+		return tx, err
+	}
+	if !s.QueuePayouts && s.Deposits == nil {
+		return tx, fmt.Errorf("stellar payments are not configured")
+	}
+	if tx.Status != transfer.StatusPendingAnchor && tx.Status != transfer.StatusPendingTrust {
+		return tx, fmt.Errorf("%w: transaction is %s", ErrActionNotAllowed, tx.Status)
+	}
+	if err := s.setAmounts(&tx, decimal.Decimal{}, p); err != nil {
+		return tx, err
+	}
+	tx, err := s.TxStore.Update(tx, p.audit)
+	if err != nil || s.QueuePayouts {
+		return tx, err
+	}
+	return s.Deposits.SubmitDeposit(ctx, tx.ID)
+}
+
+// notifyOffchainFundsPending marks a withdrawal or SEP-31 payout as sent to
+// the off-chain rail but not yet settled.
+func (s *Service) notifyOffchainFundsPending(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if err := requireKind(tx, "withdraw", "receive"); err != nil {
+		return tx, err
+	}
+	if err := s.setAmounts(&tx, decimal.Decimal{}, p); err != nil {
+		return tx, err
+	}
+	if p.ExternalTransactionID != "" {
+		tx.ExternalTransactionID = p.ExternalTransactionID
+	}
+	return s.transition(tx, transfer.StatusPendingExternal, p.audit)
+}
+
+// notifyOffchainFundsSent completes a withdrawal or SEP-31 payout.
+func (s *Service) notifyOffchainFundsSent(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if err := requireKind(tx, "withdraw", "receive"); err != nil {
+		return tx, err
+	}
+	if err := s.setAmounts(&tx, decimal.Decimal{}, p); err != nil {
+		return tx, err
+	}
+	if p.ExternalTransactionID != "" {
+		tx.ExternalTransactionID = p.ExternalTransactionID
+	}
+	return s.transition(tx, transfer.StatusCompleted, p.audit)
+}
+
+// notifyRefundSent records a refund payment; the transaction moves to
+// refunded once amount_in has been returned in full.
+func (s *Service) notifyRefundSent(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if p.Refund == nil {
+		return tx, fmt.Errorf("%w: refund is required", ErrInvalidParams)
+	}
+	payment := db.RefundPayment{ID: p.Refund.ID, IDType: p.Refund.IDType, Amount: p.Refund.Amount, Fee: p.Refund.AmountFee}
+	full, err := transfer.ApplyRefund(&tx, payment, transfer.TxAsset(s.Config.Assets, tx), s.Config.RoundingMode)
+	if err != nil {
+		return tx, err
+	}
+	if full {
+		return s.transition(tx, transfer.StatusRefunded, p.audit)
+	}
+	tx.UpdatedAt = s.Now()
+	return s.TxStore.Update(tx, p.audit)
+}
+
+func (s *Service) notifyTransactionError(_ context.Context, tx db.Transaction, p ActionParams) (db.Transaction, error) {
+	if p.Message == "" {
+		return tx, fmt.Errorf("%w: message is required", ErrInvalidParams)
+	}
+	return s.transition(tx, transfer.StatusError, p.audit)
+}
+
+func (s *Service) transition(tx db.Transaction, status string, audit db.Audit) (db.Transaction, error) {
+	previous := tx.Status
+	if err := transfer.ValidateTransition(previous, status); err != nil {
+		return tx, err
+	}
+	now := s.Now()
+	tx.Status = status
+	tx.UpdatedAt = now
+	tx.UserActionRequiredBy = s.actionDeadline(status, now)
+	updated, err := s.TxStore.Update(tx, audit)
+	if err != nil {
+		return tx, err
+	}
+	tx = updated
+	if previous != status && s.Notifier != nil {
+		s.Notifier.Notify(tx)
+	}
+	return tx, nil
+}
+
+func requireKind(tx db.Transaction, kinds ...string) error {
+	for _, kind := range kinds {
+		if tx.Kind == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: transaction %s is a %s", ErrActionNotAllowed, tx.ID, tx.Kind)
+}
+
+// setAmounts applies the amount fields passed to an action; a zero amountIn
+// leaves amount_in untouched and a changed amount_in is priced again.
+func (s *Service) setAmounts(tx *db.Transaction, amountIn decimal.Decimal, p ActionParams) error {
+	if !p.AmountIn.IsZero() && p.AmountOut.IsZero() && !p.AmountIn.Equal(pricedAmount(*tx)) {
+		if err := s.reprice(tx, p.AmountIn, p.AmountFee); err != nil {
+			return err
+		}
+	}
+	if !amountIn.IsZero() {
+		tx.AmountIn = amountIn
+	}
+	if !p.AmountOut.IsZero() {
+		tx.AmountOut = p.AmountOut
+	}
+	if !p.AmountFee.IsZero() {
+		tx.AmountFee = p.AmountFee
+	}
+	if tx.QuoteID != "" {
+		return nil
+	}
+	total := tx.AmountIn
+	for _, amount := range []decimal.Decimal{p.AmountIn, tx.Amount} {
+		if total.IsZero() {
+			total = amount
+		}
+	}
+	if !total.IsZero() && tx.AmountOut.Add(tx.AmountFee).GreaterThan(total) {
+		return fmt.Errorf("%w: amount_out %s plus amount_fee %s exceeds amount_in %s", ErrInvalidParams, tx.AmountOut, tx.AmountFee, total)
+	}
+	return nil
+}
+
+// pricedAmount is the amount tx's amount_out and fee were computed for.
+func pricedAmount(tx db.Transaction) decimal.Decimal {
+	if tx.QuoteID != "" {
+		return tx.Amount
+	}
+	return tx.AmountOut.Add(tx.AmountFee)
+}
+
+// reprice sets the fee and amount_out for amount.
+func (s *Service) reprice(tx *db.Transaction, amount, fee decimal.Decimal) error {
+	if tx.QuoteID != "" {
+		return fmt.Errorf("%w: amount_out is required to change the amount of a quoted transaction", ErrInvalidParams)
+	}
+	if !fee.IsZero() {
+		if !fee.LessThan(amount) {
+			return fmt.Errorf("%w: amount_fee %s is not less than amount_in %s", ErrInvalidParams, fee, amount)
+		}
+		tx.AmountOut = amount.Sub(fee)
+		return nil
+	}
+	priced := *tx
+	priced.Amount = amount
+	err := transfer.ApplyFee(s.Fees, &priced, fees.Request{
+		Operation: tx.Kind,
+		Type:      tx.FundingMethod,
+		Asset:     transfer.TxAsset(s.Config.Assets, *tx).ID(),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidParams, transfer.FeeErrorMessage(err))
+	}
+	tx.AmountOut, tx.AmountFee, tx.FeeDetails = priced.AmountOut, priced.AmountFee, priced.FeeDetails
+	return nil
+}
+
+func writeRPCError(w http.ResponseWriter, id json.RawMessage, code int, message string) {
+	writeJSON(w, http.StatusOK, RPCResponse{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: message}})
+}
@@ -0,0 +1,155 @@
+// Package platform is the integration surface for the anchor's business
+// server: it lists transactions across SEP-6, SEP-24 and SEP-31 and moves
+// them through the shared state machine with JSON-RPC actions.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// Notifier is told about transactions whose status the platform changed so
+// the owning SEP service can call back the wallet or sending anchor.
+type Notifier interface {
+	Notify(tx db.Transaction)
+}
+
+// Notifiers fans a notification out to every service; each ignores
+// transactions of other protocols.
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(tx db.Transaction) {
+	for _, notifier := range n {
+		notifier.Notify(tx)
+	}
+}
+
+// DepositSubmitter sends the Stellar payment for a deposit in pending_anchor.
+type DepositSubmitter interface {
+	SubmitDeposit(ctx context.Context, id string) (db.Transaction, error)
+}
+
+type Service struct {
+	Config   config.Config
+	TxStore  db.TransactionStore
+	Deposits DepositSubmitter
+	Notifier Notifier
+	Fees     fees.Calculator
+	Now      func() time.Time
+	// QueuePayouts leaves do_stellar_payment payouts to the deposit worker.
+	QueuePayouts bool
+}
+
+func NewService(cfg config.Config, txStore db.TransactionStore) *Service {
+	return &Service{
+		Config:  cfg,
+		TxStore: txStore,
+		Fees:    fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, fees.RulesFromAssets(cfg.Assets)),
+		Now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Service) RegisterRoutes(mux *http.ServeMux, adminMiddleware func(http.Handler) http.Handler) {
+	mux.Handle("/platform/transactions", adminMiddleware(http.HandlerFunc(s.handleListTransactions)))
+	mux.Handle("/platform/transactions/", adminMiddleware(http.HandlerFunc(s.handleGetTransaction)))
+	mux.Handle("/platform/actions", adminMiddleware(http.HandlerFunc(s.handleAction)))
+}
+
+func (s *Service) actionDeadline(status string, from time.Time) time.Time {
+	timeout, ok := s.Config.ExpireAfter[status]
+	if !ok || timeout <= 0 {
+		return time.Time{}
+	}
+	return from.Add(timeout)
+}
+
+// toPlatformTransaction renders tx in one shape for every protocol, with
+// each amount paired with its SEP-38 asset.
+func (s *Service) toPlatformTransaction(tx db.Transaction) map[string]any {
+	asset := transfer.TxAsset(s.Config.Assets, tx)
+	amount := func(value decimal.Decimal, assetID string) map[string]string {
+		a := transfer.AssetForID(s.Config.Assets, assetID, asset)
+		return map[string]string{
+			"amount": transfer.FormatAmount(a, value, s.Config.RoundingMode),
+			"asset":  a.ID().String(),
+		}
+	}
+	feeAssetID := ""
+	if tx.FeeDetails != nil {
+		feeAssetID = tx.FeeDetails.Asset
+	}
+
+	out := map[string]any{
+		"id":         tx.ID,
+		"sep":        strings.TrimPrefix(tx.Protocol, "sep"),
+		"kind":       tx.Kind,
+		"status":     tx.Status,
+		"account":    tx.Account,
+		"started_at": tx.StartedAt,
+		"updated_at": tx.UpdatedAt,
+	}
+	if !tx.Amount.IsZero() {
+		out["amount_expected"] = amount(tx.Amount, tx.AmountInAsset)
+	}
+	if !tx.AmountIn.IsZero() {
+		out["amount_in"] = amount(tx.AmountIn, tx.AmountInAsset)
+	}
+	if !tx.AmountOut.IsZero() {
+		out["amount_out"] = amount(tx.AmountOut, tx.AmountOutAsset)
+	}
+	if !tx.AmountFee.IsZero() {
+		out["amount_fee"] = amount(tx.AmountFee, feeAssetID)
+	}
+	if tx.FeeDetails != nil {
+		feeAsset := transfer.AssetForID(s.Config.Assets, feeAssetID, asset)
+		out["fee_details"] = transfer.RenderFeeDetails(feeAsset, *tx.FeeDetails, s.Config.RoundingMode)
+	}
+	if tx.QuoteID != "" {
+		out["quote_id"] = tx.QuoteID
+	}
+	if tx.Message != "" {
+		out["message"] = tx.Message
+	}
+	if !tx.UserActionRequiredBy.IsZero() {
+		out["user_action_required_by"] = tx.UserActionRequiredBy
+	}
+	if tx.StellarTransactionID != "" {
+		out["stellar_transaction_id"] = tx.StellarTransactionID
+	}
+	if tx.ExternalTransactionID != "" {
+		out["external_transaction_id"] = tx.ExternalTransactionID
+	}
+	if tx.WithdrawMemo != "" {
+		out["memo"] = tx.WithdrawMemo
+		out["memo_type"] = tx.WithdrawMemoType
+	}
+	if tx.ClientDomain != "" {
+		out["client_domain"] = tx.ClientDomain
+	}
+	if tx.SenderID != "" || tx.ReceiverID != "" {
+		out["customers"] = map[string]string{"sender": tx.SenderID, "receiver": tx.ReceiverID}
+	}
+	if tx.Refunds != nil {
+		out["refunds"] = transfer.RenderRefunds(asset, *tx.Refunds, s.Config.RoundingMode)
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,356 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+const testAdminKey = "admin-key"
+
+type notified []db.Transaction
+
+func (n *notified) Notify(tx db.Transaction) {
+	*n = append(*n, tx)
+}
+
+type fakeDeposits struct {
+	store db.TransactionStore
+}
+
+func (f fakeDeposits) SubmitDeposit(_ context.Context, id string) (db.Transaction, error) {
+	tx, _ := f.store.GetByID(id)
+	tx.Status = transfer.StatusPendingStellar
+	tx.StellarTransactionID = "stellar-hash"
+	return f.store.Update(tx, db.Audit{Source: db.SourceSubmitter})
+}
+
+func TestListAndGetTransactions(t *testing.T) {
+	service, mux := testServiceAndMux()
+	now := time.Now().UTC()
+	createTransaction(t, service, db.Transaction{ID: "dep-1", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusIncomplete, AssetCode: "USDC", Amount: decimal.MustParse("10"), StartedAt: now.Add(-time.Minute)})
+	createTransaction(t, service, db.Transaction{ID: "wdr-1", Protocol: transfer.ProtocolSEP6, Kind: "withdraw", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", StartedAt: now})
+	createTransaction(t, service, db.Transaction{ID: "rcv-1", Protocol: transfer.ProtocolSEP31, Kind: "receive", Status: transfer.StatusPendingReceiver, AssetCode: "USDC", SenderID: "s", ReceiverID: "r", StartedAt: now.Add(time.Minute)})
+
+	if rec := request(mux, http.MethodGet, "/platform/transactions", "wrong-key", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+
+	var list struct {
+		Transactions []map[string]any `json:"transactions"`
+	}
+	decode(t, request(mux, http.MethodGet, "/platform/transactions", testAdminKey, nil), http.StatusOK, &list)
+	if len(list.Transactions) != 3 || list.Transactions[0]["id"] != "rcv-1" || list.Transactions[2]["id"] != "dep-1" {
+		t.Fatalf("expected all transactions newest first, got %+v", list.Transactions)
+	}
+	decode(t, request(mux, http.MethodGet, "/platform/transactions?sep=24&status=incomplete,pending_anchor", testAdminKey, nil), http.StatusOK, &list)
+	if len(list.Transactions) != 1 || list.Transactions[0]["sep"] != "24" {
+		t.Fatalf("expected the SEP-24 deposit only, got %+v", list.Transactions)
+	}
+	if rec := request(mux, http.MethodGet, "/platform/transactions?status=bogus", testAdminKey, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown status, got %d", rec.Code)
+	}
+
+	var got struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	decode(t, request(mux, http.MethodGet, "/platform/transactions/dep-1", testAdminKey, nil), http.StatusOK, &got)
+	expected, _ := got.Transaction["amount_expected"].(map[string]any)
+	if expected["amount"] != "10.00" || expected["asset"] != "stellar:USDC" {
+		t.Fatalf("unexpected amount_expected: %+v", got.Transaction)
+	}
+	if rec := request(mux, http.MethodGet, "/platform/transactions/missing", testAdminKey, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestDepositActions(t *testing.T) {
+	service, mux := testServiceAndMux()
+	events := &notified{}
+	service.Notifier = events
+	service.Deposits = fakeDeposits{store: service.TxStore}
+	createTransaction(t, service, db.Transaction{ID: "dep-1", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusIncomplete, AssetCode: "USDC", StartedAt: time.Now().UTC()})
+
+	result := callAction(t, mux, "request_offchain_funds", map[string]any{"transaction_id": "dep-1"})
+	if result.Error == nil || result.Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params without amount_in, got %+v", result)
+	}
+	callAction(t, mux, "request_offchain_funds", map[string]any{"transaction_id": "dep-1", "amount_in": "100", "amount_out": "99", "amount_fee": "1"})
+	result = callAction(t, mux, "do_stellar_payment", map[string]any{"transaction_id": "dep-1"})
+	if result.Error == nil || result.Error.Code != codeActionNotAllowed {
+		t.Fatalf("expected payment before funds to be rejected, got %+v", result)
+	}
+	callAction(t, mux, "notify_offchain_funds_received", map[string]any{"transaction_id": "dep-1", "external_transaction_id": "bank-1"})
+
+	tx, _ := service.TxStore.GetByID("dep-1")
+	if tx.Status != transfer.StatusPendingAnchor || !tx.Amount.Equal(decimal.MustParse("100")) || !tx.AmountIn.Equal(decimal.MustParse("100")) ||
+		!tx.AmountOut.Equal(decimal.MustParse("99")) || !tx.AmountFee.Equal(decimal.MustParse("1")) || tx.ExternalTransactionID != "bank-1" {
+		t.Fatalf("unexpected transaction after funds received: %+v", tx)
+	}
+	if len(*events) != 2 || (*events)[1].Status != transfer.StatusPendingAnchor {
+		t.Fatalf("expected a notification per status change, got %+v", *events)
+	}
+
+	result = callAction(t, mux, "do_stellar_payment", map[string]any{"transaction_id": "dep-1"})
+	if result.Error != nil || result.Result["status"] != transfer.StatusPendingStellar || result.Result["stellar_transaction_id"] != "stellar-hash" {
+		t.Fatalf("unexpected stellar payment result: %+v", result)
+	}
+}
+
+func TestFundsReceivedRepricesChangedAmount(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Config.Assets[0].FeeFixed = decimal.MustParse("1")
+	service.Fees = fees.NewRulesCalculator(service.Config.Assets, service.Config.RoundingMode, fees.RulesFromAssets(service.Config.Assets))
+	createTransaction(t, service, db.Transaction{ID: "dep-1", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusPendingUserTransferStart, AssetCode: "USDC",
+		Amount: decimal.MustParse("100"), AmountOut: decimal.MustParse("99"), AmountFee: decimal.MustParse("1"), StartedAt: time.Now().UTC()})
+	createTransaction(t, service, db.Transaction{ID: "dep-2", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusPendingUserTransferStart, AssetCode: "USDC",
+		Amount: decimal.MustParse("100"), AmountOut: decimal.MustParse("99"), AmountFee: decimal.MustParse("1"), StartedAt: time.Now().UTC()})
+
+	result := callAction(t, mux, "notify_offchain_funds_received", map[string]any{"transaction_id": "dep-1", "amount_in": "50"})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %+v", result.Error)
+	}
+	tx, _ := service.TxStore.GetByID("dep-1")
+	if !tx.Amount.Equal(decimal.MustParse("100")) || !tx.AmountIn.Equal(decimal.MustParse("50")) ||
+		!tx.AmountOut.Equal(decimal.MustParse("49")) || !tx.AmountFee.Equal(decimal.MustParse("1")) {
+		t.Fatalf("expected amount_out to be priced for the amount received, got %+v", tx)
+	}
+
+	result = callAction(t, mux, "notify_offchain_funds_received", map[string]any{"transaction_id": "dep-2", "amount_in": "50", "amount_out": "99", "amount_fee": "1"})
+	if result.Error == nil || result.Error.Code != codeInvalidParams {
+		t.Fatalf("expected amount_out plus fee above amount_in to be rejected, got %+v", result)
+	}
+	if tx, _ := service.TxStore.GetByID("dep-2"); tx.Status != transfer.StatusPendingUserTransferStart || !tx.AmountIn.IsZero() {
+		t.Fatalf("expected the rejected action not to be applied, got %+v", tx)
+	}
+
+	callAction(t, mux, "request_offchain_funds", map[string]any{"transaction_id": "dep-2", "amount_in": "20"})
+	if tx, _ := service.TxStore.GetByID("dep-2"); !tx.Amount.Equal(decimal.MustParse("20")) || !tx.AmountOut.Equal(decimal.MustParse("19")) {
+		t.Fatalf("expected request_offchain_funds to reprice the new amount, got %+v", tx)
+	}
+}
+
+func TestRefundAndErrorActions(t *testing.T) {
+	service, mux := testServiceAndMux()
+	createTransaction(t, service, db.Transaction{ID: "rcv-1", Protocol: transfer.ProtocolSEP31, Kind: "receive", Status: transfer.StatusPendingReceiver, AssetCode: "USDC", Amount: decimal.MustParse("50"), StartedAt: time.Now().UTC()})
+	createTransaction(t, service, db.Transaction{ID: "wdr-1", Protocol: transfer.ProtocolSEP24, Kind: "withdraw", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", StartedAt: time.Now().UTC()})
+
+	result := callAction(t, mux, "request_offchain_funds", map[string]any{"transaction_id": "rcv-1", "amount_in": "50"})
+	if result.Error == nil || result.Error.Code != codeActionNotAllowed {
+		t.Fatalf("expected deposit action on a receive to be rejected, got %+v", result)
+	}
+	callAction(t, mux, "notify_refund_sent", map[string]any{"transaction_id": "rcv-1", "refund": map[string]any{"id": "r1", "amount": "20", "amount_fee": "0"}})
+	if tx, _ := service.TxStore.GetByID("rcv-1"); tx.Status != transfer.StatusPendingReceiver || tx.Refunds == nil {
+		t.Fatalf("expected partial refund to keep status, got %+v", tx)
+	}
+	result = callAction(t, mux, "notify_refund_sent", map[string]any{"transaction_id": "rcv-1", "refund": map[string]any{"id": "r2", "amount": "40", "amount_fee": "0"}})
+	if result.Error == nil || result.Error.Code != codeInvalidParams {
+		t.Fatalf("expected over-refund to be rejected, got %+v", result)
+	}
+	result = callAction(t, mux, "notify_refund_sent", map[string]any{"transaction_id": "rcv-1", "refund": map[string]any{"id": "r2", "amount": "29", "amount_fee": "1"}})
+	if result.Result["status"] != transfer.StatusRefunded {
+		t.Fatalf("expected full refund, got %+v", result)
+	}
+
+	callAction(t, mux, "notify_offchain_funds_pending", map[string]any{"transaction_id": "wdr-1", "external_transaction_id": "wire-1"})
+	result = callAction(t, mux, "notify_transaction_error", map[string]any{"transaction_id": "wdr-1", "message": "bank rejected the wire"})
+	if result.Result["status"] != transfer.StatusError || result.Result["message"] != "bank rejected the wire" {
+		t.Fatalf("unexpected error result: %+v", result)
+	}
+	result = callAction(t, mux, "notify_offchain_funds_sent", map[string]any{"transaction_id": "wdr-1"})
+	if result.Error == nil || result.Error.Code != codeActionNotAllowed {
+		t.Fatalf("expected invalid transition from error, got %+v", result)
+	}
+	result = callAction(t, mux, "no_such_action", map[string]any{"transaction_id": "wdr-1"})
+	if result.Error == nil || result.Error.Code != codeMethodNotFound {
+		t.Fatalf("expected method not found, got %+v", result)
+	}
+}
+
+func TestTransactionHistory(t *testing.T) {
+	service, mux := testServiceAndMux()
+	createTransaction(t, service, db.Transaction{ID: "wdr-1", Protocol: transfer.ProtocolSEP24, Kind: "withdraw", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", StartedAt: time.Now().UTC()})
+	callAction(t, mux, "notify_offchain_funds_pending", map[string]any{"transaction_id": "wdr-1", "external_transaction_id": "wire-1"})
+	callAction(t, mux, "notify_transaction_error", map[string]any{"transaction_id": "wdr-1", "message": "bank rejected the wire"})
+
+	if rec := request(mux, http.MethodGet, "/platform/transactions/wdr-1/history", "wrong-key", nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected history to require the admin key, got %d", rec.Code)
+	}
+	var body struct {
+		History []db.HistoryEntry `json:"history"`
+	}
+	decode(t, request(mux, http.MethodGet, "/platform/transactions/wdr-1/history", testAdminKey, nil), http.StatusOK, &body)
+	if len(body.History) != 3 {
+		t.Fatalf("expected create plus one entry per action, got %+v", body.History)
+	}
+	pending, failed := body.History[1], body.History[2]
+	if pending.Source != db.SourcePlatform || pending.Reason != "notify offchain funds pending" || len(pending.Changes) != 2 ||
+		pending.Changes[0].Field != "external_transaction_id" || pending.Changes[1].Field != "status" ||
+		string(pending.Changes[1].Before) != `"pending_anchor"` || string(pending.Changes[1].After) != `"pending_external"` {
+		t.Fatalf("unexpected history entry for the pending action: %+v", pending)
+	}
+	if failed.Reason != "bank rejected the wire" || failed.Version != 2 {
+		t.Fatalf("expected the action message as the reason, got %+v", failed)
+	}
+
+	if rec := request(mux, http.MethodGet, "/platform/transactions/missing/history", testAdminKey, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown transaction, got %d", rec.Code)
+	}
+}
+
+// racingStore lets another writer update the transaction just before each
+// of the next races calls to Update, so those calls lose the version check.
+type racingStore struct {
+	db.TransactionStore
+	races int
+}
+
+func (r *racingStore) Update(tx db.Transaction, audit db.Audit) (db.Transaction, error) {
+	if r.races > 0 {
+		r.races--
+		current, _ := r.TransactionStore.GetByID(tx.ID)
+		current.Message = "updated concurrently"
+		if _, err := r.TransactionStore.Update(current, db.Audit{Source: db.SourceAdmin}); err != nil {
+			return db.Transaction{}, err
+		}
+	}
+	return r.TransactionStore.Update(tx, audit)
+}
+
+func TestActionRetriesOnConflict(t *testing.T) {
+	service, mux := testServiceAndMux()
+	store := &racingStore{TransactionStore: service.TxStore}
+	service.TxStore = store
+	createTransaction(t, service, db.Transaction{ID: "wdr-1", Protocol: transfer.ProtocolSEP24, Kind: "withdraw", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", StartedAt: time.Now().UTC()})
+
+	store.races = 1
+	result := callAction(t, mux, "notify_offchain_funds_pending", map[string]any{"transaction_id": "wdr-1"})
+	if result.Error != nil || result.Result["status"] != transfer.StatusPendingExternal {
+		t.Fatalf("expected the action to be re-run on the reloaded transaction, got %+v", result)
+	}
+	if history, _ := service.TxStore.History("wdr-1"); len(history) != 3 || history[1].Source != db.SourceAdmin {
+		t.Fatalf("expected the action to be written after the concurrent update, got %+v", history)
+	}
+
+	store.races = conflictRetries
+	result = callAction(t, mux, "notify_offchain_funds_sent", map[string]any{"transaction_id": "wdr-1"})
+	if result.Error == nil || result.Error.Code != codeConflict {
+		t.Fatalf("expected a conflict error after %d lost races, got %+v", conflictRetries, result)
+	}
+	if tx, _ := service.TxStore.GetByID("wdr-1"); tx.Status != transfer.StatusPendingExternal {
+		t.Fatalf("expected the conflicting action not to be applied, got %+v", tx)
+	}
+}
+
+// conflictingDeposits loses the race for every deposit it is asked to pay.
+type conflictingDeposits struct {
+	calls int
+}
+
+func (f *conflictingDeposits) SubmitDeposit(context.Context, string) (db.Transaction, error) {
+	f.calls++
+	return db.Transaction{}, db.ErrConflict
+}
+
+func TestStellarPaymentIsNeverRetried(t *testing.T) {
+	service, mux := testServiceAndMux()
+	deposits := &conflictingDeposits{}
+	service.Deposits = deposits
+	createTransaction(t, service, db.Transaction{ID: "dep-1", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", Amount: decimal.MustParse("10"), StartedAt: time.Now().UTC()})
+
+	result := callAction(t, mux, "do_stellar_payment", map[string]any{"transaction_id": "dep-1"})
+	if result.Error == nil || result.Error.Code != codeConflict || deposits.calls != 1 {
+		t.Fatalf("expected one payment attempt and a conflict error, got %+v after %d attempts", result, deposits.calls)
+	}
+}
+
+func TestQueuedStellarPaymentLeavesPayoutToWorker(t *testing.T) {
+	service, mux := testServiceAndMux()
+	deposits := &conflictingDeposits{}
+	service.Deposits = deposits
+	service.QueuePayouts = true
+	createTransaction(t, service, db.Transaction{ID: "dep-1", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: transfer.StatusPendingAnchor, AssetCode: "USDC", Amount: decimal.MustParse("10"), StartedAt: time.Now().UTC()})
+
+	result := callAction(t, mux, "do_stellar_payment", map[string]any{"transaction_id": "dep-1", "amount_out": "9", "amount_fee": "1"})
+	if result.Error != nil || result.Result["status"] != transfer.StatusPendingAnchor || deposits.calls != 0 {
+		t.Fatalf("expected the payout to be queued for the deposit worker, got %+v", result)
+	}
+	if tx, _ := service.TxStore.GetByID("dep-1"); !tx.AmountOut.Equal(decimal.MustParse("9")) {
+		t.Fatalf("expected the payout amount to be recorded, got %+v", tx)
+	}
+
+	tx, _ := service.TxStore.GetByID("dep-1")
+	tx.Status = transfer.StatusPendingStellar
+	if _, err := service.TxStore.Update(tx, db.Audit{Source: db.SourceSubmitter}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	result = callAction(t, mux, "do_stellar_payment", map[string]any{"transaction_id": "dep-1"})
+	if result.Error == nil || result.Error.Code != codeActionNotAllowed {
+		t.Fatalf("expected a deposit already being paid to be rejected, got %+v", result)
+	}
+}
+
+type rpcResult struct {
+	Result map[string]any `json:"result"`
+	Error  *RPCError      `json:"error"`
+}
+
+func callAction(t *testing.T, mux *http.ServeMux, method string, params map[string]any) rpcResult {
+	t.Helper()
+	body := map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params}
+	var result rpcResult
+	decode(t, request(mux, http.MethodPost, "/platform/actions", testAdminKey, body), http.StatusOK, &result)
+	return result
+}
+
+func createTransaction(t *testing.T, service *Service, tx db.Transaction) {
+	t.Helper()
+	if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+}
+
+func request(mux *http.ServeMux, method, path, key string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+key)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, want int, out any) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("expected %d, got %d body=%s", want, rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+}
+
+func testServiceAndMux() (*Service, *http.ServeMux) {
+	cfg := config.Config{
+		Assets: []config.Asset{
+			{Code: "USDC", Enabled: true, SignificantDecimals: 2},
+		},
+	}
+	service := NewService(cfg, db.NewMemoryTransactionStore())
+	mux := http.NewServeMux()
+	service.RegisterRoutes(mux, middleware.AdminAuth(testAdminKey))
+	return service, mux
+}
@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// handleListTransactions serves GET /platform/transactions, filtered by a
+// comma-separated status list, sep, kind and account, newest first.
+func (s *Service) handleListTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+
+	limit := defaultListLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(n, maxListLimit)
+	}
+	statuses := transfer.Statuses()
+	if raw := query.Get("status"); raw != "" {
+		statuses = strings.Split(raw, ",")
+		for _, status := range statuses {
+			if !slices.Contains(transfer.Statuses(), status) {
+				writeError(w, http.StatusBadRequest, "invalid status")
+				return
+			}
+		}
+	}
+	protocol := ""
+	if sep := query.Get("sep"); sep != "" {
+		protocol = "sep" + sep
+		if protocol != transfer.ProtocolSEP6 && protocol != transfer.ProtocolSEP24 && protocol != transfer.ProtocolSEP31 {
+			writeError(w, http.StatusBadRequest, "invalid sep")
+			return
+		}
+	}
+	kind := query.Get("kind")
+	account := query.Get("account")
+
+	var matches []db.Transaction
+	for _, status := range statuses {
+		for _, tx := range s.TxStore.ListByStatus(db.StatusQuery{Status: status}) {
+			if protocol != "" && tx.Protocol != protocol {
+				continue
+			}
+			if kind != "" && tx.Kind != kind {
+				continue
+			}
+			if account != "" && tx.Account != account {
+				continue
+			}
+			matches = append(matches, tx)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].StartedAt.Equal(matches[j].StartedAt) {
+			return matches[i].StartedAt.After(matches[j].StartedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	transactions := make([]map[string]any, 0, len(matches))
+	for _, tx := range matches {
+		transactions = append(transactions, s.toPlatformTransaction(tx))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transactions": transactions})
+}
+
+// handleGetTransaction serves GET /platform/transactions/:id and
+// GET /platform/transactions/:id/history.
+func (s *Service) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, history := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/platform/transactions/"), "/history")
+	tx, ok := s.TxStore.GetByID(id)
+	if id == "" || !ok {
+		writeError(w, http.StatusNotFound, "transaction not found")
+		return
+	}
+	if !history {
+		writeJSON(w, http.StatusOK, map[string]any{"transaction": s.toPlatformTransaction(tx)})
+		return
+	}
+	entries, err := s.TxStore.History(tx.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read transaction history")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"history": entries})
+}
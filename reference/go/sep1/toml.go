@@ -15,13 +15,25 @@ func RenderStellarTOML(cfg config.Config) string {
 	b.WriteString(fmt.Sprintf("WEB_AUTH_ENDPOINT=\"http://%s/auth\"\n", cfg.HomeDomain))
 	b.WriteString(fmt.Sprintf("TRANSFER_SERVER=\"%s\"\n", cfg.TransferServer))
 	b.WriteString(fmt.Sprintf("TRANSFER_SERVER_SEP0024=\"%s\"\n", cfg.TransferServerSep24))
+	if cfg.KYCServer != "" {
+		b.WriteString(fmt.Sprintf("KYC_SERVER=\"%s\"\n", cfg.KYCServer))
+	}
+	if cfg.DirectPaymentServer != "" {
+		b.WriteString(fmt.Sprintf("DIRECT_PAYMENT_SERVER=\"%s\"\n", cfg.DirectPaymentServer))
+	}
 	if cfg.QuoteServer != "" {
 		b.WriteString(fmt.Sprintf("ANCHOR_QUOTE_SERVER=\"%s\"\n", cfg.QuoteServer))
 	}
 
 	for _, asset := range cfg.Assets {
+		if !asset.ID().IsStellar() {
+			continue
+		}
 		b.WriteString("\n[[CURRENCIES]]\n")
 		b.WriteString(fmt.Sprintf("code=\"%s\"\n", asset.Code))
+		if asset.Issuer != "" {
+			b.WriteString(fmt.Sprintf("issuer=\"%s\"\n", asset.Issuer))
+		}
 		b.WriteString("status=\"live\"\n")
 		b.WriteString("desc=\"Reference anchor asset\"\n")
 		b.WriteString("is_asset_anchored=true\n")
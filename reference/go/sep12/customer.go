@@ -0,0 +1,545 @@
+package sep12
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math/big"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+)
+
+const maxUploadBytes = 10 << 20
+
+const (
+	verificationCodeTTL     = 15 * time.Minute
+	maxVerificationAttempts = 5
+)
+
+var ErrInvalidField = errors.New("invalid field")
+
+// reservedKeys are request parameters that identify the customer rather than
+// carry SEP-9 data.
+var reservedKeys = map[string]bool{
+	"id": true, "account": true, "memo": true, "memo_type": true,
+	"type": true, "transaction_id": true, "lang": true,
+}
+
+type ReviewRequest struct {
+	ID      string            `json:"id"`
+	Status  string            `json:"status,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func (s *Service) RegisterAdminRoutes(mux *http.ServeMux, adminMiddleware func(http.Handler) http.Handler) {
+	mux.Handle("/admin/sep12/customer", adminMiddleware(http.HandlerFunc(s.handleAdminCustomer)))
+}
+
+func (s *Service) handleAdminCustomer(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleExport(w, r)
+	case http.MethodPut:
+		s.handleReview(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleExport returns the stored customer for operators. SEP-9 values and
+// verification codes are redacted; only which fields were provided shows.
+func (s *Service) handleExport(w http.ResponseWriter, r *http.Request) {
+	customer, ok := s.Customers.GetByID(r.URL.Query().Get("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrCustomerNotFound.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, customer.Redacted())
+}
+
+func (s *Service) handlePutCustomer(w http.ResponseWriter, r *http.Request) {
+	values, files, err := s.readForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ref, err := s.customerRef(r, values["id"], values["account"], values["memo"], values["memo_type"])
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer, err := s.lookup(ref)
+	switch {
+	case errors.Is(err, ErrCustomerNotFound) && ref.ID == "":
+		id, err := newCustomerID()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to create customer")
+			return
+		}
+		now := s.Now()
+		customer = db.Customer{ID: id, Account: ref.Account, Memo: ref.Memo, MemoType: ref.MemoType, Status: StatusNeedsInfo, CreatedAt: now, UpdatedAt: now}
+	case err != nil:
+		writeRefError(w, err)
+		return
+	}
+	customer, err = s.Update(customer, values, files)
+	switch {
+	case errors.Is(err, ErrCustomerRejected):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, ErrInvalidField):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to store customer")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": customer.ID})
+}
+
+// Update validates and stores SEP-9 values and binary fields. Values that
+// need verification get a fresh code; binary fields and rejected values wait
+// for review. Changes apply to the stored customer when there is one, not
+// to the caller's copy, which may predate a verification or review.
+func (s *Service) Update(customer db.Customer, values map[string]string, files map[string]db.Blob) (db.Customer, error) {
+	byName := make(map[string]Field, len(s.Fields))
+	for _, f := range s.Fields {
+		byName[f.Name] = f
+	}
+	for _, name := range sortedKeys(values) {
+		if reservedKeys[name] {
+			continue
+		}
+		f, ok := byName[name]
+		if !ok {
+			return customer, fmt.Errorf("%w: unknown field %s", ErrInvalidField, name)
+		}
+		if f.Type == TypeBinary {
+			return customer, fmt.Errorf("%w: %s must be uploaded as a file", ErrInvalidField, name)
+		}
+		if err := validateValue(f, values[name]); err != nil {
+			return customer, fmt.Errorf("%w: %v", ErrInvalidField, err)
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		f, ok := byName[name]
+		if !ok {
+			return customer, fmt.Errorf("%w: unknown field %s", ErrInvalidField, name)
+		}
+		if f.Type != TypeBinary {
+			return customer, fmt.Errorf("%w: %s is not a binary field", ErrInvalidField, name)
+		}
+	}
+
+	s.writes.Lock()
+	defer s.writes.Unlock()
+	if stored, ok := s.Customers.GetByID(customer.ID); ok {
+		customer = stored
+	}
+	if customer.Status == StatusRejected {
+		return customer, ErrCustomerRejected
+	}
+	if values["type"] != "" {
+		customer.Type = values["type"]
+	}
+
+	previous := customer.Status
+	customer = cloneCustomer(customer)
+	var pending []string
+	for _, name := range sortedKeys(values) {
+		if reservedKeys[name] {
+			continue
+		}
+		value := values[name]
+		status := customer.FieldStatus[name]
+		if customer.Fields[name] == value && status != StatusRejected && (status != FieldStatusVerificationRequired || s.liveCode(customer, name)) {
+			continue
+		}
+		customer.Fields[name] = value
+		customer.FieldStatus[name] = StatusAccepted
+		if status == StatusRejected {
+			customer.FieldStatus[name] = StatusProcessing
+		} else if byName[name].Verification {
+			code, err := verificationCode()
+			if err != nil {
+				return customer, err
+			}
+			customer.FieldStatus[name] = FieldStatusVerificationRequired
+			customer.VerificationCodes[name] = code
+			customer.Verifications[name] = db.Verification{ExpiresAt: s.Now().Add(verificationCodeTTL)}
+			pending = append(pending, name)
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		key := customer.ID + "/" + name
+		if err := s.Blobs.Put(key, files[name]); err != nil {
+			return customer, err
+		}
+		customer.Files[name] = key
+		customer.FieldStatus[name] = StatusProcessing
+	}
+
+	customer.Status = s.status(customer)
+	customer.UpdatedAt = s.Now()
+	if err := s.save(previous, customer); err != nil {
+		return customer, err
+	}
+	for _, name := range pending {
+		s.SendVerification(customer, name, customer.VerificationCodes[name])
+	}
+	return customer, nil
+}
+
+func (s *Service) handleVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	values, _, err := s.readForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if values["id"] == "" {
+		writeError(w, http.StatusBadRequest, "missing id")
+		return
+	}
+	ref, err := s.customerRef(r, values["id"], values["account"], values["memo"], values["memo_type"])
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer, err := s.lookup(ref)
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+
+	codes := map[string]string{}
+	for key, value := range values {
+		if name, ok := strings.CutSuffix(key, "_verification"); ok {
+			codes[name] = value
+		}
+	}
+	customer, err = s.Verify(customer, codes)
+	switch {
+	case errors.Is(err, ErrInvalidField):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to store customer")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.render(customer))
+}
+
+// Verify checks codes keyed by field name and accepts the verified fields.
+func (s *Service) Verify(customer db.Customer, codes map[string]string) (db.Customer, error) {
+	if len(codes) == 0 {
+		return customer, fmt.Errorf("%w: missing verification fields", ErrInvalidField)
+	}
+	s.writes.Lock()
+	defer s.writes.Unlock()
+	customer, ok := s.Customers.GetByID(customer.ID)
+	if !ok {
+		return customer, ErrCustomerNotFound
+	}
+	customer = cloneCustomer(customer)
+	for _, name := range sortedKeys(codes) {
+		if !s.liveCode(customer, name) {
+			return customer, fmt.Errorf("%w: no valid verification code for %s, submit the field again", ErrInvalidField, name)
+		}
+		if subtle.ConstantTimeCompare([]byte(customer.VerificationCodes[name]), []byte(codes[name])) == 1 {
+			continue
+		}
+		verification := customer.Verifications[name]
+		verification.Attempts++
+		customer.Verifications[name] = verification
+		if verification.Attempts >= maxVerificationAttempts {
+			delete(customer.VerificationCodes, name)
+			delete(customer.Verifications, name)
+		}
+		customer.UpdatedAt = s.Now()
+		if err := s.Customers.Put(customer); err != nil {
+			return customer, err
+		}
+		return customer, fmt.Errorf("%w: invalid verification code for %s", ErrInvalidField, name)
+	}
+	previous := customer.Status
+	for name := range codes {
+		customer.FieldStatus[name] = StatusAccepted
+		delete(customer.VerificationCodes, name)
+		delete(customer.Verifications, name)
+	}
+	customer.Status = s.status(customer)
+	customer.UpdatedAt = s.Now()
+	return customer, s.save(previous, customer)
+}
+
+func (s *Service) liveCode(customer db.Customer, name string) bool {
+	return customer.VerificationCodes[name] != "" && s.Now().Before(customer.Verifications[name].ExpiresAt)
+}
+
+func (s *Service) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	values, _, err := s.readForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	callback, err := url.Parse(values["url"])
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		writeError(w, http.StatusBadRequest, "invalid url")
+		return
+	}
+	ref, err := s.customerRef(r, values["id"], values["account"], values["memo"], values["memo_type"])
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer, err := s.lookup(ref)
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer.CallbackURL = callback.String()
+	customer.UpdatedAt = s.Now()
+	if err := s.save(customer.Status, customer); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to store customer")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Service) handleReview(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json request")
+		return
+	}
+	customer, err := s.Review(req)
+	switch {
+	case errors.Is(err, ErrCustomerNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, ErrInvalidField):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to store customer")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.render(customer))
+}
+
+// Review records the outcome of a manual check: per-field ACCEPTED or
+// REJECTED decisions, or rejecting the customer outright.
+func (s *Service) Review(req ReviewRequest) (db.Customer, error) {
+	s.writes.Lock()
+	defer s.writes.Unlock()
+	customer, ok := s.Customers.GetByID(req.ID)
+	if !ok {
+		return db.Customer{}, ErrCustomerNotFound
+	}
+	if req.Status != "" && req.Status != StatusRejected {
+		return customer, fmt.Errorf("%w: status may only be set to %s", ErrInvalidField, StatusRejected)
+	}
+	for name, status := range req.Fields {
+		if _, ok := customer.FieldStatus[name]; !ok {
+			return customer, fmt.Errorf("%w: %s has not been provided", ErrInvalidField, name)
+		}
+		if status != StatusAccepted && status != StatusRejected {
+			return customer, fmt.Errorf("%w: invalid status %q for %s", ErrInvalidField, status, name)
+		}
+	}
+
+	previous := customer.Status
+	customer = cloneCustomer(customer)
+	maps.Copy(customer.FieldStatus, req.Fields)
+	if req.Status != "" {
+		customer.Status = req.Status
+	}
+	customer.Message = req.Message
+	customer.Status = s.status(customer)
+	customer.UpdatedAt = s.Now()
+	return customer, s.save(previous, customer)
+}
+
+// Requirements reports the status of the customer identified by account and
+// memo, and the fields that still block acceptance.
+func (s *Service) Requirements(account, memo string) (string, []string) {
+	customer, ok := s.Customers.Get(account, memo)
+	if !ok || customer.Status == "" {
+		customer.Status = StatusNeedsInfo
+	}
+	var fields []string
+	for _, f := range s.Fields {
+		status, provided := customer.FieldStatus[f.Name]
+		if (!provided && f.Required) || status == StatusRejected || status == FieldStatusVerificationRequired {
+			fields = append(fields, f.Name)
+		}
+	}
+	return customer.Status, fields
+}
+
+func (s *Service) status(customer db.Customer) string {
+	if customer.Status == StatusRejected {
+		return StatusRejected
+	}
+	processing := false
+	for _, f := range s.Fields {
+		status, ok := customer.FieldStatus[f.Name]
+		switch {
+		case !ok:
+			if f.Required {
+				return StatusNeedsInfo
+			}
+		case status == FieldStatusVerificationRequired || status == StatusRejected:
+			return StatusNeedsInfo
+		case status == StatusProcessing:
+			processing = true
+		}
+	}
+	if processing {
+		return StatusProcessing
+	}
+	return StatusAccepted
+}
+
+// save stores the customer, emits customer_updated and queues a callback
+// when its status changed.
+func (s *Service) save(previous string, customer db.Customer) error {
+	if err := s.Customers.Put(customer); err != nil {
+		return err
+	}
+	if s.Events != nil {
+		if _, err := s.Events.Emit(events.TypeCustomerUpdated, map[string]any{"customer": s.render(customer)}); err != nil {
+			log.Printf("sep12: emit customer_updated for customer %s: %v", customer.ID, err)
+		}
+	}
+	if customer.Status != previous && customer.CallbackURL != "" && s.Callbacks != nil {
+		if err := s.Callbacks.Enqueue(customer.CallbackURL, s.render(customer)); err != nil {
+			log.Printf("sep12: queue callback for customer %s: %v", customer.ID, err)
+		}
+	}
+	return nil
+}
+
+// readForm accepts JSON, URL-encoded or multipart bodies. Binary fields are
+// only read from multipart file parts.
+func (s *Service) readForm(r *http.Request) (map[string]string, map[string]db.Blob, error) {
+	values := map[string]string{}
+	files := map[string]db.Blob{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+			return nil, nil, fmt.Errorf("invalid multipart request")
+		}
+		for name, v := range r.MultipartForm.Value {
+			if len(v) > 0 {
+				values[name] = v[0]
+			}
+		}
+		for name, headers := range r.MultipartForm.File {
+			if len(headers) == 0 {
+				continue
+			}
+			f, err := headers[0].Open()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid file %s", name)
+			}
+			data, err := io.ReadAll(io.LimitReader(f, maxUploadBytes+1))
+			f.Close()
+			if err != nil || len(data) > maxUploadBytes {
+				return nil, nil, fmt.Errorf("invalid file %s", name)
+			}
+			contentType := headers[0].Header.Get("Content-Type")
+			if contentType == "" {
+				contentType = http.DetectContentType(data)
+			}
+			files[name] = db.Blob{ContentType: contentType, Data: data}
+		}
+	case "application/x-www-form-urlencoded":
+		raw, err := io.ReadAll(io.LimitReader(r.Body, maxUploadBytes))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid form request")
+		}
+		form, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid form request")
+		}
+		for name := range form {
+			values[name] = form.Get(name)
+		}
+	default:
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxUploadBytes))
+		decoder.UseNumber()
+		var body map[string]any
+		if err := decoder.Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("invalid json request")
+		}
+		for name, value := range body {
+			switch v := value.(type) {
+			case string:
+				values[name] = v
+			case json.Number:
+				values[name] = v.String()
+			case nil:
+			default:
+				return nil, nil, fmt.Errorf("%s must be a string", name)
+			}
+		}
+	}
+	return values, files, nil
+}
+
+func cloneCustomer(customer db.Customer) db.Customer {
+	customer.Fields = cloneMap(customer.Fields)
+	customer.Files = cloneMap(customer.Files)
+	customer.FieldStatus = cloneMap(customer.FieldStatus)
+	customer.VerificationCodes = cloneMap(customer.VerificationCodes)
+	customer.Verifications = cloneMap(customer.Verifications)
+	return customer
+}
+
+func cloneMap[V any](m map[string]V) map[string]V {
+	out := make(map[string]V, len(m))
+	maps.Copy(out, m)
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func verificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,138 @@
+package sep12
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+const (
+	TypeString = "string"
+	TypeBinary = "binary"
+	TypeNumber = "number"
+	TypeDate   = "date"
+
+	FormatEmail       = "email"
+	FormatPhone       = "phone"
+	FormatCountryCode = "country_code"
+)
+
+// Field describes one SEP-9 field. The catalog mirrors
+// specs/shared/sep9-fields.json so the server runs without the specs tree.
+type Field struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Format       string `json:"format,omitempty"`
+	Required     bool   `json:"required"`
+	Verification bool   `json:"verification,omitempty"`
+}
+
+var DefaultFields = []Field{
+	{Name: "first_name", Type: TypeString, Required: true},
+	{Name: "last_name", Type: TypeString, Required: true},
+	{Name: "additional_name", Type: TypeString},
+	{Name: "email_address", Type: TypeString, Format: FormatEmail, Required: true},
+	{Name: "mobile_number", Type: TypeString, Format: FormatPhone, Verification: true},
+	{Name: "birth_date", Type: TypeDate},
+	{Name: "birth_place", Type: TypeString},
+	{Name: "birth_country_code", Type: TypeString, Format: FormatCountryCode},
+	{Name: "address", Type: TypeString},
+	{Name: "city", Type: TypeString},
+	{Name: "postal_code", Type: TypeString},
+	{Name: "state_or_province", Type: TypeString},
+	{Name: "address_country_code", Type: TypeString, Format: FormatCountryCode},
+	{Name: "bank_account_number", Type: TypeString},
+	{Name: "bank_account_type", Type: TypeString},
+	{Name: "bank_number", Type: TypeString},
+	{Name: "bank_branch_number", Type: TypeString},
+	{Name: "tax_id", Type: TypeString},
+	{Name: "tax_id_name", Type: TypeString},
+	{Name: "occupation", Type: TypeNumber},
+	{Name: "employer_name", Type: TypeString},
+	{Name: "language_code", Type: TypeString},
+	{Name: "id_type", Type: TypeString},
+	{Name: "id_country_code", Type: TypeString, Format: FormatCountryCode},
+	{Name: "id_issue_date", Type: TypeDate},
+	{Name: "id_expiration_date", Type: TypeDate},
+	{Name: "id_number", Type: TypeString},
+	{Name: "photo_id_front", Type: TypeBinary},
+	{Name: "photo_id_back", Type: TypeBinary},
+	{Name: "notary_approval_of_photo_id", Type: TypeBinary},
+	{Name: "photo_proof_residence", Type: TypeBinary},
+	{Name: "proof_of_income", Type: TypeBinary},
+	{Name: "proof_of_liveness", Type: TypeBinary},
+	{Name: "ip_address", Type: TypeString},
+	{Name: "sex", Type: TypeString},
+	{Name: "referral_id", Type: TypeString},
+}
+
+type fieldsFile struct {
+	Fields []Field `json:"fields"`
+}
+
+// LoadFields reads a SEP-9 catalog in the specs/shared/sep9-fields.json format.
+func LoadFields(path string) ([]Field, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file fieldsFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, f := range file.Fields {
+		if f.Name == "" || seen[f.Name] {
+			return nil, fmt.Errorf("%s: missing or duplicate field name %q", path, f.Name)
+		}
+		switch f.Type {
+		case TypeString, TypeBinary, TypeNumber, TypeDate:
+		default:
+			return nil, fmt.Errorf("%s: field %s has unsupported type %q", path, f.Name, f.Type)
+		}
+		seen[f.Name] = true
+	}
+	return file.Fields, nil
+}
+
+var (
+	phonePattern       = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+func validateValue(f Field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s must not be empty", f.Name)
+	}
+	switch f.Type {
+	case TypeNumber:
+		if _, err := decimal.Parse(value); err != nil {
+			return fmt.Errorf("%s must be a number", f.Name)
+		}
+	case TypeDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Name)
+		}
+	}
+	switch f.Format {
+	case FormatEmail:
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return fmt.Errorf("%s must be an email address", f.Name)
+		}
+	case FormatPhone:
+		if !phonePattern.MatchString(value) {
+			return fmt.Errorf("%s must be an E.164 phone number", f.Name)
+		}
+	case FormatCountryCode:
+		if !countryCodePattern.MatchString(value) {
+			return fmt.Errorf("%s must be an ISO 3166-1 alpha-3 code", f.Name)
+		}
+	}
+	return nil
+}
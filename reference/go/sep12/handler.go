@@ -0,0 +1,294 @@
+package sep12
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+)
+
+const (
+	StatusAccepted   = "ACCEPTED"
+	StatusProcessing = "PROCESSING"
+	StatusNeedsInfo  = "NEEDS_INFO"
+	StatusRejected   = "REJECTED"
+
+	FieldStatusVerificationRequired = "VERIFICATION_REQUIRED"
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrCustomerRejected = errors.New("customer has been rejected")
+	ErrForbidden        = errors.New("customer belongs to another account")
+)
+
+type Service struct {
+	Config    config.Config
+	Customers db.CustomerStore
+	Blobs     db.BlobStore
+	Fields    []Field
+	Callbacks *callback.Dispatcher
+	// SendVerification delivers a verification code to the customer out of
+	// band. By default it only logs that a code was issued.
+	SendVerification func(customer db.Customer, field, code string)
+	Events           *events.Publisher
+	Now              func() time.Time
+
+	// writes serializes the read-modify-write cycles of Update, Verify and
+	// Review so none overwrites a change another just stored.
+	writes sync.Mutex
+}
+
+// customerRef identifies the customer a request is about: either by id, or by
+// the authenticated account and an optional memo.
+type customerRef struct {
+	ID       string
+	Account  string
+	Memo     string
+	MemoType string
+}
+
+func NewService(cfg config.Config, customers db.CustomerStore, blobs db.BlobStore) *Service {
+	return &Service{
+		Config:    cfg,
+		Customers: customers,
+		Blobs:     blobs,
+		Fields:    DefaultFields,
+		SendVerification: func(customer db.Customer, field, _ string) {
+			log.Printf("sep12: issued a verification code for customer %s %s", customer.ID, field)
+		},
+		Now: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Service) RegisterRoutes(mux *http.ServeMux, authMiddleware func(http.Handler) http.Handler) {
+	mux.Handle("/sep12/customer", authMiddleware(http.HandlerFunc(s.handleCustomer)))
+	mux.Handle("/sep12/customer/", authMiddleware(http.HandlerFunc(s.handleDeleteCustomer)))
+	mux.Handle("/sep12/customer/verification", authMiddleware(http.HandlerFunc(s.handleVerification)))
+	mux.Handle("/sep12/customer/callback", authMiddleware(http.HandlerFunc(s.handleCallback)))
+}
+
+func (s *Service) handleCustomer(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetCustomer(w, r)
+	case http.MethodPut:
+		s.handlePutCustomer(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Service) handleGetCustomer(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ref, err := s.customerRef(r, query.Get("id"), query.Get("account"), query.Get("memo"), query.Get("memo_type"))
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer, err := s.lookup(ref)
+	if errors.Is(err, ErrCustomerNotFound) && ref.ID == "" {
+		writeJSON(w, http.StatusOK, s.render(db.Customer{Status: StatusNeedsInfo}))
+		return
+	}
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.render(customer))
+}
+
+func (s *Service) handleDeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	account := strings.TrimPrefix(r.URL.Path, "/sep12/customer/")
+	if account == "" || strings.Contains(account, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	values, _, err := s.readForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, key := range []string{"memo", "memo_type"} {
+		if values[key] == "" {
+			values[key] = r.URL.Query().Get(key)
+		}
+	}
+	ref, err := s.customerRef(r, "", account, values["memo"], values["memo_type"])
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	customer, err := s.lookup(ref)
+	if err != nil {
+		writeRefError(w, err)
+		return
+	}
+	if err := s.Delete(customer); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete customer")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Delete removes the customer and every binary field stored for it.
+func (s *Service) Delete(customer db.Customer) error {
+	for _, key := range customer.Files {
+		if err := s.Blobs.Delete(key); err != nil {
+			return err
+		}
+	}
+	return s.Customers.Delete(customer.ID)
+}
+
+// customerRef resolves the request's customer identifiers against the SEP-10
+// subject, which is either an account or account:memo.
+func (s *Service) customerRef(r *http.Request, id, account, memo, memoType string) (customerRef, error) {
+	subject := middleware.AccountFromContext(r.Context())
+	if subject == "" {
+		return customerRef{}, fmt.Errorf("%w: missing subject", ErrForbidden)
+	}
+	subjectAccount, subjectMemo, _ := strings.Cut(subject, ":")
+	if account != "" && account != subjectAccount {
+		return customerRef{}, fmt.Errorf("%w: account mismatch", ErrForbidden)
+	}
+	if subjectMemo != "" {
+		if memo != "" && memo != subjectMemo {
+			return customerRef{}, fmt.Errorf("%w: memo mismatch", ErrForbidden)
+		}
+		memo = subjectMemo
+	}
+	if memo != "" {
+		if memoType == "" {
+			memoType = "id"
+		}
+		if err := validateMemo(memo, memoType); err != nil {
+			return customerRef{}, err
+		}
+	} else {
+		memoType = ""
+	}
+	return customerRef{ID: id, Account: subjectAccount, Memo: memo, MemoType: memoType}, nil
+}
+
+func validateMemo(memo, memoType string) error {
+	switch memoType {
+	case "id":
+		if _, err := strconv.ParseUint(memo, 10, 64); err != nil {
+			return fmt.Errorf("invalid memo")
+		}
+	case "text":
+		if len(memo) > 28 {
+			return fmt.Errorf("invalid memo")
+		}
+	case "hash":
+		raw, err := hex.DecodeString(memo)
+		if err != nil || len(raw) != 32 {
+			return fmt.Errorf("invalid memo")
+		}
+	default:
+		return fmt.Errorf("invalid memo_type")
+	}
+	return nil
+}
+
+func (s *Service) lookup(ref customerRef) (db.Customer, error) {
+	if ref.ID != "" {
+		customer, ok := s.Customers.GetByID(ref.ID)
+		if !ok || customer.Account != ref.Account || (ref.Memo != "" && customer.Memo != ref.Memo) {
+			return db.Customer{}, ErrCustomerNotFound
+		}
+		return customer, nil
+	}
+	customer, ok := s.Customers.Get(ref.Account, ref.Memo)
+	if !ok {
+		return db.Customer{}, ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+type fieldInfo struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// render builds the GET /customer body. Unprovided fields are only listed
+// while the customer still needs to supply information.
+func (s *Service) render(customer db.Customer) map[string]any {
+	out := map[string]any{"status": customer.Status}
+	if customer.ID != "" {
+		out["id"] = customer.ID
+	}
+	if customer.Message != "" {
+		out["message"] = customer.Message
+	}
+	missing := map[string]fieldInfo{}
+	provided := map[string]fieldInfo{}
+	for _, f := range s.Fields {
+		info := fieldInfo{Type: f.Type, Description: strings.ReplaceAll(f.Name, "_", " "), Optional: !f.Required}
+		status, ok := customer.FieldStatus[f.Name]
+		if !ok {
+			if customer.Status == StatusNeedsInfo {
+				missing[f.Name] = info
+			}
+			continue
+		}
+		info.Status = status
+		provided[f.Name] = info
+	}
+	if len(missing) > 0 {
+		out["fields"] = missing
+	}
+	if len(provided) > 0 {
+		out["provided_fields"] = provided
+	}
+	return out
+}
+
+func newCustomerID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+func writeRefError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrCustomerNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
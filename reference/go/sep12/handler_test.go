@@ -0,0 +1,341 @@
+package sep12
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/sep10"
+)
+
+const testAdminKey = "admin-key"
+
+func TestDefaultFieldsMatchSpec(t *testing.T) {
+	fields, err := LoadFields(filepath.Join("..", "..", "..", "specs", "shared", "sep9-fields.json"))
+	if err != nil {
+		t.Fatalf("load spec fields: %v", err)
+	}
+	if !reflect.DeepEqual(fields, DefaultFields) {
+		t.Fatalf("DefaultFields is out of sync with specs/shared/sep9-fields.json")
+	}
+}
+
+func TestCustomerLifecycle(t *testing.T) {
+	service, mux := testServiceAndMux()
+	codes := map[string]string{}
+	service.SendVerification = func(_ db.Customer, field, code string) { codes[field] = code }
+	account := keypair.MustRandom().Address()
+	token := testToken(t, account)
+
+	customer := getCustomer(t, mux, "", token)
+	if customer["status"] != StatusNeedsInfo || customer["id"] != nil {
+		t.Fatalf("unexpected unknown customer: %+v", customer)
+	}
+	if field := customer["fields"].(map[string]any)["first_name"].(map[string]any); field["optional"] != nil {
+		t.Fatalf("expected first_name to be required: %+v", field)
+	}
+
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{
+		"first_name":    "Jane",
+		"last_name":     "Doe",
+		"email_address": "jane@example.com",
+	}, http.StatusAccepted, &created)
+	id := created["id"]
+
+	customer = getCustomer(t, mux, "?id="+id, token)
+	if customer["status"] != StatusAccepted || customer["fields"] != nil {
+		t.Fatalf("expected accepted customer: %+v", customer)
+	}
+
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"id": id, "mobile_number": "+14155550100"}, http.StatusAccepted, &created)
+	customer = getCustomer(t, mux, "", token)
+	provided := customer["provided_fields"].(map[string]any)
+	if customer["status"] != StatusNeedsInfo || provided["mobile_number"].(map[string]any)["status"] != FieldStatusVerificationRequired {
+		t.Fatalf("expected mobile number to need verification: %+v", customer)
+	}
+
+	var verified map[string]any
+	doJSON(t, mux, http.MethodPut, "/sep12/customer/verification", token, map[string]string{"id": id, "mobile_number_verification": "bad"}, http.StatusBadRequest, nil)
+	doJSON(t, mux, http.MethodPut, "/sep12/customer/verification", token, map[string]string{"id": id, "mobile_number_verification": codes["mobile_number"]}, http.StatusOK, &verified)
+	if verified["status"] != StatusAccepted || verified["fields"] != nil {
+		t.Fatalf("expected verified customer to be accepted: %+v", verified)
+	}
+
+	other := testToken(t, keypair.MustRandom().Address())
+	doJSON(t, mux, http.MethodGet, "/sep12/customer?id="+id, other, nil, http.StatusNotFound, nil)
+	doJSON(t, mux, http.MethodDelete, "/sep12/customer/"+account, other, nil, http.StatusForbidden, nil)
+
+	doJSON(t, mux, http.MethodDelete, "/sep12/customer/"+account, token, nil, http.StatusOK, nil)
+	doJSON(t, mux, http.MethodGet, "/sep12/customer?id="+id, token, nil, http.StatusNotFound, nil)
+}
+
+func TestVerificationCodesExpireAndLockOut(t *testing.T) {
+	service, mux := testServiceAndMux()
+	now := time.Now().UTC()
+	service.Now = func() time.Time { return now }
+	codes := map[string]string{}
+	service.SendVerification = func(_ db.Customer, field, code string) { codes[field] = code }
+	token := testToken(t, keypair.MustRandom().Address())
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"mobile_number": "+14155550100"}, http.StatusAccepted, &created)
+	verify := func(code string, want int) {
+		t.Helper()
+		doJSON(t, mux, http.MethodPut, "/sep12/customer/verification", token, map[string]string{"id": created["id"], "mobile_number_verification": code}, want, nil)
+	}
+
+	for range maxVerificationAttempts {
+		verify("bad", http.StatusBadRequest)
+	}
+	verify(codes["mobile_number"], http.StatusBadRequest)
+
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"id": created["id"], "mobile_number": "+14155550100"}, http.StatusAccepted, nil)
+	now = now.Add(verificationCodeTTL)
+	verify(codes["mobile_number"], http.StatusBadRequest)
+
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"id": created["id"], "mobile_number": "+14155550100"}, http.StatusAccepted, nil)
+	verify("bad", http.StatusBadRequest)
+	verify(codes["mobile_number"], http.StatusOK)
+}
+
+func TestUpdateFromStaleReadKeepsVerification(t *testing.T) {
+	service, mux := testServiceAndMux()
+	codes := map[string]string{}
+	service.SendVerification = func(_ db.Customer, field, code string) { codes[field] = code }
+	token := testToken(t, keypair.MustRandom().Address())
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"mobile_number": "+14155550100"}, http.StatusAccepted, &created)
+
+	stale, _ := service.Customers.GetByID(created["id"])
+	if _, err := service.Verify(stale, map[string]string{"mobile_number": codes["mobile_number"]}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	updated, err := service.Update(stale, map[string]string{"first_name": "Jane"}, nil)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.FieldStatus["mobile_number"] != StatusAccepted || updated.VerificationCodes["mobile_number"] != "" {
+		t.Fatalf("expected the update to keep the verified field, got %+v", updated.FieldStatus)
+	}
+	if stored, _ := service.Customers.GetByID(created["id"]); stored.FieldStatus["mobile_number"] != StatusAccepted || stored.Fields["first_name"] != "Jane" {
+		t.Fatalf("expected the stored customer to carry both writes, got %+v", stored.FieldStatus)
+	}
+}
+
+func TestPutCustomerValidatesFields(t *testing.T) {
+	_, mux := testServiceAndMux()
+	account := keypair.MustRandom().Address()
+	token := testToken(t, account)
+
+	for _, body := range []map[string]string{
+		{"favourite_colour": "blue"},
+		{"email_address": "not an email"},
+		{"birth_date": "31/12/1990"},
+		{"address_country_code": "US"},
+		{"photo_id_front": "aGVsbG8="},
+		{"memo": "abc", "first_name": "Jane"},
+	} {
+		doJSON(t, mux, http.MethodPut, "/sep12/customer", token, body, http.StatusBadRequest, nil)
+	}
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"account": keypair.MustRandom().Address(), "first_name": "Jane"}, http.StatusForbidden, nil)
+
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"memo": "42", "first_name": "Memo"}, http.StatusAccepted, &created)
+	if customer := getCustomer(t, mux, "", token); customer["id"] != nil {
+		t.Fatalf("expected memo customer to be separate from the account: %+v", customer)
+	}
+	if customer := getCustomer(t, mux, "?memo=42", token); customer["id"] != created["id"] {
+		t.Fatalf("expected memo lookup to find %s: %+v", created["id"], customer)
+	}
+}
+
+func TestBinaryFieldsAwaitReviewAndNotifyCallback(t *testing.T) {
+	service, mux := testServiceAndMux()
+	account := keypair.MustRandom().Address()
+	token := testToken(t, account)
+
+	signer := keypair.MustRandom()
+	service.Callbacks, _ = callback.NewDispatcher(signer.Seed(), callback.NewMemoryDeadLetters())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.Callbacks.Run(ctx)
+
+	callbacks := make(chan map[string]any, 4)
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		if err := callback.Verify(signer.Address(), r.Header.Get("Signature"), r.Host, raw); err != nil {
+			t.Errorf("verify callback signature: %v", err)
+		}
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		callbacks <- body
+	}))
+	defer callbackServer.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range map[string]string{"first_name": "Jane", "last_name": "Doe", "email_address": "jane@example.com"} {
+		_ = form.WriteField(name, value)
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="photo_id_front"; filename="front.png"`)
+	header.Set("Content-Type", "image/png")
+	part, _ := form.CreatePart(header)
+	_, _ = part.Write([]byte("png-bytes"))
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/sep12/customer", &body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var created map[string]string
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+
+	stored, ok := service.Customers.GetByID(created["id"])
+	if !ok || stored.Status != StatusProcessing {
+		t.Fatalf("expected customer to await review: %+v", stored)
+	}
+	blob, ok := service.Blobs.Get(stored.Files["photo_id_front"])
+	if !ok || string(blob.Data) != "png-bytes" || blob.ContentType != "image/png" {
+		t.Fatalf("unexpected blob: %+v", blob)
+	}
+
+	doJSON(t, mux, http.MethodPut, "/sep12/customer/callback", token, map[string]string{"url": "ftp://example.com"}, http.StatusBadRequest, nil)
+	doJSON(t, mux, http.MethodPut, "/sep12/customer/callback", token, map[string]string{"id": created["id"], "url": callbackServer.URL}, http.StatusOK, nil)
+
+	review := ReviewRequest{ID: created["id"], Fields: map[string]string{"photo_id_front": StatusAccepted}}
+	adminReq := httptest.NewRequest(http.MethodPut, "/admin/sep12/customer", bytes.NewReader(mustJSON(t, review)))
+	adminReq.Header.Set("Authorization", "Bearer "+testAdminKey)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminReq)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected review to succeed, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case got := <-callbacks:
+		if got["id"] != created["id"] || got["status"] != StatusAccepted {
+			t.Fatalf("unexpected callback body: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected status change callback")
+	}
+
+	doJSON(t, mux, http.MethodDelete, "/sep12/customer/"+account, token, nil, http.StatusOK, nil)
+	if _, ok := service.Blobs.Get(stored.Files["photo_id_front"]); ok {
+		t.Fatalf("expected blob to be deleted with the customer")
+	}
+}
+
+func TestRejectedFieldNeedsReviewAgain(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, keypair.MustRandom().Address())
+	fields := map[string]string{"first_name": "Jane", "last_name": "Doe", "email_address": "jane@example.com"}
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, fields, http.StatusAccepted, &created)
+	if _, err := service.Review(ReviewRequest{ID: created["id"], Fields: map[string]string{"last_name": StatusRejected}}); err != nil {
+		t.Fatalf("review: %v", err)
+	}
+
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{"id": created["id"], "last_name": "Doe"}, http.StatusAccepted, nil)
+	customer := getCustomer(t, mux, "?id="+created["id"], token)
+	provided := customer["provided_fields"].(map[string]any)
+	if customer["status"] != StatusProcessing || provided["last_name"].(map[string]any)["status"] != StatusProcessing {
+		t.Fatalf("expected the resubmitted field to await review, got %+v", customer)
+	}
+}
+
+func TestAdminExportRedactsFields(t *testing.T) {
+	_, mux := testServiceAndMux()
+	token := testToken(t, keypair.MustRandom().Address())
+	var created map[string]string
+	doJSON(t, mux, http.MethodPut, "/sep12/customer", token, map[string]string{
+		"first_name":    "Jane",
+		"email_address": "jane@example.com",
+	}, http.StatusAccepted, &created)
+
+	var exported db.Customer
+	doJSON(t, mux, http.MethodGet, "/admin/sep12/customer?id="+created["id"], testAdminKey, nil, http.StatusOK, &exported)
+	if exported.ID != created["id"] || exported.Fields["first_name"] != db.RedactedValue || exported.Fields["email_address"] != db.RedactedValue {
+		t.Fatalf("expected redacted export, got %+v", exported)
+	}
+	doJSON(t, mux, http.MethodGet, "/admin/sep12/customer?id=missing", testAdminKey, nil, http.StatusNotFound, nil)
+	doJSON(t, mux, http.MethodGet, "/admin/sep12/customer?id="+created["id"], token, nil, http.StatusForbidden, nil)
+}
+
+func doJSON(t *testing.T, mux *http.ServeMux, method, path, token string, body any, want int, out any) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(mustJSON(t, body))
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d body=%s", method, path, want, rec.Code, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+}
+
+func getCustomer(t *testing.T, mux *http.ServeMux, query, token string) map[string]any {
+	t.Helper()
+	var customer map[string]any
+	doJSON(t, mux, http.MethodGet, "/sep12/customer"+query, token, nil, http.StatusOK, &customer)
+	return customer
+}
+
+func mustJSON(t *testing.T, value any) []byte {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return raw
+}
+
+func testToken(t *testing.T, account string) string {
+	t.Helper()
+	token, err := sep10.IssueToken(account, "localhost:8080", "", "localhost:8080", "jwt-secret", time.Now().UTC(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func testServiceAndMux() (*Service, *http.ServeMux) {
+	cfg := config.Config{HomeDomain: "localhost:8080", JWTSecret: "jwt-secret"}
+	service := NewService(cfg, db.NewMemoryCustomerStore(), db.NewMemoryBlobStore())
+	service.SendVerification = func(db.Customer, string, string) {}
+
+	mux := http.NewServeMux()
+	service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	service.RegisterAdminRoutes(mux, middleware.AdminAuth(testAdminKey))
+	return service, mux
+}
@@ -0,0 +1,63 @@
+package sep24
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// parseCallbackURL validates on_change_callback. "postMessage" asks for
+// browser notifications from the interactive popup, so nothing is stored.
+func parseCallbackURL(raw string) (string, error) {
+	if raw == "" || raw == "postMessage" {
+		return "", nil
+	}
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("invalid on_change_callback")
+	}
+	return target.String(), nil
+}
+
+// update stores tx and, when its status changed, queues the wallet's
+// on_change_callback. It fails with db.ErrConflict if tx was changed since
+// it was read.
+func (s *Service) update(tx db.Transaction, audit db.Audit) (db.Transaction, error) {
+	previous, _ := s.TxStore.GetByID(tx.ID)
+	updated, err := s.TxStore.Update(tx, audit)
+	if err != nil {
+		return tx, err
+	}
+	if previous.Status != updated.Status {
+		s.Notify(updated)
+	}
+	return updated, nil
+}
+
+// Notify reports a status change of a SEP-24 transaction to the business
+// server and to the wallet's on_change_callback.
+func (s *Service) Notify(tx db.Transaction) {
+	if tx.Protocol != transfer.ProtocolSEP24 {
+		return
+	}
+	s.emit(events.TypeTransactionStatusChanged, tx)
+	if s.Callbacks == nil || tx.CallbackURL == "" {
+		return
+	}
+	if err := s.Callbacks.Enqueue(tx.CallbackURL, map[string]any{"transaction": s.toSEP24Transaction(tx)}); err != nil {
+		log.Printf("sep24: queue callback for transaction %s: %v", tx.ID, err)
+	}
+}
+
+func (s *Service) emit(eventType string, tx db.Transaction) {
+	if s.Events == nil {
+		return
+	}
+	if _, err := s.Events.Emit(eventType, map[string]any{"transaction": s.toSEP24Transaction(tx)}); err != nil {
+		log.Printf("sep24: emit %s for transaction %s: %v", eventType, tx.ID, err)
+	}
+}
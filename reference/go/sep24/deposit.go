@@ -2,11 +2,15 @@ package sep24
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/stellar/go/xdr"
 	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
 )
 
 func (s *Service) handleDepositInteractive(w http.ResponseWriter, r *http.Request) {
@@ -41,39 +45,98 @@ func (s *Service) handleDepositInteractive(w http.ResponseWriter, r *http.Reques
 		writeError(w, http.StatusBadRequest, "missing asset_code")
 		return
 	}
-	if !s.assetSupported(req.AssetCode) {
+	asset, ok := s.operationAsset(req.AssetCode, req.AssetIssuer, "deposit")
+	if !ok {
 		writeError(w, http.StatusBadRequest, "unsupported asset")
 		return
 	}
+	id, err := s.interactiveID(r, account, "deposit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	requestHash := interactiveRequestHash(req, account, asset)
+	if s.replayInteractive(w, id, requestHash) {
+		return
+	}
+
+	callbackURL, err := parseCallbackURL(req.OnChangeCallback)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	amount, err := parseAmount(asset, req.Amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+	if req.QuoteID == "" {
+		if err := s.checkAmountLimits(asset, "deposit", amount); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	now := s.Now()
-	id := transactionID("dep", account, req.AssetCode, now)
 	url := fmt.Sprintf("http://%s/sep24/interactive/deposit?id=%s", s.Config.HomeDomain, id)
 	tx := db.Transaction{
-		ID:        id,
-		Kind:      "deposit",
-		Status:    StatusIncomplete,
-		Account:   account,
-		To:        account,
-		AssetCode: req.AssetCode,
-		Amount:    req.Amount,
-		URL:       url,
-		StartedAt: now,
-		UpdatedAt: now,
-		KYCFields: []string{"first_name", "last_name", "email_address"},
-	}
-	if err := s.TxStore.Create(tx); err != nil {
+		ID:                        id,
+		Protocol:                  transfer.ProtocolSEP24,
+		Kind:                      "deposit",
+		Status:                    StatusIncomplete,
+		Account:                   account,
+		To:                        account,
+		AssetCode:                 asset.Code,
+		AssetIssuer:               asset.Issuer,
+		Amount:                    amount,
+		URL:                       url,
+		StartedAt:                 now,
+		UpdatedAt:                 now,
+		UserActionRequiredBy:      s.actionDeadline(StatusIncomplete, now),
+		CallbackURL:               callbackURL,
+		FundingMethod:             req.Type,
+		RequestHash:               requestHash,
+		KYCFields:                 []string{"first_name", "last_name", "email_address"},
+		ClaimableBalanceSupported: bool(req.ClaimableBalanceSupported),
+	}
+	if req.QuoteID != "" {
+		if err := s.applyQuote(&tx, req, asset); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if err := s.applyFee(&tx, fees.OperationDeposit); err != nil {
+		writeError(w, http.StatusBadRequest, transfer.FeeErrorMessage(err))
+		return
+	}
+	err = s.createTransaction(tx, db.Audit{Actor: account, Source: db.SourceAPI, Reason: "interactive deposit started"})
+	switch {
+	case errors.Is(err, ErrInvalidQuote):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, db.ErrDuplicate) && s.replayInteractive(w, id, requestHash):
+		return
+	case err != nil:
 		writeError(w, http.StatusInternalServerError, "failed to create transaction")
 		return
 	}
+	s.emit(events.TypeTransactionCreated, tx)
 
 	if s.CustomerStore != nil {
-		_ = s.CustomerStore.Put(db.Customer{Account: account, Fields: map[string]string{}})
+		if _, ok := s.CustomerStore.Get(account, ""); !ok {
+			_ = s.CustomerStore.Put(db.Customer{
+				ID:        s.NewID(),
+				Account:   account,
+				Status:    "NEEDS_INFO",
+				Fields:    map[string]string{},
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
 	}
 
 	writeJSON(w, http.StatusOK, InteractiveResponse{
 		ID:   id,
 		Type: "interactive_customer_info_needed",
-		URL:  url,
+		URL:  s.interactiveURL(tx),
 	})
 }
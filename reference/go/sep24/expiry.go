@@ -0,0 +1,86 @@
+package sep24
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// expiryBatchSize bounds how many transactions the sweeper loads at once.
+const expiryBatchSize = 100
+
+func (s *Service) actionDeadline(status string, from time.Time) time.Time {
+	timeout, ok := s.Config.ExpireAfter[status]
+	if !ok || timeout <= 0 {
+		return time.Time{}
+	}
+	return from.Add(timeout)
+}
+
+func (s *Service) ExpireStale(ctx context.Context) []db.Transaction {
+	now := s.Now()
+	expired := make([]db.Transaction, 0)
+	for status, timeout := range s.Config.ExpireAfter {
+		if timeout <= 0 {
+			continue
+		}
+		for {
+			batch := s.TxStore.ListByStatus(db.StatusQuery{Status: status, Protocol: transfer.ProtocolSEP24, Limit: expiryBatchSize})
+			moved := 0
+			for _, tx := range batch {
+				if ctx.Err() != nil {
+					return expired
+				}
+				if updated, ok := s.expire(tx, timeout, now); ok {
+					expired = append(expired, updated)
+					moved++
+				}
+			}
+			if len(batch) < expiryBatchSize || moved < len(batch) {
+				break
+			}
+		}
+	}
+	return expired
+}
+
+func (s *Service) expire(tx db.Transaction, timeout time.Duration, now time.Time) (db.Transaction, bool) {
+	deadline := tx.UserActionRequiredBy
+	if deadline.IsZero() {
+		deadline = tx.UpdatedAt.Add(timeout)
+	}
+	if now.Before(deadline) {
+		return tx, false
+	}
+	if err := ValidateTransition(tx.Status, StatusExpired); err != nil {
+		log.Printf("sep24: cannot expire transaction %s: %v", tx.ID, err)
+		return tx, false
+	}
+	tx.Status = StatusExpired
+	tx.UpdatedAt = now
+	tx.UserActionRequiredBy = deadline
+	updated, err := s.update(tx, db.Audit{Source: db.SourceSweeper, Reason: "user action deadline passed"})
+	if err != nil {
+		log.Printf("sep24: expire transaction %s: %v", tx.ID, err)
+		return tx, false
+	}
+	return updated, true
+}
+
+func (s *Service) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if expired := s.ExpireStale(ctx); len(expired) > 0 {
+				log.Printf("sep24: expired %d stale transactions", len(expired))
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sep24
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+func (s *Service) handleGetFee(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	operation := r.URL.Query().Get("operation")
+	assetCode := r.URL.Query().Get("asset_code")
+	amountRaw := r.URL.Query().Get("amount")
+	if operation == "" || assetCode == "" || amountRaw == "" {
+		writeError(w, http.StatusBadRequest, "missing required query params")
+		return
+	}
+	if operation != fees.OperationDeposit && operation != fees.OperationWithdraw {
+		writeError(w, http.StatusBadRequest, "invalid operation")
+		return
+	}
+	asset, ok := s.operationAsset(assetCode, r.URL.Query().Get("asset_issuer"), operation)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	amount, err := decimal.Parse(amountRaw)
+	if err != nil || amount.Sign() < 0 {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+
+	result, err := s.Fees.Calculate(fees.Request{
+		Operation:    operation,
+		Type:         r.URL.Query().Get("type"),
+		CustomerType: r.URL.Query().Get("customer_type"),
+		Asset:        asset.ID(),
+		Amount:       amount,
+	})
+	if errors.Is(err, fees.ErrNoMatchingRule) {
+		writeError(w, http.StatusBadRequest, "fee is not available for this request")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to calculate fee")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"fee": s.formatAmount(asset, result.Total)})
+}
+
+// applyFee prices tx the way /fee quotes it.
+func (s *Service) applyFee(tx *db.Transaction, operation string) error {
+	return transfer.ApplyFee(s.Fees, tx, fees.Request{
+		Operation:    operation,
+		Type:         tx.FundingMethod,
+		CustomerType: s.customerType(tx.Account),
+		Asset:        s.txAsset(*tx).ID(),
+	})
+}
+
+func (s *Service) customerType(account string) string {
+	if s.CustomerStore == nil {
+		return ""
+	}
+	customer, _ := s.CustomerStore.Get(account, "")
+	return customer.Type
+}
+
+func (s *Service) renderFeeDetails(asset config.Asset, details db.FeeDetails) map[string]any {
+	return transfer.RenderFeeDetails(asset, details, s.Config.RoundingMode)
+}
@@ -2,30 +2,78 @@ package sep24
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
 	"github.com/stellar/sep-reference/reference/go/internal/config"
 	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
 	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/submitter"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
 )
 
 type Service struct {
 	Config        config.Config
 	TxStore       db.TransactionStore
 	CustomerStore db.CustomerStore
+	Memos         memo.Allocator
+	Payments      *submitter.Submitter
+	Fees          fees.Calculator
+	Quotes        db.QuoteStore
+	Callbacks     *callback.Dispatcher
+	Events        *events.Publisher
 	Now           func() time.Time
+	NewID         func() string
+	// PlatformPayouts leaves starting deposit payouts to the platform API.
+	PlatformPayouts bool
+
+	payouts sync.Mutex
 }
 
 type InteractiveRequest struct {
-	AssetCode string `json:"asset_code"`
-	Account   string `json:"account"`
-	Amount    string `json:"amount"`
-	Lang      string `json:"lang,omitempty"`
+	AssetCode                 string   `json:"asset_code"`
+	AssetIssuer               string   `json:"asset_issuer,omitempty"`
+	Account                   string   `json:"account"`
+	Amount                    string   `json:"amount"`
+	Lang                      string   `json:"lang,omitempty"`
+	Type                      string   `json:"type,omitempty"`
+	QuoteID                   string   `json:"quote_id,omitempty"`
+	SourceAsset               string   `json:"source_asset,omitempty"`
+	DestinationAsset          string   `json:"destination_asset,omitempty"`
+	ClaimableBalanceSupported flexBool `json:"claimable_balance_supported,omitempty"`
+	OnChangeCallback          string   `json:"on_change_callback,omitempty"`
+}
+
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(raw []byte) error {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(strings.EqualFold(strings.TrimSpace(v), "true"))
+	case nil:
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean value %s", string(raw))
+	}
+	return nil
 }
 
 type InteractiveResponse struct {
@@ -39,7 +87,10 @@ func NewService(cfg config.Config, txStore db.TransactionStore, customerStore db
 		Config:        cfg,
 		TxStore:       txStore,
 		CustomerStore: customerStore,
+		Memos:         transfer.NewMemoAllocator(cfg, txStore),
+		Fees:          fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, fees.RulesFromAssets(cfg.Assets)),
 		Now:           func() time.Time { return time.Now().UTC() },
+		NewID:         transfer.NewUUID,
 	}
 }
 
@@ -71,6 +122,16 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
 
+// writeUpdateError reports a failed store update; a lost optimistic
+// concurrency race is a 409 the client can retry.
+func writeUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, db.ErrConflict) {
+		writeError(w, http.StatusConflict, "transaction was modified concurrently, retry the request")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "failed to update transaction")
+}
+
 func parseTemplate(path string, fallback string) (*template.Template, error) {
 	if _, err := os.Stat(path); err == nil {
 		return template.ParseFiles(path)
@@ -78,22 +139,109 @@ func parseTemplate(path string, fallback string) (*template.Template, error) {
 	return template.New("fallback").Parse(fallback)
 }
 
+const interactiveFallback = `<html><body><h1>{{ .Title }}</h1>
+{{ if .Error }}<p class="error">{{ .Error }}</p>{{ end }}
+<form method="post"><input type="hidden" name="id" value="{{ .ID }}" /><input type="hidden" name="token" value="{{ .Token }}" />
+<label>Amount <input type="number" name="amount" step="any" value="{{ .Amount }}"{{ if .MinAmount }} min="{{ .MinAmount }}"{{ end }}{{ if .MaxAmount }} max="{{ .MaxAmount }}"{{ end }} /></label>
+<button type="submit">Continue</button></form></body></html>`
+
 func (s *Service) renderDeposit(w http.ResponseWriter, r *http.Request) {
-	tpl, err := parseTemplate(filepath.FromSlash("sep24/interactive/templates/deposit.html"), "<html><body><h1>Deposit</h1></body></html>")
+	s.renderInteractive(w, r, "deposit", "Deposit")
+}
+
+func (s *Service) renderWithdraw(w http.ResponseWriter, r *http.Request) {
+	s.renderInteractive(w, r, "withdraw", "Withdraw")
+}
+
+func (s *Service) renderInteractive(w http.ResponseWriter, r *http.Request, kind, title string) {
+	tpl, err := parseTemplate(filepath.FromSlash("sep24/interactive/templates/"+kind+".html"), interactiveFallback)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("template error: %v", err))
 		return
 	}
-	_ = tpl.Execute(w, map[string]string{"Title": "Deposit"})
-}
 
-func (s *Service) renderWithdraw(w http.ResponseWriter, r *http.Request) {
-	tpl, err := parseTemplate(filepath.FromSlash("sep24/interactive/templates/withdraw.html"), "<html><body><h1>Withdraw</h1></body></html>")
+	id, token := r.URL.Query().Get("id"), r.URL.Query().Get("token")
+	if r.Method == http.MethodPost {
+		id, token = r.PostFormValue("id"), r.PostFormValue("token")
+	}
+	data := map[string]string{"Title": title, "ID": id, "Token": token}
+	if err := s.verifyInteractiveToken(id, token); err != nil {
+		data["Error"] = err.Error()
+		w.WriteHeader(http.StatusForbidden)
+		_ = tpl.Execute(w, data)
+		return
+	}
+	tx, ok := s.TxStore.GetByID(id)
+	if !ok || tx.Kind != kind || tx.Protocol != transfer.ProtocolSEP24 {
+		_ = tpl.Execute(w, data)
+		return
+	}
+	asset := s.txAsset(tx)
+	data["AssetCode"] = tx.AssetCode
+	if !tx.Amount.IsZero() {
+		data["Amount"] = s.formatAmount(asset, tx.Amount)
+	}
+	if asset.Enabled {
+		limits := asset.Operation(kind)
+		if !limits.MinAmount.IsZero() {
+			data["MinAmount"] = limits.MinAmount.String()
+		}
+		if !limits.MaxAmount.IsZero() {
+			data["MaxAmount"] = limits.MaxAmount.String()
+		}
+	}
+
+	if r.Method != http.MethodPost {
+		_ = tpl.Execute(w, data)
+		return
+	}
+	if tx.Status != StatusIncomplete {
+		data["Error"] = "transaction is no longer awaiting input"
+		w.WriteHeader(http.StatusConflict)
+		_ = tpl.Execute(w, data)
+		return
+	}
+
+	if tx.QuoteID != "" {
+		// Amounts were fixed by the quote when the transaction was created.
+		if _, err := s.transition(tx, StatusPendingUserTransferStart, interactiveAudit(tx)); err != nil {
+			writeUpdateError(w, err)
+			return
+		}
+		http.Redirect(w, r, "/sep24/interactive/status?id="+url.QueryEscape(tx.ID), http.StatusSeeOther)
+		return
+	}
+
+	data["Amount"] = r.PostFormValue("amount")
+	amount, err := parseAmount(asset, data["Amount"])
+	if err == nil && amount.IsZero() {
+		err = fmt.Errorf("amount is required")
+	}
+	if err == nil {
+		err = s.checkAmountLimits(asset, kind, amount)
+	}
+	if err == nil {
+		tx.Amount = amount
+		if method := r.PostFormValue("type"); method != "" {
+			tx.FundingMethod = method
+		}
+		err = s.applyFee(&tx, kind)
+	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, fmt.Sprintf("template error: %v", err))
+		data["Error"] = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		_ = tpl.Execute(w, data)
+		return
+	}
+	if _, err := s.transition(tx, StatusPendingUserTransferStart, interactiveAudit(tx)); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
-	_ = tpl.Execute(w, map[string]string{"Title": "Withdraw"})
+	http.Redirect(w, r, "/sep24/interactive/status?id="+url.QueryEscape(tx.ID), http.StatusSeeOther)
+}
+
+func interactiveAudit(tx db.Transaction) db.Audit {
+	return db.Audit{Actor: tx.Account, Source: db.SourceAPI, Reason: "interactive flow completed"}
 }
 
 func (s *Service) renderStatus(w http.ResponseWriter, r *http.Request) {
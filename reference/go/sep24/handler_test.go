@@ -2,16 +2,35 @@ package sep24
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
 	"github.com/stellar/sep-reference/reference/go/internal/config"
 	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
 	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/observer"
+	"github.com/stellar/sep-reference/reference/go/internal/submitter"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
 	"github.com/stellar/sep-reference/reference/go/sep10"
+	"gopkg.in/yaml.v3"
 )
 
 func TestTransitionValidation(t *testing.T) {
@@ -69,12 +88,1383 @@ func TestDepositAndGetTransaction(t *testing.T) {
 	}
 }
 
+func TestInteractiveIdempotencyKey(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Config.Assets = append(service.Config.Assets, config.Asset{Code: "EURC", Enabled: true, SignificantDecimals: 2})
+	frozen := time.Now().UTC()
+	service.Now = func() time.Time { return frozen }
+	user := keypair.MustRandom().Address()
+	send := func(path, account, key string, request InteractiveRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(request)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+testToken(t, account))
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	post := func(path, account, key, asset string) (int, InteractiveResponse) {
+		rec := send(path, account, key, InteractiveRequest{AssetCode: asset, Amount: "100"})
+		var body InteractiveResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+	const deposit = "/sep24/transactions/deposit/interactive"
+
+	// Without a key every request is a new transaction, even at the same instant.
+	_, first := post(deposit, user, "", "USDC")
+	_, second := post(deposit, user, "", "USDC")
+	if first.ID == "" || first.ID == second.ID {
+		t.Fatalf("expected distinct ids for requests in the same nanosecond, got %q and %q", first.ID, second.ID)
+	}
+
+	code, original := post(deposit, user, "retry-1", "USDC")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	code, retried := post(deposit, user, "retry-1", "USDC")
+	if code != http.StatusOK || retried != original {
+		t.Fatalf("expected a retry to return the original transaction %+v, got %d %+v", original, code, retried)
+	}
+	if got := service.TxStore.ListByAccount(db.TransactionQuery{Account: user}); len(got) != 3 {
+		t.Fatalf("expected the retry not to create a transaction, got %d", len(got))
+	}
+	if code, _ := post(deposit, user, "retry-1", "EURC"); code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a key reused for another asset to be rejected, got %d", code)
+	}
+	for _, changed := range []InteractiveRequest{
+		{AssetCode: "USDC", Amount: "200"},
+		{AssetCode: "USDC", Amount: "100", OnChangeCallback: "https://wallet.example.com/callback"},
+		{AssetCode: "USDC", Amount: "100", Type: "SEPA"},
+	} {
+		if rec := send(deposit, user, "retry-1", changed); rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected a key reused for %+v to be rejected, got %d", changed, rec.Code)
+		}
+	}
+	if rec := send(deposit, user, "retry-1", InteractiveRequest{AssetCode: "USDC", Amount: "100", Account: user}); rec.Code != http.StatusOK {
+		t.Fatalf("expected naming the authenticated account to be the same request, got %d", rec.Code)
+	}
+	if _, err := service.TxStore.UpdateStatus(original.ID, StatusIncomplete, StatusPendingUserTransferStart, frozen, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	rec := send(deposit, user, "retry-1", InteractiveRequest{AssetCode: "USDC", Amount: "100"})
+	var advanced struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &advanced); err != nil || rec.Code != http.StatusOK ||
+		advanced.Transaction["id"] != original.ID || advanced.Transaction["status"] != StatusPendingUserTransferStart {
+		t.Fatalf("expected a retry after the flow to return the transaction as it stands, got %d %s", rec.Code, rec.Body.String())
+	}
+	if _, withdrawal := post("/sep24/transactions/withdraw/interactive", user, "retry-1", "USDC"); withdrawal.ID == "" || withdrawal.ID == original.ID {
+		t.Fatalf("expected the key to be scoped to the operation, got %+v", withdrawal)
+	}
+	if _, other := post(deposit, keypair.MustRandom().Address(), "retry-1", "USDC"); other.ID == "" || other.ID == original.ID {
+		t.Fatalf("expected the key to be scoped to the account, got %+v", other)
+	}
+	if code, _ := post(deposit, user, strings.Repeat("k", 256), "USDC"); code != http.StatusBadRequest {
+		t.Fatalf("expected an oversized key to be rejected, got %d", code)
+	}
+}
+
+func TestListTransactionsPaging(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []db.Transaction{
+		{ID: "tx-a", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start},
+		{ID: "tx-b", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "withdraw", AssetCode: "USDC", StartedAt: start.Add(time.Minute)},
+		{ID: "tx-c", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(time.Minute)},
+		{ID: "tx-d", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(2 * time.Minute)},
+		{ID: "tx-e", Account: testAccount, Protocol: transfer.ProtocolSEP6, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(3 * time.Minute)},
+		{ID: "tx-f", Account: "GOTHER", Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(3 * time.Minute)},
+	}
+	for _, tx := range seed {
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+			t.Fatalf("create transaction: %v", err)
+		}
+	}
+
+	list := func(query string) (int, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/sep24/transactions?asset_code=USDC&"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var body struct {
+			Transactions []map[string]any `json:"transactions"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		ids := make([]string, 0, len(body.Transactions))
+		for _, tx := range body.Transactions {
+			ids = append(ids, tx["id"].(string))
+		}
+		return rec.Code, ids
+	}
+
+	var pages [][]string
+	for cursor := ""; ; {
+		_, ids := list("limit=2&paging_id=" + cursor)
+		if len(ids) == 0 {
+			break
+		}
+		pages = append(pages, ids)
+		cursor = ids[len(ids)-1]
+	}
+	if fmt.Sprint(pages) != "[[tx-d tx-c] [tx-b tx-a]]" {
+		t.Fatalf("expected newest-first pages ordered by started_at then id, got %v", pages)
+	}
+
+	// A transaction started after the cursor was issued must not shift later pages.
+	if err := service.TxStore.Create(db.Transaction{ID: "tx-g", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(time.Hour)}, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("create transaction: %v", err)
+	}
+	if _, ids := list("limit=2&paging_id=tx-c"); fmt.Sprint(ids) != "[tx-b tx-a]" {
+		t.Fatalf("expected the page after tx-c to be stable, got %v", ids)
+	}
+	if _, ids := list("kind=deposit&paging_id=tx-d"); fmt.Sprint(ids) != "[tx-c tx-a]" {
+		t.Fatalf("expected deposits after tx-d, got %v", ids)
+	}
+	if _, ids := list("no_older_than=" + start.Add(time.Minute).Format(time.RFC3339) + "&paging_id=tx-d"); fmt.Sprint(ids) != "[tx-c tx-b]" {
+		t.Fatalf("expected no_older_than to bound the page, got %v", ids)
+	}
+	for i := range transfer.MaxListLimit {
+		if err := service.TxStore.Create(db.Transaction{ID: fmt.Sprintf("tx-old-%03d", i), Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(-time.Hour)}, db.Audit{Source: db.SourceAdmin}); err != nil {
+			t.Fatalf("create transaction: %v", err)
+		}
+	}
+	if _, ids := list("limit=100000"); len(ids) != transfer.MaxListLimit {
+		t.Fatalf("expected the page to be capped at %d, got %d", transfer.MaxListLimit, len(ids))
+	}
+	for _, cursor := range []string{"missing", "tx-e", "tx-f"} {
+		if code, _ := list("paging_id=" + cursor); code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for paging_id %s, got %d", cursor, code)
+		}
+	}
+}
+
+func TestGetTransactionByIdentifiers(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+	now := time.Now().UTC()
+	for _, tx := range []db.Transaction{
+		{ID: "tx-a", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", ExternalTransactionID: "bank-a", StartedAt: now},
+		{ID: "tx-b", Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "withdraw", AssetCode: "USDC", StellarTransactionID: "hash-b", WithdrawMemo: "42", WithdrawMemoType: memo.TypeID, StartedAt: now},
+		{ID: "tx-c", Account: "GOTHER", Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", ExternalTransactionID: "bank-c", StartedAt: now},
+		// Newer transactions sharing tx-a's and tx-b's identifiers must not
+		// hide them: one belongs to another account, one to SEP-6.
+		{ID: "tx-d", Account: "GOTHER", Protocol: transfer.ProtocolSEP24, Kind: "deposit", AssetCode: "USDC", ExternalTransactionID: "bank-a", StellarTransactionID: "hash-b", StartedAt: now.Add(time.Minute)},
+		{ID: "tx-e", Account: testAccount, Protocol: transfer.ProtocolSEP6, Kind: "deposit", AssetCode: "USDC", ExternalTransactionID: "bank-a", StellarTransactionID: "hash-b", StartedAt: now.Add(time.Minute)},
+	} {
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+			t.Fatalf("create transaction: %v", err)
+		}
+	}
+	if tx, ok := service.TxStore.GetByMemo("42", memo.TypeID); !ok || tx.ID != "tx-b" {
+		t.Fatalf("expected memo lookup to find tx-b, got %+v", tx)
+	}
+
+	get := func(query string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, "/sep24/transaction?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var body struct {
+			Transaction map[string]any `json:"transaction"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Transaction
+	}
+	for query, want := range map[string]string{
+		"external_transaction_id=bank-a":         "tx-a",
+		"stellar_transaction_id=hash-b":          "tx-b",
+		"id=tx-b&stellar_transaction_id=hash-b":  "tx-b",
+		"id=tx-a&external_transaction_id=bank-a": "tx-a",
+	} {
+		if code, tx := get(query); code != http.StatusOK || tx["id"] != want {
+			t.Fatalf("%s: expected %s, got %d %+v", query, want, code, tx)
+		}
+	}
+	if code, _ := get("id=tx-a&stellar_transaction_id=hash-b"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for conflicting identifiers, got %d", code)
+	}
+	if code, _ := get("external_transaction_id=bank-c"); code != http.StatusNotFound {
+		t.Fatalf("expected another account's transaction to be hidden, got %d", code)
+	}
+
+	// Identifiers follow updates: the old external id no longer resolves.
+	tx, _ := service.TxStore.GetByID("tx-a")
+	tx.ExternalTransactionID = "bank-a2"
+	if _, err := service.TxStore.Update(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("update transaction: %v", err)
+	}
+	if code, _ := get("external_transaction_id=bank-a"); code != http.StatusNotFound {
+		t.Fatalf("expected stale external id to be unindexed, got %d", code)
+	}
+	if code, got := get("external_transaction_id=bank-a2"); code != http.StatusOK || got["id"] != "tx-a" {
+		t.Fatalf("expected updated external id to resolve, got %d %+v", code, got)
+	}
+}
+
+func TestDepositEmitsEvents(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Events = events.NewPublisher("http://127.0.0.1:0", "secret", events.NewMemoryOutbox())
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "100.00"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, testAccount))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	if _, err := service.transition(tx, StatusPendingUserTransferStart, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+
+	pending := service.Events.Outbox.Pending()
+	if len(pending) != 2 || pending[0].Type != events.TypeTransactionCreated || pending[1].Type != events.TypeTransactionStatusChanged {
+		t.Fatalf("expected created and status_changed events, got %+v", pending)
+	}
+	var data struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	if err := json.Unmarshal(pending[1].Data, &data); err != nil || data.Transaction["id"] != tx.ID || data.Transaction["status"] != StatusPendingUserTransferStart {
+		t.Fatalf("unexpected status_changed data: %s", pending[1].Data)
+	}
+}
+
+// brokenOutbox fails every append, as a full disk or a crash right after
+// the database commit would.
+type brokenOutbox struct {
+	*events.MemoryOutbox
+}
+
+func (brokenOutbox) Append(events.Event) error {
+	return errors.New("disk full")
+}
+
+// The outbox is not written in the database transaction: when the event
+// cannot be recorded the deposit still stands and the event is lost.
+func TestDepositStandsWhenItsEventIsLost(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Events = events.NewPublisher("http://127.0.0.1:0", "secret", brokenOutbox{events.NewMemoryOutbox()})
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "100.00"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, testAccount))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the deposit to succeed, got %d %s", rec.Code, rec.Body.String())
+	}
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if _, ok := service.TxStore.GetByID(interactive.ID); !ok {
+		t.Fatal("expected the transaction to be stored")
+	}
+	if pending := service.Events.Outbox.Pending(); len(pending) != 0 {
+		t.Fatalf("expected no event to be recorded, got %+v", pending)
+	}
+}
+
+func TestWithdrawAssignsAnchorAccountAndMemo(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "25.00"})
+		req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+		}
+
+		var interactive InteractiveResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+
+		body := getTransaction(t, mux, token, interactive.ID)
+		if body["withdraw_anchor_account"] != service.Config.DistributionAccount {
+			t.Fatalf("unexpected withdraw_anchor_account: %v", body["withdraw_anchor_account"])
+		}
+		if body["withdraw_memo_type"] != memo.TypeID {
+			t.Fatalf("unexpected withdraw_memo_type: %v", body["withdraw_memo_type"])
+		}
+		value, _ := body["withdraw_memo"].(string)
+		if value == "" || seen[value] {
+			t.Fatalf("expected unique withdraw memo, got %q", value)
+		}
+		seen[value] = true
+
+		owner, ok := service.TxStore.GetByMemo(value, memo.TypeID)
+		if !ok || owner.ID != interactive.ID {
+			t.Fatalf("expected memo lookup to return %s, got %q", interactive.ID, owner.ID)
+		}
+	}
+}
+
+func TestObservedPaymentAdvancesWithdrawal(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "25.00"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	if _, err := service.TxStore.UpdateStatus(tx.ID, tx.Status, StatusPendingUserTransferStart, time.Now().UTC(), db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+
+	source := observer.NewMemorySource()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = observer.New("sep24", service.Config.DistributionAccount, source, observer.NewMemoryCursorStore(), service).Run(ctx)
+	}()
+
+	source.Publish(observer.Payment{
+		TransactionHash: "wrong-asset",
+		From:            testAccount,
+		To:              service.Config.DistributionAccount,
+		AssetCode:       "EURC",
+		Amount:          "25.0000000",
+		Memo:            tx.WithdrawMemo,
+		MemoType:        tx.WithdrawMemoType,
+	})
+	source.Publish(observer.Payment{
+		TransactionHash: "abc123",
+		From:            testAccount,
+		To:              service.Config.DistributionAccount,
+		AssetCode:       "USDC",
+		Amount:          "25.0000000",
+		Memo:            tx.WithdrawMemo,
+		MemoType:        tx.WithdrawMemoType,
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		updated, _ := service.TxStore.GetByID(tx.ID)
+		if updated.Status == StatusPendingAnchor {
+			if updated.StellarTransactionID != "abc123" || !updated.AmountIn.Equal(decimal.MustParse("25")) {
+				t.Fatalf("unexpected settlement fields: %+v", updated)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for pending_anchor, status=%s", updated.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOnChangeCallbackIsSignedAndDelivered(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+	signer := keypair.MustRandom()
+	service.Callbacks, _ = callback.NewDispatcher(signer.Seed(), callback.NewMemoryDeadLetters())
+
+	callbacks := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		if err := callback.Verify(signer.Address(), r.Header.Get("Signature"), r.Host, raw); err != nil {
+			t.Errorf("verify callback signature: %v", err)
+		}
+		var body struct {
+			Transaction map[string]any `json:"transaction"`
+		}
+		_ = json.Unmarshal(raw, &body)
+		callbacks <- body.Transaction
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.Callbacks.Run(ctx)
+
+	post := func(callbackURL string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "25.00", OnChangeCallback: callbackURL})
+		req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post("ftp://wallet.example.com"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for non-http callback, got %d", rec.Code)
+	}
+	rec := post(server.URL)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	if _, err := service.TxStore.UpdateStatus(tx.ID, tx.Status, StatusPendingUserTransferStart, time.Now().UTC(), db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	payment := observer.Payment{ID: "p1", TransactionHash: "abc123", From: testAccount, To: service.Config.DistributionAccount, AssetCode: "USDC", Amount: "25.00", Memo: tx.WithdrawMemo, MemoType: tx.WithdrawMemoType}
+	if err := service.HandlePayment(context.Background(), payment); err != nil {
+		t.Fatalf("handle payment: %v", err)
+	}
+	select {
+	case notified := <-callbacks:
+		if notified["id"] != tx.ID || notified["status"] != StatusPendingAnchor {
+			t.Fatalf("unexpected callback: %+v", notified)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a status callback")
+	}
+}
+
+func TestDepositSubmissionWaitsForTrustline(t *testing.T) {
+	service, mux := testServiceAndMux()
+	user := keypair.MustRandom().Address()
+	distribution := keypair.MustRandom()
+	issuer := keypair.MustRandom().Address()
+	service.Config.Assets[0].Issuer = issuer
+
+	ledger := submitter.NewMemoryLedger()
+	ledger.SetAccount(submitter.Account{ID: distribution.Address(), Sequence: 1})
+	ledger.SetAccount(submitter.Account{ID: user})
+	service.Payments = submitter.New(ledger, distribution.Seed(), network.TestNetworkPassphrase)
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "100"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, user))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	from := StatusIncomplete
+	for _, status := range []string{StatusPendingUserTransferStart, StatusPendingAnchor} {
+		if _, err := service.TxStore.UpdateStatus(interactive.ID, from, status, time.Now().UTC(), db.Audit{Source: db.SourceAdmin}); err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+		from = status
+	}
+
+	ctx := context.Background()
+	service.ProcessDeposits(ctx)
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	if tx.Status != StatusPendingTrust {
+		t.Fatalf("expected pending_trust, got %s", tx.Status)
+	}
+	token := testToken(t, user)
+	if got := getTransaction(t, mux, token, interactive.ID); got["message"] != statusMessages[StatusPendingTrust] || got["completed_at"] != nil {
+		t.Fatalf("expected the pending_trust message and no completed_at, got %+v", got)
+	}
+
+	ledger.SetAccount(submitter.Account{ID: user, Trustlines: map[string]bool{submitter.TrustlineKey("USDC", issuer): true}})
+	service.ProcessDeposits(ctx)
+	tx, _ = service.TxStore.GetByID(interactive.ID)
+	if tx.Status != StatusPendingStellar || tx.StellarTransactionID == "" {
+		t.Fatalf("expected pending_stellar with stellar transaction id, got %+v", tx)
+	}
+
+	ledger.SetStatus(tx.StellarTransactionID, submitter.StatusSuccess)
+	service.ProcessDeposits(ctx)
+	tx, _ = service.TxStore.GetByID(interactive.ID)
+	if tx.Status != StatusCompleted {
+		t.Fatalf("expected completed, got %s", tx.Status)
+	}
+
+	history, err := service.TxStore.History(interactive.ID)
+	if err != nil || len(history) != 6 {
+		t.Fatalf("expected one history entry per write, got %+v %v", history, err)
+	}
+	last := history[len(history)-1]
+	if last.Source != db.SourceSubmitter || last.Version != tx.Version {
+		t.Fatalf("expected the submitter to have recorded the completion, got %+v", last)
+	}
+	got := getTransaction(t, mux, token, interactive.ID)
+	if got["completed_at"] != last.At.Format(time.RFC3339Nano) || got["message"] != statusMessages[StatusCompleted] {
+		t.Fatalf("expected completed_at from the history and the completed message, got %+v", got)
+	}
+}
+
+// flakyLedger fails the first failures submissions and counts them all.
+type flakyLedger struct {
+	*submitter.MemoryLedger
+	failures    int
+	submissions int
+}
+
+func (l *flakyLedger) Submit(ctx context.Context, envelope string) (string, error) {
+	l.submissions++
+	if l.submissions <= l.failures {
+		return "", errors.New("horizon unavailable")
+	}
+	return l.MemoryLedger.Submit(ctx, envelope)
+}
+
+// conflictingStore fails every Update as if another writer got there first.
+type conflictingStore struct {
+	db.TransactionStore
+}
+
+func (s conflictingStore) Update(db.Transaction, db.Audit) (db.Transaction, error) {
+	return db.Transaction{}, db.ErrConflict
+}
+
+func TestDepositIsClaimedBeforeBroadcast(t *testing.T) {
+	service, _ := testServiceAndMux()
+	user := keypair.MustRandom().Address()
+	distribution := keypair.MustRandom()
+	ledger := &flakyLedger{MemoryLedger: submitter.NewMemoryLedger(), failures: 1}
+	ledger.SetAccount(submitter.Account{ID: distribution.Address(), Sequence: 1})
+	ledger.SetAccount(submitter.Account{ID: user})
+	service.Config.Assets[0].Issuer = user
+	service.Payments = submitter.New(ledger, distribution.Seed(), network.TestNetworkPassphrase)
+
+	deposit := db.Transaction{ID: "deposit-1", Kind: "deposit", Status: StatusPendingAnchor, Account: user, To: user, AssetCode: "USDC", Amount: decimal.MustParse("10"), AmountOut: decimal.MustParse("9")}
+	if err := service.TxStore.Create(deposit, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	ctx := context.Background()
+
+	unpriced := deposit
+	unpriced.ID, unpriced.AmountOut = "deposit-unpriced", decimal.Zero
+	if err := service.TxStore.Create(unpriced, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := service.SubmitDeposit(ctx, unpriced.ID); err == nil || ledger.submissions != 0 {
+		t.Fatalf("expected a deposit without amount_out to be refused, got %v", err)
+	}
+	service.PlatformPayouts = true
+	service.ProcessDeposits(ctx)
+	if tx, _ := service.TxStore.GetByID(deposit.ID); tx.Status != StatusPendingAnchor || ledger.submissions != 0 {
+		t.Fatalf("expected the worker to leave new payouts to the platform, got %s", tx.Status)
+	}
+	service.PlatformPayouts = false
+
+	store := service.TxStore
+	service.TxStore = conflictingStore{store}
+	if _, err := service.SubmitDeposit(ctx, deposit.ID); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("expected the claim to conflict, got %v", err)
+	}
+	if ledger.submissions != 0 {
+		t.Fatalf("expected nothing to be broadcast without a claim, got %d submissions", ledger.submissions)
+	}
+	service.TxStore = store
+
+	service.ProcessDeposits(ctx)
+	claimed, _ := service.TxStore.GetByID(deposit.ID)
+	if claimed.Status != StatusPendingStellar || claimed.PaymentEnvelope == "" {
+		t.Fatalf("expected a claimed deposit after a failed broadcast, got %+v", claimed)
+	}
+	service.ProcessDeposits(ctx)
+	service.ProcessDeposits(ctx)
+	if envelope, ok := ledger.Envelope(claimed.StellarTransactionID); !ok || envelope != claimed.PaymentEnvelope {
+		t.Fatal("expected the recorded envelope to be resubmitted under its hash")
+	}
+	if tx, _ := service.TxStore.GetByID(deposit.ID); tx.StellarTransactionID != claimed.StellarTransactionID {
+		t.Fatalf("expected the deposit to keep its payment, got %s", tx.StellarTransactionID)
+	}
+
+	ledger.SetStatus(claimed.StellarTransactionID, submitter.StatusSuccess)
+	service.ProcessDeposits(ctx)
+	if tx, _ := service.TxStore.GetByID(deposit.ID); tx.Status != StatusCompleted {
+		t.Fatalf("expected completed, got %s", tx.Status)
+	}
+}
+
+func TestDepositWorkerReachesDepositsBehindFullBatches(t *testing.T) {
+	service, _ := testServiceAndMux()
+	user := keypair.MustRandom().Address()
+	untrusted := keypair.MustRandom().Address()
+	distribution := keypair.MustRandom()
+	issuer := keypair.MustRandom().Address()
+	service.Config.Assets[0].Issuer = issuer
+	ledger := submitter.NewMemoryLedger()
+	ledger.SetAccount(submitter.Account{ID: distribution.Address(), Sequence: 1})
+	ledger.SetAccount(submitter.Account{ID: user, Trustlines: map[string]bool{submitter.TrustlineKey("USDC", issuer): true}})
+	ledger.SetAccount(submitter.Account{ID: untrusted})
+	service.Payments = submitter.New(ledger, distribution.Seed(), network.TestNetworkPassphrase)
+
+	old := time.Now().UTC().Add(-time.Hour)
+	create := func(id, kind, status, account string) {
+		tx := db.Transaction{ID: id, Kind: kind, Status: status, Account: account, To: account, AssetCode: "USDC", Amount: decimal.MustParse("10"), AmountOut: decimal.MustParse("9"), StartedAt: old, UpdatedAt: old}
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAPI}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	for i := range 2 * depositBatchSize {
+		create(fmt.Sprintf("withdraw-%03d", i), "withdraw", StatusPendingAnchor, user)
+		create(fmt.Sprintf("untrusted-%03d", i), "deposit", StatusPendingTrust, untrusted)
+	}
+	create("zz-anchor", "deposit", StatusPendingAnchor, user)
+	create("zz-trust", "deposit", StatusPendingTrust, user)
+
+	service.ProcessDeposits(context.Background())
+	for _, id := range []string{"zz-anchor", "zz-trust"} {
+		if tx, _ := service.TxStore.GetByID(id); tx.Status != StatusPendingStellar {
+			t.Fatalf("expected %s to be submitted, got %s", id, tx.Status)
+		}
+	}
+}
+
+func TestDepositAfterFailedBroadcastUsesNextSequence(t *testing.T) {
+	service, _ := testServiceAndMux()
+	user := keypair.MustRandom().Address()
+	distribution := keypair.MustRandom()
+	ledger := &flakyLedger{MemoryLedger: submitter.NewMemoryLedger(), failures: 1}
+	ledger.SetAccount(submitter.Account{ID: distribution.Address(), Sequence: 1})
+	ledger.SetAccount(submitter.Account{ID: user})
+	service.Config.Assets[0].Issuer = user
+	service.Payments = submitter.New(ledger, distribution.Seed(), network.TestNetworkPassphrase)
+
+	ctx := context.Background()
+	var hashes []string
+	for _, id := range []string{"deposit-1", "deposit-2"} {
+		deposit := db.Transaction{ID: id, Kind: "deposit", Status: StatusPendingAnchor, Account: user, To: user, AssetCode: "USDC", Amount: decimal.MustParse("10"), AmountOut: decimal.MustParse("9")}
+		if err := service.TxStore.Create(deposit, db.Audit{Source: db.SourceAPI}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		tx, err := service.SubmitDeposit(ctx, id)
+		if err != nil {
+			t.Fatalf("submit %s: %v", id, err)
+		}
+		hashes = append(hashes, tx.StellarTransactionID)
+	}
+	for range 2 {
+		service.ProcessDeposits(ctx)
+	}
+	for _, hash := range hashes {
+		if _, ok := ledger.Envelope(hash); !ok {
+			t.Fatalf("expected both deposits to land, %s is missing", hash)
+		}
+	}
+}
+
+func TestUnseenDepositPaymentExpires(t *testing.T) {
+	service, _ := testServiceAndMux()
+	ledger := submitter.NewMemoryLedger()
+	service.Payments = submitter.New(ledger, keypair.MustRandom().Seed(), network.TestNetworkPassphrase)
+	now := time.Now().UTC()
+	service.Now = func() time.Time { return now }
+	deposit := db.Transaction{
+		ID: "deposit-1", Kind: "deposit", Status: StatusPendingStellar, AssetCode: "USDC",
+		StellarTransactionID: "abc", PaymentEnvelope: "AAAA", PaymentValidUntil: now.Add(-2 * paymentExpiryGrace),
+	}
+	if err := service.TxStore.Create(deposit, db.Audit{Source: db.SourceAPI}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	ledger.SetStatus("abc", submitter.StatusPending)
+	tx, err := service.ConfirmDeposit(context.Background(), deposit.ID)
+	if err != nil || tx.Status != StatusError {
+		t.Fatalf("expected an expired payment to move the deposit to error, got %s %v", tx.Status, err)
+	}
+}
+
+func TestExpireStaleTransactions(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Config.ExpireAfter = map[string]time.Duration{
+		StatusIncomplete:               time.Hour,
+		StatusPendingUserTransferStart: 24 * time.Hour,
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	service.Now = func() time.Time { return now }
+	token := testToken(t, testAccount)
+
+	ids := make([]string, 0, 2)
+	for _, path := range []string{"/sep24/transactions/deposit/interactive", "/sep24/transactions/withdraw/interactive"} {
+		payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "10"})
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var interactive InteractiveResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		ids = append(ids, interactive.ID)
+	}
+
+	deposit, _ := service.TxStore.GetByID(ids[0])
+	if !deposit.UserActionRequiredBy.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected user_action_required_by: %v", deposit.UserActionRequiredBy)
+	}
+
+	withdrawal, _ := service.TxStore.GetByID(ids[1])
+	if _, err := service.transition(withdrawal, StatusPendingUserTransferStart, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("transition withdrawal: %v", err)
+	}
+
+	now = start.Add(2 * time.Hour)
+	expired := service.ExpireStale(context.Background())
+	if len(expired) != 1 || expired[0].ID != ids[0] {
+		t.Fatalf("expected only the incomplete deposit to expire, got %+v", expired)
+	}
+	if body := getTransaction(t, mux, token, ids[0]); body["status"] != StatusExpired {
+		t.Fatalf("expected expired status, got %v", body["status"])
+	}
+
+	now = start.Add(25 * time.Hour)
+	expired = service.ExpireStale(context.Background())
+	if len(expired) != 1 || expired[0].ID != ids[1] || expired[0].Status != StatusExpired {
+		t.Fatalf("expected withdrawal to expire, got %+v", expired)
+	}
+
+	late := expired[0]
+	payment := observer.Payment{ID: "p-late", TransactionHash: "hash-late", From: testAccount, AssetCode: "USDC", Amount: "10", Memo: late.WithdrawMemo, MemoType: late.WithdrawMemoType}
+	if err := service.HandlePayment(context.Background(), payment); err != nil {
+		t.Fatalf("handle payment: %v", err)
+	}
+	got, _ := service.TxStore.GetByID(late.ID)
+	if got.Status != StatusError || got.StellarTransactionID != "hash-late" || !got.AmountIn.Equal(decimal.MustParse("10")) {
+		t.Fatalf("expected the late payment to be recorded for an operator, got %s %s %s", got.Status, got.StellarTransactionID, got.AmountIn)
+	}
+}
+
+func TestExpireStalePagesThroughTransactions(t *testing.T) {
+	service, _ := testServiceAndMux()
+	service.Config.ExpireAfter = map[string]time.Duration{StatusIncomplete: time.Hour}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.Now = func() time.Time { return start.Add(2 * time.Hour) }
+	total := 2*expiryBatchSize + 1
+	for i := range total {
+		tx := db.Transaction{ID: fmt.Sprintf("tx-%03d", i), Account: testAccount, Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: StatusIncomplete, AssetCode: "USDC", StartedAt: start, UpdatedAt: start}
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAPI}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if expired := service.ExpireStale(context.Background()); len(expired) != total {
+		t.Fatalf("expected all %d transactions to expire in one sweep, got %d", total, len(expired))
+	}
+}
+
+func TestExpireStaleSkipsOtherProtocols(t *testing.T) {
+	service, _ := testServiceAndMux()
+	service.Config.ExpireAfter = map[string]time.Duration{StatusIncomplete: time.Hour, transfer.StatusPendingSender: time.Hour}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.Now = func() time.Time { return start.Add(2 * time.Hour) }
+	for _, tx := range []db.Transaction{
+		{ID: "sep6", Protocol: transfer.ProtocolSEP6, Kind: "deposit", Status: StatusIncomplete},
+		{ID: "sep31", Protocol: transfer.ProtocolSEP31, Kind: "receive", Status: transfer.StatusPendingSender},
+		{ID: "sep24", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Status: StatusIncomplete},
+	} {
+		tx.Account, tx.AssetCode, tx.StartedAt, tx.UpdatedAt = testAccount, "USDC", start, start
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAPI}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if expired := service.ExpireStale(context.Background()); len(expired) != 1 || expired[0].ID != "sep24" {
+		t.Fatalf("expected only the SEP-24 transaction to expire, got %+v", expired)
+	}
+	for _, id := range []string{"sep6", "sep31"} {
+		if tx, _ := service.TxStore.GetByID(id); tx.Status == StatusExpired {
+			t.Fatalf("expected %s to be left to its own protocol, got %s", id, tx.Status)
+		}
+	}
+}
+
+func TestAdminRefunds(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "100"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	tx.Status = StatusPendingAnchor
+	tx.AmountIn = decimal.MustParse("100")
+	if _, err := service.TxStore.Update(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("update transaction: %v", err)
+	}
+
+	refund := func(key string, body RefundRequest) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/admin/sep24/refunds", bytes.NewReader(raw))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := refund("wrong-key", RefundRequest{TransactionID: tx.ID, ID: "ext-1", Amount: decimal.MustParse("10")}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for invalid admin key, got %d", rec.Code)
+	}
+	for _, protocol := range []string{transfer.ProtocolSEP6, transfer.ProtocolSEP31} {
+		other := tx
+		other.ID, other.Protocol = "other-"+protocol, protocol
+		if err := service.TxStore.Create(other, db.Audit{Source: db.SourceAPI}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if rec := refund(testAdminKey, RefundRequest{TransactionID: other.ID, ID: "ext-0", Amount: decimal.MustParse("10")}); rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for a %s transaction, got %d body=%s", protocol, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := refund(testAdminKey, RefundRequest{TransactionID: tx.ID, ID: "ext-1", Amount: decimal.MustParse("40"), Fee: decimal.MustParse("1")}); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	body := getTransaction(t, mux, token, tx.ID)
+	if body["status"] != StatusPendingAnchor {
+		t.Fatalf("expected partial refund to keep pending_anchor, got %v", body["status"])
+	}
+	refunds, _ := body["refunds"].(map[string]any)
+	if refunds["amount_refunded"] != "41.00" || refunds["amount_fee"] != "1.00" {
+		t.Fatalf("unexpected refunds object: %v", refunds)
+	}
+
+	if rec := refund(testAdminKey, RefundRequest{TransactionID: tx.ID, ID: "ext-2", Amount: decimal.MustParse("60")}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for over-refund, got %d", rec.Code)
+	}
+	if rec := refund(testAdminKey, RefundRequest{TransactionID: tx.ID, ID: "ext-2", Amount: decimal.MustParse("59")}); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	body = getTransaction(t, mux, token, tx.ID)
+	refunds, _ = body["refunds"].(map[string]any)
+	payments, _ := refunds["payments"].([]any)
+	if body["status"] != StatusRefunded || body["refunded"] != true || len(payments) != 2 {
+		t.Fatalf("expected full refund, got %v", body)
+	}
+}
+
+func TestFeeUsesDecimalArithmetic(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Config.Assets[0].SignificantDecimals = 7
+	token := testToken(t, testAccount)
+
+	cases := map[string]string{
+		"1234.5678901": "2.2345679",
+		"0.1":          "1.0001000",
+		"0":            "1.0000000",
+	}
+	for amount, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/sep24/fee?operation=deposit&asset_code=USDC&amount="+amount, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode fee: %v", err)
+		}
+		if body["fee"] != want {
+			t.Fatalf("fee for %s: expected %s, got %s", amount, want, body["fee"])
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/sep24/fee?operation=deposit&asset_code=USDC&amount=1e3", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for non-decimal amount, got %d", rec.Code)
+	}
+}
+
+func TestFeeRulesApplyToInfoAndTransactions(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Fees = fees.NewRulesCalculator(service.Config.Assets, decimal.RoundHalfEven, []fees.Rule{
+		{AssetCode: "USDC", Operation: fees.OperationDeposit, Fixed: decimal.MustParse("0.5")},
+		{AssetCode: "USDC", Operation: fees.OperationWithdraw, Fixed: decimal.MustParse("1"), Percent: decimal.MustParse("2"), Minimum: decimal.MustParse("3")},
+	})
+	token := testToken(t, testAccount)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sep24/info", nil))
+	var info struct {
+		Deposit  map[string]map[string]any `json:"deposit"`
+		Withdraw map[string]map[string]any `json:"withdraw"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+	if info.Deposit["USDC"]["fee_fixed"] != 0.5 || info.Deposit["USDC"]["fee_percent"] != 0.0 {
+		t.Fatalf("unexpected deposit info: %+v", info.Deposit["USDC"])
+	}
+	if info.Withdraw["USDC"]["fee_percent"] != 2.0 || info.Withdraw["USDC"]["fee_minimum"] != 3.0 {
+		t.Fatalf("unexpected withdraw info: %+v", info.Withdraw["USDC"])
+	}
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "50"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode interactive response: %v", err)
+	}
+
+	tx := getTransaction(t, mux, token, interactive.ID)
+	if tx["amount_fee"] != "3.00" || tx["amount_out"] != "47.00" {
+		t.Fatalf("unexpected fee amounts: fee=%v out=%v", tx["amount_fee"], tx["amount_out"])
+	}
+	details, ok := tx["fee_details"].(map[string]any)
+	if !ok || details["total"] != "3.00" || details["asset"] != "stellar:USDC" {
+		t.Fatalf("unexpected fee_details: %+v", tx["fee_details"])
+	}
+	if items, _ := details["details"].([]any); len(items) != 3 {
+		t.Fatalf("expected fixed, percentage and minimum lines, got %+v", details["details"])
+	}
+
+	payload, _ = json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "3"})
+	req = httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "fee exceeds the amount") {
+		t.Fatalf("expected a fee that consumes the amount to be rejected, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTransactionFeeMatchesFeeEndpoint(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Fees = fees.NewRulesCalculator(service.Config.Assets, decimal.RoundHalfEven, []fees.Rule{
+		{AssetCode: "USDC", Operation: fees.OperationWithdraw, Type: "SEPA", CustomerType: "business", Fixed: decimal.MustParse("5")},
+		{AssetCode: "USDC", Operation: fees.OperationWithdraw, Fixed: decimal.MustParse("1")},
+	})
+	if err := service.CustomerStore.Put(db.Customer{ID: "customer-1", Account: testAccount, Type: "business"}); err != nil {
+		t.Fatalf("put customer: %v", err)
+	}
+	token := testToken(t, testAccount)
+
+	req := httptest.NewRequest(http.MethodGet, "/sep24/fee?operation=withdraw&asset_code=USDC&amount=50&type=SEPA&customer_type=business", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"fee":"5.00"`) {
+		t.Fatalf("unexpected /fee response: %s", rec.Body.String())
+	}
+
+	for method, want := range map[string]string{"SEPA": "5.00", "": "1.00"} {
+		payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "50", Type: method})
+		req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var interactive InteractiveResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+			t.Fatalf("decode interactive response: %v body=%s", err, rec.Body.String())
+		}
+		if tx := getTransaction(t, mux, token, interactive.ID); tx["amount_fee"] != want {
+			t.Fatalf("type %q: expected fee %s, got %v", method, want, tx["amount_fee"])
+		}
+	}
+}
+
+func TestAmountLimits(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Config.Assets[0].Withdraw = config.AssetOperation{MinAmount: decimal.MustParse("10"), MaxAmount: decimal.MustParse("1000")}
+	token := testToken(t, testAccount)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sep24/info", nil))
+	var info struct {
+		Deposit  map[string]map[string]any `json:"deposit"`
+		Withdraw map[string]map[string]any `json:"withdraw"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+	if info.Withdraw["USDC"]["min_amount"] != 10.0 || info.Withdraw["USDC"]["max_amount"] != 1000.0 {
+		t.Fatalf("unexpected withdraw limits: %+v", info.Withdraw["USDC"])
+	}
+	if _, ok := info.Deposit["USDC"]["min_amount"]; ok {
+		t.Fatalf("deposit should not advertise limits: %+v", info.Deposit["USDC"])
+	}
+
+	for amount, want := range map[string]int{"abc": http.StatusBadRequest, "5": http.StatusBadRequest, "1000.01": http.StatusBadRequest, "50.001": http.StatusBadRequest, "50.100": http.StatusOK, "": http.StatusOK} {
+		payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: amount})
+		req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("amount %q: expected %d, got %d body=%s", amount, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/withdraw/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	formURL, err := url.Parse(interactive.URL)
+	if err != nil {
+		t.Fatalf("parse interactive url: %v", err)
+	}
+	formToken := formURL.Query().Get("token")
+	submitForm := func(token, amount string) *httptest.ResponseRecorder {
+		form := url.Values{"id": {interactive.ID}, "token": {token}, "amount": {amount}}
+		req := httptest.NewRequest(http.MethodPost, "/sep24/interactive/withdraw", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	stored, _ := service.TxStore.GetByID(interactive.ID)
+	if strings.Contains(stored.URL, "token=") {
+		t.Fatalf("interactive token must not be persisted: %s", stored.URL)
+	}
+	for _, forged := range []string{"", "1.abc", formToken + "x"} {
+		if rec := submitForm(forged, "50"); rec.Code != http.StatusForbidden {
+			t.Fatalf("token %q: expected 403, got %d", forged, rec.Code)
+		}
+	}
+	if rec := submitForm(formToken, "2"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "below the minimum") {
+		t.Fatalf("expected form to reject small amount, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := submitForm(formToken, "50.125"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "more than 2 decimal places") {
+		t.Fatalf("expected form to reject excess precision, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := submitForm(formToken, "50"); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected redirect after valid amount, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	tx, _ := service.TxStore.GetByID(interactive.ID)
+	if tx.Status != StatusPendingUserTransferStart || !tx.Amount.Equal(decimal.MustParse("50")) || tx.AmountFee.IsZero() {
+		t.Fatalf("unexpected transaction after form submission: %+v", tx)
+	}
+
+	if err := service.HandlePayment(context.Background(), observer.Payment{
+		ID:        "p1",
+		AssetCode: "USDC",
+		Amount:    "5000",
+		Memo:      tx.WithdrawMemo,
+		MemoType:  tx.WithdrawMemoType,
+	}); err != nil {
+		t.Fatalf("handle payment: %v", err)
+	}
+	if body := getTransaction(t, mux, token, tx.ID); body["status"] != StatusTooLarge {
+		t.Fatalf("expected too_large status, got %v", body["status"])
+	}
+}
+
+func TestInfoMatchesOpenAPISchema(t *testing.T) {
+	service, mux := testServiceAndMux()
+	disabled := false
+	withdrawFee := decimal.MustParse("2.5")
+	service.Config.Assets = append(service.Config.Assets, config.Asset{
+		Code:                "EURC",
+		Issuer:              "GISSUERAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Enabled:             true,
+		SignificantDecimals: 2,
+		Deposit:             config.AssetOperation{Enabled: &disabled},
+		Withdraw:            config.AssetOperation{MinAmount: decimal.MustParse("5"), FeeFixed: &withdrawFee, FeeMinimum: decimal.MustParse("3")},
+	})
+	service.Fees = fees.NewRulesCalculator(service.Config.Assets, decimal.RoundHalfEven, fees.RulesFromAssets(service.Config.Assets))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sep24/info", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var body any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.FromSlash("../../../specs/sep24/openapi.yaml"))
+	if err != nil {
+		t.Fatalf("read openapi spec: %v", err)
+	}
+	var spec map[string]any
+	if err := yaml.Unmarshal(raw, &spec); err != nil {
+		t.Fatalf("decode openapi spec: %v", err)
+	}
+	validateSchema(t, spec, map[string]any{"$ref": "#/components/schemas/InfoResponse"}, body, "info")
+
+	info := body.(map[string]any)
+	if info["deposit"].(map[string]any)["EURC"].(map[string]any)["enabled"] != false {
+		t.Fatalf("expected EURC deposit to be disabled: %+v", info["deposit"])
+	}
+	eurc := info["withdraw"].(map[string]any)["EURC"].(map[string]any)
+	if eurc["asset_issuer"] == nil || eurc["fee_fixed"] != 2.5 || eurc["fee_minimum"] != 3.0 || eurc["min_amount"] != 5.0 {
+		t.Fatalf("unexpected EURC withdraw info: %+v", eurc)
+	}
+	if features, _ := info["features"].(map[string]any); features["claimable_balances"] != true {
+		t.Fatalf("unexpected features: %+v", info["features"])
+	}
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "EURC"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, testAccount))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected disabled deposit to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAssetsWithSameCodeAndDifferentIssuers(t *testing.T) {
+	service, mux := testServiceAndMux()
+	issuerA := keypair.MustRandom().Address()
+	issuerB := keypair.MustRandom().Address()
+	service.Config.Assets = []config.Asset{
+		{Code: "USDC", Issuer: issuerA, Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("1")},
+		{Code: "USDC", Issuer: issuerB, Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("2")},
+	}
+	service.Fees = fees.NewRulesCalculator(service.Config.Assets, decimal.RoundHalfEven, fees.RulesFromAssets(service.Config.Assets))
+	token := testToken(t, testAccount)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sep24/info", nil))
+	var info struct {
+		Deposit map[string]map[string]any `json:"deposit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode info: %v", err)
+	}
+	if info.Deposit["stellar:USDC:"+issuerB]["asset_issuer"] != issuerB {
+		t.Fatalf("expected both issuers in info, got %+v", info.Deposit)
+	}
+
+	payload, _ := json.Marshal(InteractiveRequest{AssetCode: "USDC", Amount: "10"})
+	req := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected ambiguous asset_code to be rejected, got %d", rec.Code)
+	}
+
+	payload, _ = json.Marshal(InteractiveRequest{AssetCode: "USDC", AssetIssuer: issuerB, Amount: "10"})
+	req = httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	tx := getTransaction(t, mux, token, interactive.ID)
+	if tx["asset_issuer"] != issuerB || tx["amount_in_asset"] != "stellar:USDC:"+issuerB || tx["amount_fee"] != "2.00" {
+		t.Fatalf("unexpected transaction asset fields: %+v", tx)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/sep24/fee?operation=deposit&asset_code=USDC&asset_issuer="+issuerA+"&amount=10", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"1.00"`) {
+		t.Fatalf("expected first issuer fee, got %s", rec.Body.String())
+	}
+}
+
+func TestDepositWithQuote(t *testing.T) {
+	service, mux := testServiceAndMux()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.Now = func() time.Time { return now }
+	service.Quotes = db.NewMemoryQuoteStore()
+	token := testToken(t, testAccount)
+
+	quote := db.Quote{
+		ID:         "quote-1",
+		Account:    testAccount,
+		Context:    "sep24",
+		SellAsset:  "iso4217:USD",
+		SellAmount: decimal.MustParse("103"),
+		BuyAsset:   "stellar:USDC",
+		BuyAmount:  decimal.MustParse("99.98"),
+		Price:      decimal.MustParse("1.02"),
+		TotalPrice: decimal.MustParse("1.0302060"),
+		Fee:        db.FeeDetails{Total: decimal.MustParse("1"), Asset: "stellar:USDC"},
+		ExpiresAt:  now.Add(time.Minute),
+	}
+	expired := quote
+	expired.ID, expired.ExpiresAt = "quote-expired", now
+	for _, q := range []db.Quote{quote, expired} {
+		if err := service.Quotes.Create(q); err != nil {
+			t.Fatalf("create quote: %v", err)
+		}
+	}
+
+	deposit := func(req InteractiveRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/sep24/transactions/deposit/interactive", bytes.NewReader(payload))
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httpReq)
+		return rec
+	}
+
+	rejected := []InteractiveRequest{
+		{AssetCode: "USDC", QuoteID: "quote-expired"},
+		{AssetCode: "USDC", QuoteID: "quote-1", Amount: "100"},
+		{AssetCode: "USDC", QuoteID: "quote-1", SourceAsset: "iso4217:EUR"},
+		{AssetCode: "USDC", QuoteID: "missing"},
+	}
+	for _, req := range rejected {
+		if rec := deposit(req); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %+v to be rejected, got %d", req, rec.Code)
+		}
+	}
+
+	store := service.TxStore
+	service.TxStore = failingCreateStore{store}
+	if rec := deposit(InteractiveRequest{AssetCode: "USDC", QuoteID: "quote-1"}); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected a failed create to return 500, got %d", rec.Code)
+	}
+	if q, _ := service.Quotes.GetByID("quote-1"); q.TransactionID != "" {
+		t.Fatalf("expected the quote to be released after a failed create, got %+v", q)
+	}
+	service.TxStore = store
+
+	rec := deposit(InteractiveRequest{AssetCode: "USDC", QuoteID: "quote-1", Amount: "103", SourceAsset: "iso4217:USD"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var interactive InteractiveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &interactive); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	tx := getTransaction(t, mux, token, interactive.ID)
+	if tx["quote_id"] != "quote-1" || tx["amount_in"] != "103.0000000" || tx["amount_in_asset"] != "iso4217:USD" {
+		t.Fatalf("unexpected amount_in fields: %+v", tx)
+	}
+	if tx["amount_out"] != "99.98" || tx["amount_out_asset"] != "stellar:USDC" || tx["amount_fee"] != "1.00" {
+		t.Fatalf("unexpected amount_out fields: %+v", tx)
+	}
+
+	if rec := deposit(InteractiveRequest{AssetCode: "USDC", QuoteID: "quote-1"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected quote reuse to be rejected, got %d", rec.Code)
+	}
+}
+
+type failingCreateStore struct {
+	db.TransactionStore
+}
+
+func (failingCreateStore) Create(db.Transaction, db.Audit) error {
+	return errors.New("disk full")
+}
+
+func validateSchema(t *testing.T, spec map[string]any, schema map[string]any, value any, path string) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		node := any(spec)
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node = node.(map[string]any)[part]
+		}
+		validateSchema(t, spec, node.(map[string]any), value, path)
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			t.Fatalf("%s: expected object, got %T", path, value)
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				t.Fatalf("%s: missing required property %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, item := range object {
+			if property, ok := properties[name].(map[string]any); ok {
+				validateSchema(t, spec, property, item, path+"."+name)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					t.Fatalf("%s: unexpected property %s", path, name)
+				}
+			case map[string]any:
+				validateSchema(t, spec, extra, item, path+"."+name)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Fatalf("%s: expected boolean, got %T", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			t.Fatalf("%s: expected number, got %T", path, value)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			t.Fatalf("%s: expected string, got %T", path, value)
+		}
+	}
+}
+
+const testAdminKey = "admin-key"
+
+const testAccount = "GTESTACCOUNTAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func testToken(t *testing.T, account string) string {
+	t.Helper()
+	token, err := sep10.IssueToken(account, "localhost:8080", "", "localhost:8080", "jwt-secret", time.Now().UTC(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func getTransaction(t *testing.T, mux *http.ServeMux, token, id string) map[string]any {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/sep24/transaction?id="+id, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode transaction: %v", err)
+	}
+	return body.Transaction
+}
+
 func testServiceAndMux() (*Service, *http.ServeMux) {
 	cfg := config.Config{
-		HomeDomain: "localhost:8080",
-		JWTSecret:  "jwt-secret",
+		HomeDomain:          "localhost:8080",
+		JWTSecret:           "jwt-secret",
+		DistributionAccount: "GDISTRIBUTIONAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		WithdrawMemoType:    memo.TypeID,
 		Assets: []config.Asset{
-			{Code: "USDC", Enabled: true, FeeFixed: 1.0, FeePercent: 0.1},
+			{Code: "USDC", Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("1.0"), FeePercent: decimal.MustParse("0.1")},
 		},
 	}
 	txStore := db.NewMemoryTransactionStore()
@@ -83,5 +1473,6 @@ func testServiceAndMux() (*Service, *http.ServeMux) {
 
 	mux := http.NewServeMux()
 	service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	service.RegisterAdminRoutes(mux, middleware.AdminAuth(testAdminKey))
 	return service, mux
 }
@@ -0,0 +1,69 @@
+package sep24
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// IdempotencyHeader lets a wallet retry an interactive request safely:
+// requests from one account with the same key name the same transaction.
+const IdempotencyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+var errInvalidIdempotencyKey = errors.New("invalid Idempotency-Key")
+
+// interactiveID returns the id of the transaction an interactive request
+// creates. With an Idempotency-Key the id is derived from the key, so a
+// retry finds the transaction its first attempt created.
+func (s *Service) interactiveID(r *http.Request, account, kind string) (string, error) {
+	key := r.Header.Get(IdempotencyHeader)
+	if key == "" {
+		return s.NewID(), nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", errInvalidIdempotencyKey
+	}
+	return transfer.IdempotentID(account, "sep24-"+kind, key), nil
+}
+
+// interactiveRequestHash fingerprints an interactive request after the
+// account and asset it names have been resolved.
+func interactiveRequestHash(req InteractiveRequest, account string, asset config.Asset) string {
+	req.Account = account
+	req.AssetCode, req.AssetIssuer = asset.Code, asset.Issuer
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayInteractive answers a retried request with the transaction that
+// already has id and reports whether it wrote a response. A key reused for
+// a different request is rejected rather than replayed, and a transaction
+// past incomplete is returned as it now stands.
+func (s *Service) replayInteractive(w http.ResponseWriter, id, requestHash string) bool {
+	tx, ok := s.TxStore.GetByID(id)
+	if !ok {
+		return false
+	}
+	if tx.RequestHash != requestHash {
+		writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return true
+	}
+	if tx.Status != StatusIncomplete {
+		writeJSON(w, http.StatusOK, map[string]any{"transaction": s.toSEP24Transaction(tx)})
+		return true
+	}
+	writeJSON(w, http.StatusOK, InteractiveResponse{
+		ID:   tx.ID,
+		Type: "interactive_customer_info_needed",
+		URL:  s.interactiveURL(tx),
+	})
+	return true
+}
@@ -1,11 +1,38 @@
 package sep24
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+)
 
 type assetInfo struct {
-	Enabled    bool    `json:"enabled"`
-	FeeFixed   float64 `json:"fee_fixed"`
-	FeePercent float64 `json:"fee_percent"`
+	Enabled     bool        `json:"enabled"`
+	AssetIssuer string      `json:"asset_issuer,omitempty"`
+	FeeFixed    json.Number `json:"fee_fixed,omitempty"`
+	FeePercent  json.Number `json:"fee_percent,omitempty"`
+	FeeMinimum  json.Number `json:"fee_minimum,omitempty"`
+	MinAmount   json.Number `json:"min_amount,omitempty"`
+	MaxAmount   json.Number `json:"max_amount,omitempty"`
+}
+
+type infoResponse struct {
+	Deposit  map[string]assetInfo `json:"deposit"`
+	Withdraw map[string]assetInfo `json:"withdraw"`
+	Fee      feeInfo              `json:"fee"`
+	Features featureInfo          `json:"features"`
+}
+
+type feeInfo struct {
+	Enabled                bool `json:"enabled"`
+	AuthenticationRequired bool `json:"authentication_required"`
+}
+
+type featureInfo struct {
+	AccountCreation   bool `json:"account_creation"`
+	ClaimableBalances bool `json:"claimable_balances"`
 }
 
 func (s *Service) handleInfo(w http.ResponseWriter, r *http.Request) {
@@ -13,24 +40,56 @@ func (s *Service) handleInfo(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	writeJSON(w, http.StatusOK, s.info())
+}
 
-	deposit := map[string]assetInfo{}
-	withdraw := map[string]assetInfo{}
+func (s *Service) info() infoResponse {
+	out := infoResponse{
+		Deposit:  map[string]assetInfo{},
+		Withdraw: map[string]assetInfo{},
+		Fee:      feeInfo{Enabled: true, AuthenticationRequired: true},
+		Features: featureInfo{AccountCreation: false, ClaimableBalances: true},
+	}
+	codes := map[string]int{}
 	for _, asset := range s.Config.Assets {
-		info := assetInfo{
-			Enabled:    asset.Enabled,
-			FeeFixed:   asset.FeeFixed,
-			FeePercent: asset.FeePercent,
+		if asset.ID().IsStellar() {
+			codes[asset.Code]++
 		}
-		deposit[asset.Code] = info
-		withdraw[asset.Code] = info
 	}
+	for _, asset := range s.Config.Assets {
+		if !asset.ID().IsStellar() {
+			continue
+		}
+		key := asset.Code
+		if codes[asset.Code] > 1 {
+			key = asset.ID().String()
+		}
+		out.Deposit[key] = s.assetInfo(asset, fees.OperationDeposit)
+		out.Withdraw[key] = s.assetInfo(asset, fees.OperationWithdraw)
+	}
+	return out
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"deposit":  deposit,
-		"withdraw": withdraw,
-		"fee": map[string]bool{
-			"enabled": true,
-		},
-	})
+func (s *Service) assetInfo(asset config.Asset, operation string) assetInfo {
+	if !asset.OperationEnabled(operation) {
+		return assetInfo{Enabled: false}
+	}
+	info := assetInfo{Enabled: true, AssetIssuer: asset.Issuer}
+	limits := asset.Operation(operation)
+	if !limits.MinAmount.IsZero() {
+		info.MinAmount = json.Number(limits.MinAmount.String())
+	}
+	if !limits.MaxAmount.IsZero() {
+		info.MaxAmount = json.Number(limits.MaxAmount.String())
+	}
+	summary, ok := s.Fees.Summary(operation, asset.ID())
+	if !ok {
+		return info
+	}
+	info.FeeFixed = json.Number(summary.Fixed.String())
+	info.FeePercent = json.Number(summary.Percent.String())
+	if !summary.Minimum.IsZero() {
+		info.FeeMinimum = json.Number(summary.Minimum.String())
+	}
+	return info
 }
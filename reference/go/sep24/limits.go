@@ -0,0 +1,20 @@
+package sep24
+
+import (
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+var (
+	ErrAmountTooSmall = transfer.ErrAmountTooSmall
+	ErrAmountTooLarge = transfer.ErrAmountTooLarge
+)
+
+func (s *Service) checkAmountLimits(asset config.Asset, kind string, amount decimal.Decimal) error {
+	return transfer.CheckLimits(asset, kind, amount, s.Config.RoundingMode)
+}
+
+func limitStatus(err error) string {
+	return transfer.LimitStatus(err)
+}
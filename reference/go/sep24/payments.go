@@ -0,0 +1,60 @@
+package sep24
+
+import (
+	"context"
+	"log"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/observer"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+func (s *Service) HandlePayment(ctx context.Context, p observer.Payment) error {
+	if p.Memo == "" {
+		return nil
+	}
+	tx, ok := s.TxStore.GetByMemo(p.Memo, p.MemoType)
+	if !ok {
+		return nil
+	}
+	if tx.Protocol == transfer.ProtocolSEP31 {
+		return nil
+	}
+	late := tx.Kind == "withdraw" && tx.Status == StatusExpired
+	if tx.Kind != "withdraw" || (tx.Status != StatusPendingUserTransferStart && !late) {
+		log.Printf("sep24: ignoring payment %s for transaction %s in status %s", p.ID, tx.ID, tx.Status)
+		return nil
+	}
+	if p.AssetCode != tx.AssetCode || (tx.AssetIssuer != "" && p.AssetIssuer != tx.AssetIssuer) {
+		log.Printf("sep24: payment %s asset %s does not match transaction %s asset %s", p.ID, p.AssetCode, tx.ID, tx.AssetCode)
+		return nil
+	}
+
+	received, err := decimal.Parse(p.Amount)
+	if err != nil || received.Sign() <= 0 {
+		log.Printf("sep24: payment %s has invalid amount %q", p.ID, p.Amount)
+		return nil
+	}
+
+	next, reason := StatusPendingAnchor, "withdrawal payment received"
+	if late {
+		log.Printf("sep24: payment %s for transaction %s arrived after it expired", p.ID, tx.ID)
+		next, reason = StatusError, "payment received after the transaction expired; refund or complete it manually"
+	} else if err := s.checkAmountLimits(s.txAsset(tx), tx.Kind, received); err != nil {
+		log.Printf("sep24: payment %s for transaction %s rejected: %v", p.ID, tx.ID, err)
+		next, reason = limitStatus(err), err.Error()
+	} else if !tx.Amount.IsZero() && !received.Equal(tx.Amount) {
+		log.Printf("sep24: payment %s amount %s does not match transaction %s amount %s", p.ID, p.Amount, tx.ID, tx.Amount)
+		next, reason = StatusError, "payment amount does not match the transaction amount"
+	}
+	if err := ValidateTransition(tx.Status, next); err != nil {
+		return err
+	}
+
+	s.applyStatus(&tx, next)
+	tx.StellarTransactionID = p.TransactionHash
+	tx.AmountIn = received
+	_, err = s.update(tx, db.Audit{Actor: p.From, Source: db.SourceObserver, Reason: reason})
+	return err
+}
@@ -0,0 +1,31 @@
+package sep24
+
+import (
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+var ErrInvalidQuote = transfer.ErrInvalidQuote
+
+func (s *Service) applyQuote(tx *db.Transaction, req InteractiveRequest, asset config.Asset) error {
+	offchain := req.SourceAsset
+	if tx.Kind == "withdraw" {
+		offchain = req.DestinationAsset
+	}
+	return transfer.ApplyQuote(s.Quotes, tx, transfer.QuoteRequest{
+		ID:            req.QuoteID,
+		Context:       transfer.ProtocolSEP24,
+		Asset:         asset,
+		OffchainAsset: offchain,
+		Now:           s.Now(),
+	}, s.Config.RoundingMode)
+}
+
+func (s *Service) createTransaction(tx db.Transaction, audit db.Audit) error {
+	return transfer.CreateTransaction(s.TxStore, s.Quotes, tx, audit)
+}
+
+func (s *Service) assetForID(raw string, fallback config.Asset) config.Asset {
+	return transfer.AssetForID(s.Config.Assets, raw, fallback)
+}
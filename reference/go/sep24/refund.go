@@ -0,0 +1,79 @@
+package sep24
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrInvalidRefund       = transfer.ErrInvalidRefund
+)
+
+type RefundRequest struct {
+	TransactionID string          `json:"transaction_id"`
+	ID            string          `json:"id"`
+	IDType        string          `json:"id_type"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+}
+
+func (s *Service) RegisterAdminRoutes(mux *http.ServeMux, adminMiddleware func(http.Handler) http.Handler) {
+	mux.Handle("/admin/sep24/refunds", adminMiddleware(http.HandlerFunc(s.handleRefund)))
+}
+
+func (s *Service) handleRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	defer r.Body.Close()
+
+	var req RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json request")
+		return
+	}
+
+	tx, err := s.Refund(req)
+	switch {
+	case errors.Is(err, ErrTransactionNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, ErrInvalidRefund):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": s.toSEP24Transaction(tx)})
+}
+
+func (s *Service) Refund(req RefundRequest) (db.Transaction, error) {
+	tx, ok := s.TxStore.GetByID(req.TransactionID)
+	if !ok || tx.Protocol != transfer.ProtocolSEP24 {
+		return db.Transaction{}, ErrTransactionNotFound
+	}
+	payment := db.RefundPayment{ID: req.ID, IDType: req.IDType, Amount: req.Amount, Fee: req.Fee}
+	full, err := transfer.ApplyRefund(&tx, payment, s.txAsset(tx), s.Config.RoundingMode)
+	if err != nil {
+		return tx, err
+	}
+	audit := db.Audit{Source: db.SourceAdmin, Reason: "refund sent"}
+	if full {
+		return s.transition(tx, StatusRefunded, audit)
+	}
+	tx.UpdatedAt = s.Now()
+	return s.update(tx, audit)
+}
+
+func (s *Service) renderRefunds(asset config.Asset, refunds db.Refunds) map[string]any {
+	return transfer.RenderRefunds(asset, refunds, s.Config.RoundingMode)
+}
@@ -1,44 +1,55 @@
 package sep24
 
-import "fmt"
+import (
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
 
 const (
-	StatusIncomplete               = "incomplete"
-	StatusPendingUserTransferStart = "pending_user_transfer_start"
-	StatusPendingAnchor            = "pending_anchor"
-	StatusPendingStellar           = "pending_stellar"
-	StatusCompleted                = "completed"
-	StatusError                    = "error"
-	StatusExpired                  = "expired"
+	StatusIncomplete               = transfer.StatusIncomplete
+	StatusPendingUserTransferStart = transfer.StatusPendingUserTransferStart
+	StatusPendingAnchor            = transfer.StatusPendingAnchor
+	StatusPendingStellar           = transfer.StatusPendingStellar
+	StatusPendingTrust             = transfer.StatusPendingTrust
+	StatusCompleted                = transfer.StatusCompleted
+	StatusRefunded                 = transfer.StatusRefunded
+	StatusError                    = transfer.StatusError
+	StatusExpired                  = transfer.StatusExpired
+	StatusTooSmall                 = transfer.StatusTooSmall
+	StatusTooLarge                 = transfer.StatusTooLarge
 )
 
-var allowedTransitions = map[string]map[string]bool{
-	StatusIncomplete: {
-		StatusPendingUserTransferStart: true,
-		StatusExpired:                  true,
-		StatusError:                    true,
-	},
-	StatusPendingUserTransferStart: {
-		StatusPendingAnchor: true,
-		StatusExpired:       true,
-		StatusError:         true,
-	},
-	StatusPendingAnchor: {
-		StatusPendingStellar: true,
-		StatusError:          true,
-	},
-	StatusPendingStellar: {
-		StatusCompleted: true,
-		StatusError:     true,
-	},
+// statusMessages is the message shown to wallets for a status when the
+// anchor has not given one.
+var statusMessages = map[string]string{
+	StatusIncomplete:               "Complete the interactive flow to continue.",
+	StatusPendingUserTransferStart: "Waiting for you to send the funds.",
+	StatusPendingAnchor:            "The anchor is processing the transaction.",
+	StatusPendingStellar:           "Waiting for the Stellar payment to be confirmed.",
+	StatusPendingTrust:             "Add a trustline for the asset to receive the deposit.",
+	StatusCompleted:                "The transaction is complete.",
+	StatusRefunded:                 "The funds were refunded.",
+	StatusError:                    "The transaction could not be completed.",
+	StatusExpired:                  "The transaction expired before it was completed.",
+	StatusTooSmall:                 "The amount is below the minimum for this asset.",
+	StatusTooLarge:                 "The amount is above the maximum for this asset.",
 }
 
 func ValidateTransition(from, to string) error {
-	if from == to {
-		return nil
-	}
-	if next, ok := allowedTransitions[from]; ok && next[to] {
-		return nil
+	return transfer.ValidateTransition(from, to)
+}
+
+func (s *Service) transition(tx db.Transaction, status string, audit db.Audit) (db.Transaction, error) {
+	if err := ValidateTransition(tx.Status, status); err != nil {
+		return tx, err
 	}
-	return fmt.Errorf("invalid transition from %s to %s", from, to)
+	s.applyStatus(&tx, status)
+	return s.update(tx, audit)
+}
+
+func (s *Service) applyStatus(tx *db.Transaction, status string) {
+	now := s.Now()
+	tx.Status = status
+	tx.UpdatedAt = now
+	tx.UserActionRequiredBy = s.actionDeadline(status, now)
 }
@@ -0,0 +1,171 @@
+package sep24
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/submitter"
+)
+
+const depositBatchSize = 50
+
+// paymentExpiryGrace allows for Horizon ingesting a ledger after it closed
+// before an unseen payment is treated as expired.
+const paymentExpiryGrace = time.Minute
+
+func (s *Service) SubmitDeposit(ctx context.Context, id string) (db.Transaction, error) {
+	if s.Payments == nil {
+		return db.Transaction{}, fmt.Errorf("payment submitter is not configured")
+	}
+	tx, ok := s.TxStore.GetByID(id)
+	if !ok {
+		return db.Transaction{}, fmt.Errorf("transaction %s not found", id)
+	}
+	if tx.Kind != "deposit" {
+		return tx, fmt.Errorf("transaction %s is not a deposit", id)
+	}
+	if tx.Status != StatusPendingAnchor && tx.Status != StatusPendingTrust {
+		return tx, fmt.Errorf("transaction %s is in status %s", id, tx.Status)
+	}
+	asset, ok := s.assetConfig(tx.AssetCode, tx.AssetIssuer)
+	if !ok {
+		return tx, fmt.Errorf("asset %s is not supported", tx.AssetCode)
+	}
+
+	amount := tx.AmountOut
+	if amount.Sign() <= 0 {
+		return tx, fmt.Errorf("transaction %s has no amount to pay out", id)
+	}
+	received := tx.AmountIn
+	if received.IsZero() {
+		received = tx.Amount
+	}
+	if tx.QuoteID != "" {
+		received = amount
+	}
+	if err := s.checkAmountLimits(asset, tx.Kind, received); err != nil {
+		log.Printf("sep24: deposit %s not submitted: %v", id, err)
+		return s.transition(tx, limitStatus(err), db.Audit{Source: db.SourceSubmitter, Reason: err.Error()})
+	}
+	if err := ValidateTransition(tx.Status, StatusPendingStellar); err != nil {
+		return tx, err
+	}
+	s.payouts.Lock()
+	defer s.payouts.Unlock()
+	payment, err := s.Payments.Prepare(ctx, submitter.PaymentRequest{
+		Destination:               tx.To,
+		AssetCode:                 asset.Code,
+		AssetIssuer:               asset.Issuer,
+		Amount:                    s.formatAmount(asset, amount),
+		ClaimableBalanceSupported: tx.ClaimableBalanceSupported,
+	})
+	if errors.Is(err, submitter.ErrNoTrustline) {
+		if tx.Status == StatusPendingTrust {
+			return tx, nil
+		}
+		return s.transition(tx, StatusPendingTrust, db.Audit{Source: db.SourceSubmitter, Reason: "waiting for the destination account to trust the asset"})
+	}
+	if err != nil {
+		return tx, err
+	}
+
+	s.applyStatus(&tx, StatusPendingStellar)
+	tx.StellarTransactionID = payment.Hash
+	tx.ClaimableBalanceID = payment.ClaimableBalanceID
+	tx.PaymentEnvelope = payment.Envelope
+	tx.PaymentValidUntil = payment.ValidUntil
+	tx, err = s.update(tx, db.Audit{Source: db.SourceSubmitter, Reason: "deposit payment signed"})
+	if err != nil {
+		s.Payments.Release(payment)
+		return tx, err
+	}
+	if err := s.Payments.Submit(ctx, payment.Envelope); err != nil {
+		log.Printf("sep24: deposit %s will be resubmitted: %v", id, err)
+	}
+	return tx, nil
+}
+
+func (s *Service) ConfirmDeposit(ctx context.Context, id string) (db.Transaction, error) {
+	if s.Payments == nil {
+		return db.Transaction{}, fmt.Errorf("payment submitter is not configured")
+	}
+	tx, ok := s.TxStore.GetByID(id)
+	if !ok {
+		return db.Transaction{}, fmt.Errorf("transaction %s not found", id)
+	}
+	if tx.Status != StatusPendingStellar || tx.StellarTransactionID == "" {
+		return tx, fmt.Errorf("transaction %s has no pending stellar payment", id)
+	}
+
+	status, err := s.Payments.Status(ctx, tx.StellarTransactionID)
+	if err != nil {
+		return tx, err
+	}
+	switch status {
+	case submitter.StatusSuccess:
+		return s.transition(tx, StatusCompleted, db.Audit{Source: db.SourceSubmitter, Reason: "deposit payment confirmed"})
+	case submitter.StatusFailed:
+		return s.transition(tx, StatusError, db.Audit{Source: db.SourceSubmitter, Reason: "deposit payment failed"})
+	}
+	if tx.PaymentEnvelope == "" {
+		return tx, nil
+	}
+	// The payment is not in a ledger yet. Past its time bounds it never
+	// will be; until then the recorded envelope is resubmitted.
+	if !tx.PaymentValidUntil.IsZero() && s.Now().After(tx.PaymentValidUntil.Add(paymentExpiryGrace)) {
+		return s.transition(tx, StatusError, db.Audit{Source: db.SourceSubmitter, Reason: "deposit payment expired before it was included in a ledger"})
+	}
+	if err := s.Payments.Submit(ctx, tx.PaymentEnvelope); err != nil {
+		log.Printf("sep24: resubmit deposit %s: %v", id, err)
+	}
+	return tx, nil
+}
+
+func (s *Service) ProcessDeposits(ctx context.Context) {
+	if !s.PlatformPayouts {
+		s.eachDeposit(ctx, StatusPendingAnchor, s.SubmitDeposit, "submit")
+		s.eachDeposit(ctx, StatusPendingTrust, s.SubmitDeposit, "submit")
+	}
+	s.eachDeposit(ctx, StatusPendingStellar, s.ConfirmDeposit, "confirm")
+}
+
+// eachDeposit pages through the deposits in status, oldest update first.
+// Deposits updated after the pass started are left for the next tick so
+// rows the pass itself rewrites are not visited twice.
+func (s *Service) eachDeposit(ctx context.Context, status string, step func(context.Context, string) (db.Transaction, error), action string) {
+	started := s.Now()
+	query := db.StatusQuery{Status: status, Kinds: []string{"deposit"}, Limit: depositBatchSize}
+	for {
+		batch := s.TxStore.ListByStatus(query)
+		for _, tx := range batch {
+			if tx.UpdatedAt.After(started) {
+				return
+			}
+			if _, err := step(ctx, tx.ID); err != nil {
+				log.Printf("sep24: %s deposit %s: %v", action, tx.ID, err)
+			}
+		}
+		if len(batch) < depositBatchSize {
+			return
+		}
+		last := batch[len(batch)-1]
+		query.After = db.StatusCursor{UpdatedAt: last.UpdatedAt, ID: last.ID}
+	}
+}
+
+func (s *Service) RunDepositWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ProcessDeposits(ctx)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package sep24
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+)
+
+// InteractiveTokenTTL bounds how long an interactive URL accepts input.
+const InteractiveTokenTTL = 15 * time.Minute
+
+var errInvalidInteractiveToken = errors.New("interactive session is invalid or has expired")
+
+// interactiveURL is tx.URL with a token binding it to the transaction.
+func (s *Service) interactiveURL(tx db.Transaction) string {
+	expires := strconv.FormatInt(s.Now().Add(InteractiveTokenTTL).Unix(), 10)
+	return tx.URL + "&token=" + url.QueryEscape(expires+"."+s.interactiveMAC(tx.ID, expires))
+}
+
+func (s *Service) verifyInteractiveToken(id, token string) error {
+	expires, mac, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(s.interactiveMAC(id, expires))) {
+		return errInvalidInteractiveToken
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.Now().Unix() > unix {
+		return errInvalidInteractiveToken
+	}
+	return nil
+}
+
+func (s *Service) interactiveMAC(id, expires string) string {
+	h := hmac.New(sha256.New, []byte(s.Config.JWTSecret))
+	h.Write([]byte("sep24-interactive:" + id + ":" + expires))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
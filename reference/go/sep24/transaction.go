@@ -1,16 +1,18 @@
 package sep24
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"cmp"
+	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/stellar/sep-reference/reference/go/internal/config"
 	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
 )
 
 func (s *Service) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
@@ -33,8 +35,12 @@ func (s *Service) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, ok := s.findTransactionForAccount(account, id, externalID, stellarID)
-	if !ok {
+	tx, err := transfer.FindTransaction(s.TxStore, account, transfer.ProtocolSEP24, transfer.Lookup{ID: id, ExternalTransactionID: externalID, StellarTransactionID: stellarID})
+	if errors.Is(err, transfer.ErrConflictingIdentifiers) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
 		writeError(w, http.StatusNotFound, "transaction not found")
 		return
 	}
@@ -61,11 +67,12 @@ func (s *Service) handleListTransactions(w http.ResponseWriter, r *http.Request)
 			writeError(w, http.StatusBadRequest, "invalid limit")
 			return
 		}
-		limit = parsed
+		limit = min(parsed, transfer.MaxListLimit)
 	}
 
 	assetCode := strings.TrimSpace(r.URL.Query().Get("asset_code"))
-	if assetCode != "" && !s.assetSupported(assetCode) {
+	assetIssuer := strings.TrimSpace(r.URL.Query().Get("asset_issuer"))
+	if assetCode != "" && !s.knownAsset(assetCode, assetIssuer) {
 		writeError(w, http.StatusBadRequest, "unsupported asset")
 		return
 	}
@@ -86,108 +93,65 @@ func (s *Service) handleListTransactions(w http.ResponseWriter, r *http.Request)
 		noOlderThan = parsed
 	}
 
-	all := s.TxStore.ListByAccount(account, 0, "")
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].StartedAt.After(all[j].StartedAt)
-	})
-
-	transactions := make([]map[string]any, 0, len(all))
-	for _, tx := range all {
-		mapped := s.toSEP24Transaction(tx)
-		if assetCode != "" && tx.AssetCode != assetCode {
-			continue
-		}
-		if kindFilter != "" && mapped["kind"] != kindFilter {
-			continue
-		}
-		if !noOlderThan.IsZero() && tx.StartedAt.Before(noOlderThan) {
-			continue
+	pagingID := strings.TrimSpace(r.URL.Query().Get("paging_id"))
+	if pagingID != "" {
+		if _, err := transfer.FindTransaction(s.TxStore, account, transfer.ProtocolSEP24, transfer.Lookup{ID: pagingID}); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid paging_id")
+			return
 		}
-		transactions = append(transactions, mapped)
 	}
 
-	if limit < len(transactions) {
-		transactions = transactions[:limit]
+	query := db.TransactionQuery{
+		Account:     account,
+		Protocol:    transfer.ProtocolSEP24,
+		AssetCode:   assetCode,
+		AssetIssuer: assetIssuer,
+		NoOlderThan: noOlderThan,
+		Cursor:      pagingID,
+		Limit:       limit,
+	}
+	switch kindFilter {
+	case "deposit":
+		query.Kinds = []string{"deposit"}
+	case "withdrawal":
+		query.Kinds = []string{"withdraw", "withdrawal"}
+	}
+
+	page := s.TxStore.ListByAccount(query)
+	transactions := make([]map[string]any, 0, len(page))
+	for _, tx := range page {
+		transactions = append(transactions, s.toSEP24Transaction(tx))
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"transactions": transactions})
 }
 
-func (s *Service) handleGetFee(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-	operation := r.URL.Query().Get("operation")
-	assetCode := r.URL.Query().Get("asset_code")
-	amountRaw := r.URL.Query().Get("amount")
-	if operation == "" || assetCode == "" || amountRaw == "" {
-		writeError(w, http.StatusBadRequest, "missing required query params")
-		return
-	}
-	if operation != "deposit" && operation != "withdraw" {
-		writeError(w, http.StatusBadRequest, "invalid operation")
-		return
-	}
-	asset, ok := s.assetConfig(assetCode)
-	if !ok {
-		writeError(w, http.StatusBadRequest, "unsupported asset")
-		return
-	}
-	amount, err := strconv.ParseFloat(amountRaw, 64)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid amount")
-		return
-	}
-	fee := asset.FeeFixed + (amount * asset.FeePercent / 100.0)
-	writeJSON(w, http.StatusOK, map[string]float64{"fee": fee})
+func (s *Service) operationAsset(code, issuer, kind string) (config.Asset, bool) {
+	return transfer.OperationAsset(s.Config.Assets, code, issuer, kind)
 }
 
-func (s *Service) assetSupported(code string) bool {
-	_, ok := s.assetConfig(code)
-	return ok
+func (s *Service) assetConfig(code, issuer string) (config.Asset, bool) {
+	return transfer.FindAsset(s.Config.Assets, code, issuer)
 }
 
-func (s *Service) assetConfig(code string) (asset struct {
-	Code       string
-	Enabled    bool
-	FeeFixed   float64
-	FeePercent float64
-}, ok bool) {
+func (s *Service) knownAsset(code, issuer string) bool {
 	for _, a := range s.Config.Assets {
-		if a.Code == code && a.Enabled {
-			return struct {
-				Code       string
-				Enabled    bool
-				FeeFixed   float64
-				FeePercent float64
-			}{a.Code, a.Enabled, a.FeeFixed, a.FeePercent}, true
+		if a.ID().IsStellar() && a.Matches(code, issuer) {
+			return true
 		}
 	}
-	return asset, false
+	return false
 }
 
-func formatAmount(value float64) string {
-	return strconv.FormatFloat(value, 'f', 2, 64)
+func (s *Service) txAsset(tx db.Transaction) config.Asset {
+	return transfer.TxAsset(s.Config.Assets, tx)
 }
 
-func transactionID(prefix, account, asset string, t time.Time) string {
-	h := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%s|%d", prefix, account, asset, t.UnixNano())))
-	return prefix + "-" + hex.EncodeToString(h[:8])
+func (s *Service) formatAmount(asset config.Asset, value decimal.Decimal) string {
+	return transfer.FormatAmount(asset, value, s.Config.RoundingMode)
 }
 
-func (s *Service) findTransactionForAccount(account, id, externalID, stellarID string) (db.Transaction, bool) {
-	for _, tx := range s.TxStore.ListByAccount(account, 0, "") {
-		if id != "" && tx.ID == id {
-			return tx, true
-		}
-		if externalID != "" && tx.ExternalTransactionID == externalID {
-			return tx, true
-		}
-		if stellarID != "" && tx.StellarTransactionID == stellarID {
-			return tx, true
-		}
-	}
-	return db.Transaction{}, false
+func parseAmount(asset config.Asset, raw string) (decimal.Decimal, error) {
+	return transfer.ParseAmount(asset, raw)
 }
 
 func (s *Service) toSEP24Transaction(tx db.Transaction) map[string]any {
@@ -206,6 +170,8 @@ func (s *Service) toSEP24Transaction(tx db.Transaction) map[string]any {
 		moreInfoURL = fmt.Sprintf("http://%s/sep24/interactive/status?id=%s", s.Config.HomeDomain, tx.ID)
 	}
 
+	asset := s.txAsset(tx)
+	assetID := asset.ID().String()
 	out := map[string]any{
 		"id":            tx.ID,
 		"kind":          kind,
@@ -215,11 +181,59 @@ func (s *Service) toSEP24Transaction(tx db.Transaction) map[string]any {
 		"updated_at":    tx.UpdatedAt,
 		"asset_code":    tx.AssetCode,
 	}
-	if tx.Amount != "" {
-		out["amount_in"] = tx.Amount
-		out["amount_out"] = tx.Amount
-		out["amount_in_asset"] = tx.AssetCode
-		out["amount_out_asset"] = tx.AssetCode
+	if tx.AssetIssuer != "" {
+		out["asset_issuer"] = tx.AssetIssuer
+	}
+	inAsset, inID := asset, assetID
+	if tx.AmountInAsset != "" {
+		inAsset, inID = s.assetForID(tx.AmountInAsset, asset), tx.AmountInAsset
+	}
+	outAsset, outID := asset, assetID
+	if tx.AmountOutAsset != "" {
+		outAsset, outID = s.assetForID(tx.AmountOutAsset, asset), tx.AmountOutAsset
+	}
+	feeAsset, feeID := asset, assetID
+	if tx.FeeDetails != nil && tx.FeeDetails.Asset != "" {
+		feeAsset, feeID = s.assetForID(tx.FeeDetails.Asset, asset), tx.FeeDetails.Asset
+	}
+	if !tx.Amount.IsZero() {
+		out["amount_in"] = s.formatAmount(inAsset, tx.Amount)
+		out["amount_in_asset"] = inID
+		if tx.AmountOutAsset == "" {
+			out["amount_out"] = s.formatAmount(outAsset, tx.Amount)
+			out["amount_out_asset"] = outID
+		}
+	}
+	if !tx.AmountIn.IsZero() {
+		out["amount_in"] = s.formatAmount(inAsset, tx.AmountIn)
+		out["amount_in_asset"] = inID
+	}
+	if !tx.AmountOut.IsZero() {
+		out["amount_out"] = s.formatAmount(outAsset, tx.AmountOut)
+		out["amount_out_asset"] = outID
+	}
+	if !tx.AmountFee.IsZero() {
+		out["amount_fee"] = s.formatAmount(feeAsset, tx.AmountFee)
+		out["amount_fee_asset"] = feeID
+	}
+	if tx.FeeDetails != nil {
+		out["fee_details"] = s.renderFeeDetails(feeAsset, *tx.FeeDetails)
+	}
+	if tx.QuoteID != "" {
+		out["quote_id"] = tx.QuoteID
+	}
+	if tx.Refunds != nil {
+		out["refunds"] = s.renderRefunds(asset, *tx.Refunds)
+		out["refunded"] = status == StatusRefunded
+	}
+	if !tx.UserActionRequiredBy.IsZero() {
+		out["user_action_required_by"] = tx.UserActionRequiredBy
+	}
+	if completedAt := transfer.CompletedAt(s.TxStore, tx); !completedAt.IsZero() {
+		out["completed_at"] = completedAt
+	}
+	if message := cmp.Or(tx.Message, statusMessages[status]); message != "" {
+		out["message"] = message
 	}
 	if tx.StellarTransactionID != "" {
 		out["stellar_transaction_id"] = tx.StellarTransactionID
@@ -233,6 +247,9 @@ func (s *Service) toSEP24Transaction(tx db.Transaction) map[string]any {
 		if tx.From != "" {
 			out["from"] = tx.From
 		}
+		if tx.ClaimableBalanceID != "" {
+			out["claimable_balance_id"] = tx.ClaimableBalanceID
+		}
 		return out
 	}
 
@@ -240,5 +257,12 @@ func (s *Service) toSEP24Transaction(tx db.Transaction) map[string]any {
 	if tx.To != "" {
 		out["to"] = tx.To
 	}
+	if tx.WithdrawAnchorAccount != "" {
+		out["withdraw_anchor_account"] = tx.WithdrawAnchorAccount
+	}
+	if tx.WithdrawMemo != "" {
+		out["withdraw_memo"] = tx.WithdrawMemo
+		out["withdraw_memo_type"] = tx.WithdrawMemoType
+	}
 	return out
 }
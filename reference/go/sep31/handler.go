@@ -0,0 +1,95 @@
+package sep31
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// SEP-12 customer types a sending anchor registers through KYC_SERVER.
+const (
+	CustomerTypeSender   = "sep31-sender"
+	CustomerTypeReceiver = "sep31-receiver"
+)
+
+type Service struct {
+	Config    config.Config
+	TxStore   db.TransactionStore
+	Customers db.CustomerStore
+	Quotes    db.QuoteStore
+	Memos     memo.Allocator
+	Fees      fees.Calculator
+	Callbacks *callback.Dispatcher
+	Now       func() time.Time
+	NewID     func() string
+}
+
+func NewService(cfg config.Config, txStore db.TransactionStore, customers db.CustomerStore) *Service {
+	return &Service{
+		Config:    cfg,
+		TxStore:   txStore,
+		Customers: customers,
+		Memos:     transfer.NewMemoAllocator(cfg, txStore),
+		Fees:      fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, fees.RulesFromAssets(cfg.Assets)),
+		Now:       func() time.Time { return time.Now().UTC() },
+		NewID:     transfer.NewUUID,
+	}
+}
+
+func (s *Service) RegisterRoutes(mux *http.ServeMux, authMiddleware func(http.Handler) http.Handler) {
+	auth := func(h http.HandlerFunc) http.Handler {
+		return authMiddleware(s.requireClientDomain(h))
+	}
+	mux.HandleFunc("/sep31/info", s.handleInfo)
+	mux.Handle("/sep31/transactions", auth(s.handlePostTransaction))
+	mux.Handle("/sep31/transactions/", auth(s.handleTransaction))
+}
+
+// requireClientDomain only admits sending anchors that authenticated with a
+// SEP-10 client_domain, restricted to SEP31_CLIENT_DOMAINS when it is set.
+func (s *Service) requireClientDomain(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain := middleware.ClientDomainFromContext(r.Context())
+		if domain == "" {
+			writeError(w, http.StatusForbidden, "client_domain is required")
+			return
+		}
+		if len(s.Config.SEP31ClientDomains) > 0 && !slices.Contains(s.Config.SEP31ClientDomains, domain) {
+			writeError(w, http.StatusForbidden, "client_domain is not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Service) actionDeadline(status string, from time.Time) time.Time {
+	timeout, ok := s.Config.ExpireAfter[status]
+	if !ok || timeout <= 0 {
+		return time.Time{}
+	}
+	return from.Add(timeout)
+}
+
+func (s *Service) formatAmount(asset config.Asset, value decimal.Decimal) string {
+	return transfer.FormatAmount(asset, value, s.Config.RoundingMode)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
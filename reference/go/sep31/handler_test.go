@@ -0,0 +1,212 @@
+package sep31
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/sep-reference/reference/go/internal/callback"
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/observer"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+	"github.com/stellar/sep-reference/reference/go/sep10"
+	"github.com/stellar/sep-reference/reference/go/sep12"
+)
+
+const (
+	testAccount      = "GSENDINGANCHORAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	testClientDomain = "sender.example.com"
+)
+
+func TestClientDomainRequired(t *testing.T) {
+	service, mux := testServiceAndMux()
+	body := TransactionRequest{AssetCode: "USDC", Amount: "100", SenderID: "sender", ReceiverID: "receiver"}
+
+	post(t, mux, testToken(t, testAccount, ""), body, http.StatusForbidden, nil)
+	service.Config.SEP31ClientDomains = []string{"other.example.com"}
+	post(t, mux, testToken(t, testAccount, testClientDomain), body, http.StatusForbidden, nil)
+}
+
+func TestTransactionLifecycle(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount, testClientDomain)
+	addCustomer(t, service, "sender", testAccount, sep12.StatusAccepted)
+	addCustomer(t, service, "receiver", testAccount, sep12.StatusNeedsInfo)
+	addCustomer(t, service, "foreign", "GOTHER", sep12.StatusAccepted)
+
+	var info infoResponse
+	request(t, mux, http.MethodGet, "/sep31/info", "", nil, http.StatusOK, &info)
+	usdc, ok := info.Receive["USDC"]
+	if !ok || usdc.FeeFixed != "1.0" || usdc.SEP12.Receiver.Types[CustomerTypeReceiver].Description == "" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	var failure map[string]string
+	post(t, mux, token, TransactionRequest{AssetCode: "USDC", Amount: "100", SenderID: "sender", ReceiverID: "receiver"}, http.StatusBadRequest, &failure)
+	if failure["error"] != "customer_info_needed" || failure["type"] != CustomerTypeReceiver {
+		t.Fatalf("expected receiver info request, got %+v", failure)
+	}
+	post(t, mux, token, TransactionRequest{AssetCode: "USDC", Amount: "100", SenderID: "foreign", ReceiverID: "sender"}, http.StatusBadRequest, &failure)
+	if failure["type"] != CustomerTypeSender {
+		t.Fatalf("expected another anchor's customer to be rejected, got %+v", failure)
+	}
+
+	addCustomer(t, service, "receiver", testAccount, sep12.StatusAccepted)
+	var created transactionResponse
+	post(t, mux, token, TransactionRequest{AssetCode: "USDC", Amount: "100", SenderID: "sender", ReceiverID: "receiver"}, http.StatusCreated, &created)
+	if created.StellarAccountID != service.Config.DistributionAccount || created.StellarMemoType != memo.TypeID || created.StellarMemo == "" {
+		t.Fatalf("unexpected create response: %+v", created)
+	}
+
+	tx := getTransaction(t, mux, token, created.ID)
+	if tx["status"] != transfer.StatusPendingSender || tx["amount_in"] != "100.00" || tx["amount_out"] != "98.90" {
+		t.Fatalf("unexpected transaction: %+v", tx)
+	}
+	request(t, mux, http.MethodGet, "/sep31/transactions/"+created.ID, testToken(t, "GOTHER", testClientDomain), nil, http.StatusNotFound, nil)
+
+	callbacks := make(chan map[string]any, 1)
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Transaction map[string]any `json:"transaction"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		callbacks <- body.Transaction
+	}))
+	defer callbackServer.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.Callbacks.Run(ctx)
+	request(t, mux, http.MethodPut, "/sep31/transactions/"+created.ID+"/callback", token, map[string]string{"url": "ftp://bad"}, http.StatusBadRequest, nil)
+	request(t, mux, http.MethodPut, "/sep31/transactions/"+created.ID+"/callback", token, map[string]string{"url": callbackServer.URL}, http.StatusNoContent, nil)
+
+	payment := observer.Payment{ID: "p1", TransactionHash: "hash-1", AssetCode: "USDC", Amount: "100", Memo: created.StellarMemo, MemoType: created.StellarMemoType}
+	if err := service.HandlePayment(context.Background(), payment); err != nil {
+		t.Fatalf("handle payment: %v", err)
+	}
+	select {
+	case notified := <-callbacks:
+		if notified["status"] != transfer.StatusPendingReceiver || notified["stellar_transaction_id"] != "hash-1" {
+			t.Fatalf("unexpected callback: %+v", notified)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a status callback")
+	}
+	if stored, _ := service.TxStore.GetByID(created.ID); stored.ClientDomain != testClientDomain || stored.Status != transfer.StatusPendingReceiver {
+		t.Fatalf("unexpected stored transaction: %+v", stored)
+	}
+}
+
+func TestQuotedTransaction(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount, testClientDomain)
+	addCustomer(t, service, "sender", testAccount, sep12.StatusAccepted)
+	addCustomer(t, service, "receiver", testAccount, sep12.StatusAccepted)
+	quote := db.Quote{
+		ID:         "quote-1",
+		Account:    testAccount,
+		Context:    transfer.ProtocolSEP31,
+		SellAsset:  "stellar:USDC",
+		SellAmount: decimal.MustParse("100"),
+		BuyAsset:   "iso4217:BRL",
+		BuyAmount:  decimal.MustParse("495"),
+		Price:      decimal.MustParse("0.2"),
+		TotalPrice: decimal.MustParse("0.2020202"),
+		Fee:        db.FeeDetails{Total: decimal.MustParse("1"), Asset: "stellar:USDC"},
+		ExpiresAt:  time.Now().UTC().Add(time.Minute),
+	}
+	if err := service.Quotes.Create(quote); err != nil {
+		t.Fatalf("create quote: %v", err)
+	}
+
+	req := TransactionRequest{AssetCode: "USDC", Amount: "100", DestinationAsset: "iso4217:BRL", QuoteID: "quote-1", SenderID: "sender", ReceiverID: "receiver"}
+	var created transactionResponse
+	post(t, mux, token, req, http.StatusCreated, &created)
+	tx := getTransaction(t, mux, token, created.ID)
+	if tx["quote_id"] != "quote-1" || tx["amount_out"] != "495.0000000" || tx["amount_out_asset"] != "iso4217:BRL" {
+		t.Fatalf("unexpected quoted transaction: %+v", tx)
+	}
+	post(t, mux, token, req, http.StatusBadRequest, nil)
+}
+
+func addCustomer(t *testing.T, service *Service, id, account, status string) {
+	t.Helper()
+	if err := service.Customers.Put(db.Customer{ID: id, Account: account, Memo: id, Status: status}); err != nil {
+		t.Fatalf("put customer: %v", err)
+	}
+}
+
+func getTransaction(t *testing.T, mux *http.ServeMux, token, id string) map[string]any {
+	t.Helper()
+	var body struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	request(t, mux, http.MethodGet, "/sep31/transactions/"+id, token, nil, http.StatusOK, &body)
+	return body.Transaction
+}
+
+func post(t *testing.T, mux *http.ServeMux, token string, body TransactionRequest, want int, out any) {
+	t.Helper()
+	request(t, mux, http.MethodPost, "/sep31/transactions", token, body, want, out)
+}
+
+func request(t *testing.T, mux *http.ServeMux, method, path, token string, body any, want int, out any) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != want {
+		t.Fatalf("%s %s: expected %d, got %d body=%s", method, path, want, rec.Code, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+}
+
+func testToken(t *testing.T, account, clientDomain string) string {
+	t.Helper()
+	token, err := sep10.IssueToken(account, "localhost:8080", clientDomain, "localhost:8080", "jwt-secret", time.Now().UTC(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func testServiceAndMux() (*Service, *http.ServeMux) {
+	cfg := config.Config{
+		HomeDomain:          "localhost:8080",
+		JWTSecret:           "jwt-secret",
+		DistributionAccount: "GDISTRIBUTIONAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		WithdrawMemoType:    memo.TypeID,
+		Assets: []config.Asset{
+			{Code: "USDC", Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("1.0"), FeePercent: decimal.MustParse("0.1")},
+		},
+	}
+	service := NewService(cfg, db.NewMemoryTransactionStore(), db.NewMemoryCustomerStore())
+	service.Quotes = db.NewMemoryQuoteStore()
+	service.Callbacks, _ = callback.NewDispatcher(keypair.MustRandom().Seed(), callback.NewMemoryDeadLetters())
+
+	mux := http.NewServeMux()
+	service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	return service, mux
+}
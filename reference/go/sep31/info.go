@@ -0,0 +1,66 @@
+package sep31
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+)
+
+type customerType struct {
+	Description string `json:"description"`
+}
+
+type customerTypes struct {
+	Types map[string]customerType `json:"types"`
+}
+
+type sep12Info struct {
+	Sender   customerTypes `json:"sender"`
+	Receiver customerTypes `json:"receiver"`
+}
+
+type assetInfo struct {
+	QuotesSupported bool        `json:"quotes_supported"`
+	QuotesRequired  bool        `json:"quotes_required"`
+	FeeFixed        json.Number `json:"fee_fixed,omitempty"`
+	FeePercent      json.Number `json:"fee_percent,omitempty"`
+	MinAmount       json.Number `json:"min_amount,omitempty"`
+	MaxAmount       json.Number `json:"max_amount,omitempty"`
+	SEP12           sep12Info   `json:"sep12"`
+}
+
+type infoResponse struct {
+	Receive map[string]assetInfo `json:"receive"`
+}
+
+func (s *Service) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	customers := sep12Info{
+		Sender:   customerTypes{Types: map[string]customerType{CustomerTypeSender: {Description: "Person sending the payment"}}},
+		Receiver: customerTypes{Types: map[string]customerType{CustomerTypeReceiver: {Description: "Person receiving the payment"}}},
+	}
+	out := infoResponse{Receive: map[string]assetInfo{}}
+	for _, asset := range s.Config.Assets {
+		if !asset.ID().IsStellar() || !asset.OperationEnabled(fees.OperationReceive) {
+			continue
+		}
+		info := assetInfo{QuotesSupported: s.Quotes != nil, SEP12: customers}
+		limits := asset.Operation(fees.OperationReceive)
+		if !limits.MinAmount.IsZero() {
+			info.MinAmount = json.Number(limits.MinAmount.String())
+		}
+		if !limits.MaxAmount.IsZero() {
+			info.MaxAmount = json.Number(limits.MaxAmount.String())
+		}
+		if summary, ok := s.Fees.Summary(fees.OperationReceive, asset.ID()); ok {
+			info.FeeFixed = json.Number(summary.Fixed.String())
+			info.FeePercent = json.Number(summary.Percent.String())
+		}
+		out.Receive[asset.Code] = info
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,61 @@
+package sep31
+
+import (
+	"context"
+	"log"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/observer"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+// HandlePayment moves a SEP-31 transaction from pending_sender to
+// pending_receiver once the sending anchor's Stellar payment arrives.
+func (s *Service) HandlePayment(ctx context.Context, p observer.Payment) error {
+	if p.Memo == "" {
+		return nil
+	}
+	tx, ok := s.TxStore.GetByMemo(p.Memo, p.MemoType)
+	if !ok {
+		return nil
+	}
+	if tx.Protocol != transfer.ProtocolSEP31 {
+		return nil
+	}
+	if tx.Status != transfer.StatusPendingSender {
+		log.Printf("sep31: ignoring payment %s for transaction %s in status %s", p.ID, tx.ID, tx.Status)
+		return nil
+	}
+	if p.AssetCode != tx.AssetCode || (tx.AssetIssuer != "" && p.AssetIssuer != tx.AssetIssuer) {
+		log.Printf("sep31: payment %s asset %s does not match transaction %s asset %s", p.ID, p.AssetCode, tx.ID, tx.AssetCode)
+		return nil
+	}
+	received, err := decimal.Parse(p.Amount)
+	if err != nil || received.Sign() <= 0 {
+		log.Printf("sep31: payment %s has invalid amount %q", p.ID, p.Amount)
+		return nil
+	}
+
+	next, reason := transfer.StatusPendingReceiver, "payment received from the sending anchor"
+	if !received.Equal(tx.Amount) {
+		log.Printf("sep31: payment %s amount %s does not match transaction %s amount %s", p.ID, p.Amount, tx.ID, tx.Amount)
+		next, reason = transfer.StatusError, "payment amount does not match the transaction amount"
+	}
+	if err := transfer.ValidateTransition(tx.Status, next); err != nil {
+		return err
+	}
+
+	now := s.Now()
+	tx.Status = next
+	tx.UpdatedAt = now
+	tx.UserActionRequiredBy = s.actionDeadline(next, now)
+	tx.StellarTransactionID = p.TransactionHash
+	tx.AmountIn = received
+	tx, err = s.TxStore.Update(tx, db.Audit{Actor: p.From, Source: db.SourceObserver, Reason: reason})
+	if err != nil {
+		return err
+	}
+	s.Notify(tx)
+	return nil
+}
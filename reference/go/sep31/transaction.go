@@ -0,0 +1,223 @@
+package sep31
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+	"github.com/stellar/sep-reference/reference/go/sep12"
+)
+
+type TransactionRequest struct {
+	Amount           string `json:"amount"`
+	AssetCode        string `json:"asset_code"`
+	AssetIssuer      string `json:"asset_issuer,omitempty"`
+	DestinationAsset string `json:"destination_asset,omitempty"`
+	QuoteID          string `json:"quote_id,omitempty"`
+	SenderID         string `json:"sender_id"`
+	ReceiverID       string `json:"receiver_id"`
+	FundingMethod    string `json:"funding_method,omitempty"`
+}
+
+type transactionResponse struct {
+	ID               string `json:"id"`
+	StellarAccountID string `json:"stellar_account_id"`
+	StellarMemoType  string `json:"stellar_memo_type"`
+	StellarMemo      string `json:"stellar_memo"`
+}
+
+func (s *Service) handlePostTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	account := middleware.AccountFromContext(r.Context())
+
+	asset, ok := transfer.OperationAsset(s.Config.Assets, req.AssetCode, req.AssetIssuer, fees.OperationReceive)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	amount, err := transfer.ParseAmount(asset, req.Amount)
+	if err != nil || amount.IsZero() {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+	if !s.requireCustomer(w, account, req.SenderID, CustomerTypeSender) ||
+		!s.requireCustomer(w, account, req.ReceiverID, CustomerTypeReceiver) {
+		return
+	}
+
+	now := s.Now()
+	tx := db.Transaction{
+		ID:                   s.NewID(),
+		Protocol:             transfer.ProtocolSEP31,
+		Kind:                 "receive",
+		Status:               transfer.StatusPendingSender,
+		Account:              account,
+		ClientDomain:         middleware.ClientDomainFromContext(r.Context()),
+		SenderID:             req.SenderID,
+		ReceiverID:           req.ReceiverID,
+		AssetCode:            asset.Code,
+		AssetIssuer:          asset.Issuer,
+		Amount:               amount,
+		StartedAt:            now,
+		UpdatedAt:            now,
+		UserActionRequiredBy: s.actionDeadline(transfer.StatusPendingSender, now),
+	}
+	if req.QuoteID != "" {
+		err = transfer.ApplyQuote(s.Quotes, &tx, transfer.QuoteRequest{
+			ID:            req.QuoteID,
+			Context:       transfer.ProtocolSEP31,
+			Asset:         asset,
+			OffchainAsset: req.DestinationAsset,
+			Now:           now,
+		}, s.Config.RoundingMode)
+	} else if err = transfer.CheckLimits(asset, fees.OperationReceive, amount, s.Config.RoundingMode); err == nil {
+		if feeErr := transfer.ApplyFee(s.Fees, &tx, fees.Request{Operation: fees.OperationReceive, Type: req.FundingMethod, Asset: asset.ID()}); feeErr != nil {
+			err = errors.New(transfer.FeeErrorMessage(feeErr))
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := transfer.AssignWithdrawMemo(s.Memos, s.Config, &tx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to allocate memo")
+		return
+	}
+	err = transfer.CreateTransaction(s.TxStore, s.Quotes, tx, db.Audit{Actor: tx.Account, Source: db.SourceAPI, Reason: "payment requested by the sending anchor"})
+	if errors.Is(err, transfer.ErrInvalidQuote) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create transaction")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, transactionResponse{
+		ID:               tx.ID,
+		StellarAccountID: tx.WithdrawAnchorAccount,
+		StellarMemoType:  tx.WithdrawMemoType,
+		StellarMemo:      tx.WithdrawMemo,
+	})
+}
+
+// requireCustomer checks that a SEP-12 customer registered by the sending
+// anchor has been accepted, writing customer_info_needed otherwise.
+func (s *Service) requireCustomer(w http.ResponseWriter, account, id, customerType string) bool {
+	customer, ok := s.Customers.GetByID(id)
+	if id == "" || !ok || customer.Account != account || customer.Status != sep12.StatusAccepted {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "customer_info_needed", "type": customerType})
+		return false
+	}
+	return true
+}
+
+// handleTransaction serves GET /transactions/:id and
+// PUT /transactions/:id/callback.
+func (s *Service) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	id, callback := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/sep31/transactions/"), "/callback")
+	tx, ok := s.TxStore.GetByID(id)
+	if id == "" || !ok || tx.Protocol != transfer.ProtocolSEP31 || tx.Account != middleware.AccountFromContext(r.Context()) {
+		writeError(w, http.StatusNotFound, "transaction not found")
+		return
+	}
+
+	switch {
+	case !callback && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"transaction": s.toSEP31Transaction(tx)})
+	case callback && r.Method == http.MethodPut:
+		var body struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		target, err := url.Parse(body.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			writeError(w, http.StatusBadRequest, "invalid url")
+			return
+		}
+		tx.CallbackURL = target.String()
+		if _, err := s.TxStore.Update(tx, db.Audit{Actor: tx.Account, Source: db.SourceAPI, Reason: "callback url registered"}); err != nil {
+			if errors.Is(err, db.ErrConflict) {
+				writeError(w, http.StatusConflict, "transaction was modified concurrently, retry the request")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to update transaction")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Service) toSEP31Transaction(tx db.Transaction) map[string]any {
+	asset := transfer.TxAsset(s.Config.Assets, tx)
+	assetID := asset.ID().String()
+	out := map[string]any{
+		"id":                 tx.ID,
+		"status":             tx.Status,
+		"amount_in":          s.formatAmount(asset, tx.Amount),
+		"amount_in_asset":    assetID,
+		"stellar_account_id": tx.WithdrawAnchorAccount,
+		"stellar_memo_type":  tx.WithdrawMemoType,
+		"stellar_memo":       tx.WithdrawMemo,
+		"started_at":         tx.StartedAt,
+		"updated_at":         tx.UpdatedAt,
+	}
+	if !tx.AmountOut.IsZero() {
+		outAsset, outID := asset, assetID
+		if tx.AmountOutAsset != "" {
+			outAsset, outID = transfer.AssetForID(s.Config.Assets, tx.AmountOutAsset, asset), tx.AmountOutAsset
+		}
+		out["amount_out"] = s.formatAmount(outAsset, tx.AmountOut)
+		out["amount_out_asset"] = outID
+	}
+	if tx.FeeDetails != nil {
+		feeAsset := transfer.AssetForID(s.Config.Assets, tx.FeeDetails.Asset, asset)
+		out["fee_details"] = transfer.RenderFeeDetails(feeAsset, *tx.FeeDetails, s.Config.RoundingMode)
+	}
+	if tx.QuoteID != "" {
+		out["quote_id"] = tx.QuoteID
+	}
+	if completedAt := transfer.CompletedAt(s.TxStore, tx); !completedAt.IsZero() {
+		out["completed_at"] = completedAt
+	}
+	if tx.StellarTransactionID != "" {
+		out["stellar_transaction_id"] = tx.StellarTransactionID
+	}
+	if tx.ExternalTransactionID != "" {
+		out["external_transaction_id"] = tx.ExternalTransactionID
+	}
+	if tx.Refunds != nil {
+		out["refunds"] = transfer.RenderRefunds(asset, *tx.Refunds, s.Config.RoundingMode)
+	}
+	return out
+}
+
+// Notify queues a SEP-31 transaction for the sending anchor's callback URL.
+func (s *Service) Notify(tx db.Transaction) {
+	if s.Callbacks == nil || tx.CallbackURL == "" || tx.Protocol != transfer.ProtocolSEP31 {
+		return
+	}
+	if err := s.Callbacks.Enqueue(tx.CallbackURL, map[string]any{"transaction": s.toSEP31Transaction(tx)}); err != nil {
+		log.Printf("sep31: queue callback for transaction %s: %v", tx.ID, err)
+	}
+}
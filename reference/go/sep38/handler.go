@@ -0,0 +1,177 @@
+package sep38
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+)
+
+var contexts = map[string]bool{"sep6": true, "sep24": true, "sep31": true}
+
+type Service struct {
+	Config config.Config
+	Quotes db.QuoteStore
+	Rates  RateSource
+	Fees   fees.Calculator
+	Events *events.Publisher
+	Now    func() time.Time
+}
+
+type assetInfo struct {
+	Asset string `json:"asset"`
+}
+
+type indicativePrice struct {
+	Asset    string `json:"asset"`
+	Price    string `json:"price"`
+	Decimals int32  `json:"decimals"`
+}
+
+func NewService(cfg config.Config, quotes db.QuoteStore, rates RateSource) *Service {
+	return &Service{
+		Config: cfg,
+		Quotes: quotes,
+		Rates:  rates,
+		Fees:   fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, fees.RulesFromAssets(cfg.Assets)),
+		Now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Service) RegisterRoutes(mux *http.ServeMux, authMiddleware func(http.Handler) http.Handler) {
+	mux.HandleFunc("/sep38/info", s.handleInfo)
+	mux.HandleFunc("/sep38/prices", s.handlePrices)
+	mux.HandleFunc("/sep38/price", s.handlePrice)
+	mux.Handle("/sep38/quote", authMiddleware(http.HandlerFunc(s.handlePostQuote)))
+	mux.Handle("/sep38/quote/", authMiddleware(http.HandlerFunc(s.handleGetQuote)))
+}
+
+func (s *Service) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	assets := make([]assetInfo, 0, len(s.Config.Assets))
+	for _, asset := range s.Config.Assets {
+		if asset.Enabled {
+			assets = append(assets, assetInfo{Asset: asset.ID().String()})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"assets": assets})
+}
+
+func (s *Service) handlePrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	sellRaw, buyRaw := query.Get("sell_asset"), query.Get("buy_asset")
+	if (sellRaw == "") == (buyRaw == "") {
+		writeError(w, http.StatusBadRequest, "exactly one of sell_asset or buy_asset is required")
+		return
+	}
+
+	selling := sellRaw != ""
+	amountParam, assetRaw := "buy_amount", buyRaw
+	if selling {
+		amountParam, assetRaw = "sell_amount", sellRaw
+	}
+	base, ok := s.asset(assetRaw)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	if _, err := parseAmount(query.Get(amountParam)); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+amountParam)
+		return
+	}
+
+	prices := make([]indicativePrice, 0, len(s.Config.Assets))
+	for _, other := range s.Config.Assets {
+		if !other.Enabled || other.ID() == base.ID() {
+			continue
+		}
+		sell, buy := base, other
+		if !selling {
+			sell, buy = other, base
+		}
+		price, err := s.Rates.Price(sell.ID(), buy.ID())
+		if err != nil {
+			continue
+		}
+		prices = append(prices, indicativePrice{Asset: other.ID().String(), Price: price.String(), Decimals: other.SignificantDecimals})
+	}
+	if selling {
+		writeJSON(w, http.StatusOK, map[string]any{"buy_assets": prices})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sell_assets": prices})
+}
+
+func (s *Service) handlePrice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	req := priceRequest{
+		SellAsset:  query.Get("sell_asset"),
+		BuyAsset:   query.Get("buy_asset"),
+		SellAmount: query.Get("sell_amount"),
+		BuyAmount:  query.Get("buy_amount"),
+		Context:    query.Get("context"),
+	}
+	result, err := s.price(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.renderPrice(result))
+}
+
+func (s *Service) asset(raw string) (config.Asset, bool) {
+	id, err := config.ParseAssetID(raw)
+	if err != nil {
+		return config.Asset{}, false
+	}
+	for _, asset := range s.Config.Assets {
+		if asset.Enabled && asset.ID() == id {
+			return asset, true
+		}
+	}
+	return config.Asset{}, false
+}
+
+func parseAmount(raw string) (decimal.Decimal, error) {
+	amount, err := decimal.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if amount.Sign() <= 0 {
+		return decimal.Zero, errors.New("amount must be positive")
+	}
+	return amount, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func accountFromRequest(r *http.Request) string {
+	return middleware.AccountFromContext(r.Context())
+}
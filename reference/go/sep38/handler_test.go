@@ -0,0 +1,185 @@
+package sep38
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/sep10"
+)
+
+var testIssuer = keypair.MustRandom().Address()
+
+func TestInfoAndPrices(t *testing.T) {
+	_, mux := testServiceAndMux()
+
+	var info struct {
+		Assets []map[string]string `json:"assets"`
+	}
+	getJSON(t, mux, "/sep38/info", "", &info)
+	if len(info.Assets) != 2 || info.Assets[0]["asset"] != "iso4217:USD" || info.Assets[1]["asset"] != usdc().String() {
+		t.Fatalf("unexpected assets: %+v", info.Assets)
+	}
+
+	var prices struct {
+		BuyAssets []indicativePrice `json:"buy_assets"`
+	}
+	getJSON(t, mux, "/sep38/prices?sell_asset=iso4217:USD&sell_amount=100", "", &prices)
+	if len(prices.BuyAssets) != 1 || prices.BuyAssets[0].Asset != usdc().String() || prices.BuyAssets[0].Price != "1.02" || prices.BuyAssets[0].Decimals != 2 {
+		t.Fatalf("unexpected buy assets: %+v", prices.BuyAssets)
+	}
+
+	var sellPrices struct {
+		SellAssets []indicativePrice `json:"sell_assets"`
+	}
+	getJSON(t, mux, "/sep38/prices?buy_asset=iso4217:USD&buy_amount=100", "", &sellPrices)
+	if len(sellPrices.SellAssets) != 1 || sellPrices.SellAssets[0].Price != "0.9803922" {
+		t.Fatalf("unexpected inverse price: %+v", sellPrices.SellAssets)
+	}
+}
+
+func TestPriceAppliesFeeInStellarAsset(t *testing.T) {
+	_, mux := testServiceAndMux()
+
+	var price map[string]any
+	getJSON(t, mux, "/sep38/price?context=sep24&sell_asset=iso4217:USD&buy_asset="+usdc().String()+"&sell_amount=103", "", &price)
+	if price["sell_amount"] != "103.00" || price["buy_amount"] != "99.98" || price["price"] != "1.02" {
+		t.Fatalf("unexpected deposit price: %+v", price)
+	}
+	if fee := price["fee"].(map[string]any); fee["total"] != "1.00" || fee["asset"] != usdc().String() {
+		t.Fatalf("unexpected fee: %+v", price["fee"])
+	}
+
+	getJSON(t, mux, "/sep38/price?context=sep24&sell_asset="+usdc().String()+"&buy_asset=iso4217:USD&buy_amount=100", "", &price)
+	if price["sell_amount"] != "99.04" || price["buy_amount"] != "100.00" {
+		t.Fatalf("unexpected withdraw price: %+v", price)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sep38/price?context=sep12&sell_asset=iso4217:USD&buy_asset="+usdc().String()+"&sell_amount=1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid context to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestCreateAndGetQuote(t *testing.T) {
+	service, mux := testServiceAndMux()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.Now = func() time.Time { return now }
+	account := keypair.MustRandom().Address()
+	token := testToken(t, account)
+
+	body, _ := json.Marshal(map[string]any{
+		"context":     "sep24",
+		"sell_asset":  "iso4217:USD",
+		"buy_asset":   usdc().String(),
+		"sell_amount": "103",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/sep38/quote", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected quote creation to require auth, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/sep38/quote", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var created map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode quote: %v", err)
+	}
+	if created["buy_amount"] != "99.98" || created["expires_at"] != now.Add(5*time.Minute).Format(time.RFC3339) {
+		t.Fatalf("unexpected quote: %+v", created)
+	}
+
+	var fetched map[string]any
+	getJSON(t, mux, "/sep38/quote/"+created["id"].(string), token, &fetched)
+	if fetched["id"] != created["id"] || fetched["total_price"] != created["total_price"] {
+		t.Fatalf("unexpected fetched quote: %+v", fetched)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/sep38/quote/"+created["id"].(string), nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, keypair.MustRandom().Address()))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other account to get 404, got %d", rec.Code)
+	}
+
+	body, _ = json.Marshal(map[string]any{
+		"context":      "sep24",
+		"sell_asset":   "iso4217:USD",
+		"buy_asset":    usdc().String(),
+		"sell_amount":  "103",
+		"expire_after": now.Add(48 * time.Hour),
+	})
+	req = httptest.NewRequest(http.MethodPost, "/sep38/quote", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected distant expire_after to be rejected, got %d", rec.Code)
+	}
+}
+
+func usdc() config.AssetID {
+	return config.AssetID{Scheme: config.SchemeStellar, Code: "USDC", Issuer: testIssuer}
+}
+
+func getJSON(t *testing.T, mux *http.ServeMux, path, token string, out any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d body=%s", path, rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		t.Fatalf("GET %s: decode: %v", path, err)
+	}
+}
+
+func testToken(t *testing.T, account string) string {
+	t.Helper()
+	token, err := sep10.IssueToken(account, "localhost:8080", "", "localhost:8080", "jwt-secret", time.Now().UTC(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func testServiceAndMux() (*Service, *http.ServeMux) {
+	cfg := config.Config{
+		HomeDomain: "localhost:8080",
+		JWTSecret:  "jwt-secret",
+		QuoteTTL:   5 * time.Minute,
+		Assets: []config.Asset{
+			{Scheme: config.SchemeISO4217, Code: "USD", Enabled: true, SignificantDecimals: 2},
+			{Code: "USDC", Issuer: testIssuer, Enabled: true, SignificantDecimals: 2, FeeFixed: decimal.MustParse("1")},
+		},
+	}
+	rates := NewStaticRateSource([]config.QuoteRate{
+		{Sell: config.AssetID{Scheme: config.SchemeISO4217, Code: "USD"}, Buy: usdc(), Price: decimal.MustParse("1.02")},
+	})
+	service := NewService(cfg, db.NewMemoryQuoteStore(), rates)
+
+	mux := http.NewServeMux()
+	service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	return service, mux
+}
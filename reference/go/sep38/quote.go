@@ -0,0 +1,305 @@
+package sep38
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/events"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+)
+
+const maxQuoteLifetime = 24 * time.Hour
+
+type priceRequest struct {
+	SellAsset          string `json:"sell_asset"`
+	BuyAsset           string `json:"buy_asset"`
+	SellAmount         string `json:"sell_amount,omitempty"`
+	BuyAmount          string `json:"buy_amount,omitempty"`
+	SellDeliveryMethod string `json:"sell_delivery_method,omitempty"`
+	BuyDeliveryMethod  string `json:"buy_delivery_method,omitempty"`
+	CountryCode        string `json:"country_code,omitempty"`
+	Context            string `json:"context"`
+}
+
+type quoteRequest struct {
+	priceRequest
+	ExpireAfter *time.Time `json:"expire_after,omitempty"`
+}
+
+type priceResult struct {
+	Sell       config.Asset
+	Buy        config.Asset
+	SellAmount decimal.Decimal
+	BuyAmount  decimal.Decimal
+	Price      decimal.Decimal
+	TotalPrice decimal.Decimal
+	Fee        db.FeeDetails
+}
+
+func (s *Service) handlePostQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	defer r.Body.Close()
+
+	account := accountFromRequest(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+	var req quoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json request")
+		return
+	}
+
+	result, err := s.price(req.priceRequest)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := s.Now()
+	expiresAt := now.Add(s.Config.QuoteTTL)
+	if req.ExpireAfter != nil && req.ExpireAfter.After(expiresAt) {
+		if req.ExpireAfter.After(now.Add(maxQuoteLifetime)) {
+			writeError(w, http.StatusBadRequest, "expire_after is too far in the future")
+			return
+		}
+		expiresAt = req.ExpireAfter.UTC()
+	}
+
+	id, err := newQuoteID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create quote")
+		return
+	}
+	quote := db.Quote{
+		ID:                 id,
+		Account:            account,
+		Context:            req.Context,
+		SellAsset:          result.Sell.ID().String(),
+		SellAmount:         result.SellAmount,
+		SellDeliveryMethod: req.SellDeliveryMethod,
+		BuyAsset:           result.Buy.ID().String(),
+		BuyAmount:          result.BuyAmount,
+		BuyDeliveryMethod:  req.BuyDeliveryMethod,
+		CountryCode:        req.CountryCode,
+		Price:              result.Price,
+		TotalPrice:         result.TotalPrice,
+		Fee:                result.Fee,
+		CreatedAt:          now,
+		ExpiresAt:          expiresAt,
+	}
+	if err := s.Quotes.Create(quote); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create quote")
+		return
+	}
+	if s.Events != nil {
+		if _, err := s.Events.Emit(events.TypeQuoteCreated, map[string]any{"quote": s.renderQuote(quote)}); err != nil {
+			log.Printf("sep38: emit quote_created for quote %s: %v", quote.ID, err)
+		}
+	}
+	writeJSON(w, http.StatusCreated, s.renderQuote(quote))
+}
+
+func (s *Service) handleGetQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	account := accountFromRequest(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/sep38/quote/")
+	quote, ok := s.Quotes.GetByID(id)
+	if id == "" || !ok || quote.Account != account {
+		writeError(w, http.StatusNotFound, "quote not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.renderQuote(quote))
+}
+
+func (s *Service) price(req priceRequest) (priceResult, error) {
+	if !contexts[req.Context] {
+		return priceResult{}, errors.New("context must be one of sep6, sep24 or sep31")
+	}
+	sell, ok := s.asset(req.SellAsset)
+	if !ok {
+		return priceResult{}, errors.New("unsupported sell_asset")
+	}
+	buy, ok := s.asset(req.BuyAsset)
+	if !ok {
+		return priceResult{}, errors.New("unsupported buy_asset")
+	}
+	if (req.SellAmount == "") == (req.BuyAmount == "") {
+		return priceResult{}, errors.New("exactly one of sell_amount or buy_amount is required")
+	}
+	price, err := s.Rates.Price(sell.ID(), buy.ID())
+	if err != nil {
+		return priceResult{}, err
+	}
+
+	feeAsset, operation := sell, ""
+	switch {
+	case buy.ID().IsStellar() && !sell.ID().IsStellar():
+		feeAsset, operation = buy, fees.OperationDeposit
+	case sell.ID().IsStellar() && !buy.ID().IsStellar():
+		operation = fees.OperationWithdraw
+		if req.Context == "sep31" {
+			operation = fees.OperationReceive
+		}
+	}
+	feeInBuy := feeAsset.ID() == buy.ID()
+	mode := s.Config.RoundingMode
+
+	result := priceResult{Sell: sell, Buy: buy, Price: price}
+	if req.SellAmount != "" {
+		sellAmount, err := parseAmount(req.SellAmount)
+		if err != nil {
+			return priceResult{}, errors.New("invalid sell_amount")
+		}
+		gross, err := sellAmount.Quo(price, buy.SignificantDecimals, mode)
+		if err != nil {
+			return priceResult{}, err
+		}
+		base := sellAmount
+		if feeInBuy {
+			base = gross
+		}
+		fee, err := s.fee(operation, feeAsset, base)
+		if err != nil {
+			return priceResult{}, err
+		}
+		result.SellAmount = sellAmount.Round(sell.SignificantDecimals, mode)
+		result.Fee = fee
+		result.BuyAmount = gross.Sub(fee.Total)
+		if !feeInBuy {
+			if result.BuyAmount, err = sellAmount.Sub(fee.Total).Quo(price, buy.SignificantDecimals, mode); err != nil {
+				return priceResult{}, err
+			}
+		}
+	} else {
+		buyAmount, err := parseAmount(req.BuyAmount)
+		if err != nil {
+			return priceResult{}, errors.New("invalid buy_amount")
+		}
+		base := buyAmount
+		if !feeInBuy {
+			base = buyAmount.Mul(price)
+		}
+		fee, err := s.fee(operation, feeAsset, base)
+		if err != nil {
+			return priceResult{}, err
+		}
+		result.BuyAmount = buyAmount.Round(buy.SignificantDecimals, mode)
+		result.Fee = fee
+		if feeInBuy {
+			result.SellAmount = result.BuyAmount.Add(fee.Total).Mul(price).Round(sell.SignificantDecimals, mode)
+		} else {
+			result.SellAmount = result.BuyAmount.Mul(price).Add(fee.Total).Round(sell.SignificantDecimals, mode)
+		}
+	}
+	if result.BuyAmount.Sign() <= 0 || result.SellAmount.Sign() <= 0 {
+		return priceResult{}, errors.New("amount does not cover the fee")
+	}
+	result.TotalPrice, err = result.SellAmount.Quo(result.BuyAmount, priceDecimals, mode)
+	if err != nil {
+		return priceResult{}, err
+	}
+	return result, nil
+}
+
+func (s *Service) fee(operation string, asset config.Asset, amount decimal.Decimal) (db.FeeDetails, error) {
+	details := db.FeeDetails{Total: decimal.Zero, Asset: asset.ID().String()}
+	if operation == "" || s.Fees == nil {
+		return details, nil
+	}
+	result, err := s.Fees.Calculate(fees.Request{Operation: operation, Asset: asset.ID(), Amount: amount})
+	if errors.Is(err, fees.ErrNoMatchingRule) {
+		return details, nil
+	}
+	if err != nil {
+		return details, fmt.Errorf("calculate fee: %w", err)
+	}
+	details.Total = result.Total
+	for _, detail := range result.Details {
+		details.Details = append(details.Details, db.FeeDetail{Name: detail.Name, Description: detail.Description, Amount: detail.Amount})
+	}
+	return details, nil
+}
+
+func (s *Service) renderPrice(result priceResult) map[string]any {
+	return map[string]any{
+		"total_price": result.TotalPrice.String(),
+		"price":       result.Price.String(),
+		"sell_amount": s.formatAmount(result.Sell, result.SellAmount),
+		"buy_amount":  s.formatAmount(result.Buy, result.BuyAmount),
+		"fee":         s.renderFee(result.Fee),
+	}
+}
+
+func (s *Service) renderQuote(quote db.Quote) map[string]any {
+	sell, _ := s.asset(quote.SellAsset)
+	buy, _ := s.asset(quote.BuyAsset)
+	return map[string]any{
+		"id":          quote.ID,
+		"expires_at":  quote.ExpiresAt,
+		"total_price": quote.TotalPrice.String(),
+		"price":       quote.Price.String(),
+		"sell_asset":  quote.SellAsset,
+		"sell_amount": s.formatAmount(sell, quote.SellAmount),
+		"buy_asset":   quote.BuyAsset,
+		"buy_amount":  s.formatAmount(buy, quote.BuyAmount),
+		"fee":         s.renderFee(quote.Fee),
+	}
+}
+
+func (s *Service) renderFee(fee db.FeeDetails) map[string]any {
+	asset, _ := s.asset(fee.Asset)
+	out := map[string]any{
+		"total": s.formatAmount(asset, fee.Total),
+		"asset": fee.Asset,
+	}
+	if len(fee.Details) > 0 {
+		details := make([]map[string]string, 0, len(fee.Details))
+		for _, detail := range fee.Details {
+			item := map[string]string{"name": detail.Name, "amount": s.formatAmount(asset, detail.Amount)}
+			if detail.Description != "" {
+				item["description"] = detail.Description
+			}
+			details = append(details, item)
+		}
+		out["details"] = details
+	}
+	return out
+}
+
+func (s *Service) formatAmount(asset config.Asset, value decimal.Decimal) string {
+	decimals := asset.SignificantDecimals
+	if asset.Code == "" {
+		decimals = config.DefaultSignificantDecimals
+	}
+	return value.StringFixed(decimals, s.Config.RoundingMode)
+}
+
+func newQuoteID() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,53 @@
+package sep38
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+)
+
+const priceDecimals int32 = 7
+
+var ErrNoRate = errors.New("no rate available for asset pair")
+
+// RateSource returns the price of one unit of buy in terms of sell, before fees.
+type RateSource interface {
+	Price(sell, buy config.AssetID) (decimal.Decimal, error)
+}
+
+type StaticRateSource struct {
+	mu    sync.RWMutex
+	rates map[string]decimal.Decimal
+}
+
+func NewStaticRateSource(rates []config.QuoteRate) *StaticRateSource {
+	source := &StaticRateSource{rates: map[string]decimal.Decimal{}}
+	for _, rate := range rates {
+		source.Set(rate.Sell, rate.Buy, rate.Price)
+	}
+	return source
+}
+
+func (s *StaticRateSource) Set(sell, buy config.AssetID, price decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[pairKey(sell, buy)] = price
+}
+
+func (s *StaticRateSource) Price(sell, buy config.AssetID) (decimal.Decimal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if price, ok := s.rates[pairKey(sell, buy)]; ok {
+		return price, nil
+	}
+	if inverse, ok := s.rates[pairKey(buy, sell)]; ok && inverse.Sign() > 0 {
+		return decimal.New(1, 0).Quo(inverse, priceDecimals, decimal.RoundHalfEven)
+	}
+	return decimal.Zero, ErrNoRate
+}
+
+func pairKey(sell, buy config.AssetID) string {
+	return sell.String() + "/" + buy.String()
+}
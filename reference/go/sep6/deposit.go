@@ -0,0 +1,186 @@
+package sep6
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+type depositResponse struct {
+	ID        string      `json:"id"`
+	How       string      `json:"how"`
+	MinAmount json.Number `json:"min_amount,omitempty"`
+	MaxAmount json.Number `json:"max_amount,omitempty"`
+}
+
+func (s *Service) handleDeposit(w http.ResponseWriter, r *http.Request) {
+	s.deposit(w, r, false)
+}
+
+func (s *Service) handleDepositExchange(w http.ResponseWriter, r *http.Request) {
+	s.deposit(w, r, true)
+}
+
+func (s *Service) deposit(w http.ResponseWriter, r *http.Request, exchange bool) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	account, customerMemo := subject(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+	if raw := query.Get("account"); raw != "" && raw != account {
+		writeError(w, http.StatusForbidden, "account mismatch")
+		return
+	}
+	if query.Get("memo") != "" {
+		writeError(w, http.StatusBadRequest, "deposit memos are not supported")
+		return
+	}
+
+	param := "asset_code"
+	if exchange {
+		param = "destination_asset"
+	}
+	code := query.Get(param)
+	if code == "" {
+		writeError(w, http.StatusBadRequest, "missing "+param)
+		return
+	}
+	asset, ok := transfer.OperationAsset(s.Config.Assets, code, query.Get("asset_issuer"), "deposit")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	amount, err := transfer.ParseAmount(asset, query.Get("amount"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+	source := query.Get("source_asset")
+	if exchange {
+		if err := validateExchange(source, amount.IsZero(), query.Get("quote_id")); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if err := transfer.CheckLimits(asset, "deposit", amount, s.Config.RoundingMode); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !s.requireCustomer(w, account, customerMemo) {
+		return
+	}
+
+	now := s.Now()
+	id := s.NewID()
+	tx := db.Transaction{
+		ID:                        id,
+		Protocol:                  transfer.ProtocolSEP6,
+		Kind:                      "deposit",
+		Status:                    transfer.StatusPendingUserTransferStart,
+		Account:                   account,
+		To:                        account,
+		AssetCode:                 asset.Code,
+		AssetIssuer:               asset.Issuer,
+		Amount:                    amount,
+		StartedAt:                 now,
+		UpdatedAt:                 now,
+		UserActionRequiredBy:      s.actionDeadline(transfer.StatusPendingUserTransferStart, now),
+		ClaimableBalanceSupported: strings.EqualFold(query.Get("claimable_balance_supported"), "true"),
+	}
+	if !s.priceTransaction(w, &tx, query, asset, source, fees.OperationDeposit, exchange) {
+		return
+	}
+	err = transfer.CreateTransaction(s.TxStore, s.Quotes, tx, db.Audit{Actor: account, Source: db.SourceAPI, Reason: "deposit requested"})
+	if errors.Is(err, transfer.ErrInvalidQuote) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create transaction")
+		return
+	}
+
+	min, max := limitInfo(asset, "deposit")
+	writeJSON(w, http.StatusOK, depositResponse{
+		ID:        id,
+		How:       fmt.Sprintf("Send the deposit with reference %s", id),
+		MinAmount: min,
+		MaxAmount: max,
+	})
+}
+
+// validateExchange checks the parameters shared by /deposit-exchange and
+// /withdraw-exchange. Exchanges are only priced through SEP-38 quotes.
+func validateExchange(offchain string, noAmount bool, quoteID string) error {
+	if offchain == "" || noAmount {
+		return fmt.Errorf("asset and amount are required for exchanges")
+	}
+	if _, err := config.ParseAssetID(offchain); err != nil {
+		return fmt.Errorf("invalid off-chain asset: %v", err)
+	}
+	if quoteID == "" {
+		return fmt.Errorf("quote_id is required")
+	}
+	return nil
+}
+
+// priceTransaction applies the SEP-38 quote of an exchange, or the fee rules
+// otherwise, writing a 400 when neither applies.
+func (s *Service) priceTransaction(w http.ResponseWriter, tx *db.Transaction, query url.Values, asset config.Asset, offchain, operation string, exchange bool) bool {
+	if exchange {
+		err := transfer.ApplyQuote(s.Quotes, tx, transfer.QuoteRequest{
+			ID:            query.Get("quote_id"),
+			Context:       transfer.ProtocolSEP6,
+			Asset:         asset,
+			OffchainAsset: offchain,
+			Now:           tx.StartedAt,
+		}, s.Config.RoundingMode)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return false
+		}
+		return true
+	}
+	err := transfer.ApplyFee(s.Fees, tx, fees.Request{
+		Operation: operation,
+		Type:      fundingMethod(query),
+		Asset:     asset.ID(),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, transfer.FeeErrorMessage(err))
+		return false
+	}
+	return true
+}
+
+// fundingMethod reads funding_method, falling back to the deprecated type.
+func fundingMethod(query url.Values) string {
+	if method := query.Get("funding_method"); method != "" {
+		return method
+	}
+	return query.Get("type")
+}
+
+func limitInfo(asset config.Asset, kind string) (json.Number, json.Number) {
+	limits := asset.Operation(kind)
+	var min, max json.Number
+	if !limits.MinAmount.IsZero() {
+		min = json.Number(limits.MinAmount.String())
+	}
+	if !limits.MaxAmount.IsZero() {
+		max = json.Number(limits.MaxAmount.String())
+	}
+	return min, max
+}
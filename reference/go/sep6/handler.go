@@ -0,0 +1,106 @@
+package sep6
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+	"github.com/stellar/sep-reference/reference/go/sep12"
+)
+
+// CustomerInfo reports a customer's SEP-12 status and the SEP-9 fields that
+// still block it. *sep12.Service implements it.
+type CustomerInfo interface {
+	Requirements(account, memo string) (string, []string)
+}
+
+type Service struct {
+	Config    config.Config
+	TxStore   db.TransactionStore
+	Customers CustomerInfo
+	Quotes    db.QuoteStore
+	Memos     memo.Allocator
+	Fees      fees.Calculator
+	Now       func() time.Time
+	NewID     func() string
+}
+
+func NewService(cfg config.Config, txStore db.TransactionStore, customers CustomerInfo) *Service {
+	return &Service{
+		Config:    cfg,
+		TxStore:   txStore,
+		Customers: customers,
+		Memos:     transfer.NewMemoAllocator(cfg, txStore),
+		Fees:      fees.NewRulesCalculator(cfg.Assets, cfg.RoundingMode, fees.RulesFromAssets(cfg.Assets)),
+		Now:       func() time.Time { return time.Now().UTC() },
+		NewID:     transfer.NewUUID,
+	}
+}
+
+func (s *Service) RegisterRoutes(mux *http.ServeMux, authMiddleware func(http.Handler) http.Handler) {
+	mux.HandleFunc("/sep6/info", s.handleInfo)
+	mux.Handle("/sep6/deposit", authMiddleware(http.HandlerFunc(s.handleDeposit)))
+	mux.Handle("/sep6/deposit-exchange", authMiddleware(http.HandlerFunc(s.handleDepositExchange)))
+	mux.Handle("/sep6/withdraw", authMiddleware(http.HandlerFunc(s.handleWithdraw)))
+	mux.Handle("/sep6/withdraw-exchange", authMiddleware(http.HandlerFunc(s.handleWithdrawExchange)))
+	mux.Handle("/sep6/transaction", authMiddleware(http.HandlerFunc(s.handleGetTransaction)))
+	mux.Handle("/sep6/transactions", authMiddleware(http.HandlerFunc(s.handleListTransactions)))
+	mux.Handle("/sep6/fee", authMiddleware(http.HandlerFunc(s.handleGetFee)))
+}
+
+// requireCustomer writes the SEP-6 KYC response and returns false unless the
+// customer has been accepted.
+func (s *Service) requireCustomer(w http.ResponseWriter, account, customerMemo string) bool {
+	if s.Customers == nil {
+		return true
+	}
+	status, fields := s.Customers.Requirements(account, customerMemo)
+	switch status {
+	case sep12.StatusAccepted:
+		return true
+	case sep12.StatusProcessing:
+		writeJSON(w, http.StatusForbidden, map[string]string{"type": "customer_info_status", "status": "pending"})
+	case sep12.StatusRejected:
+		writeJSON(w, http.StatusForbidden, map[string]string{"type": "customer_info_status", "status": "denied"})
+	default:
+		writeJSON(w, http.StatusForbidden, map[string]any{"type": "non_interactive_customer_info_needed", "fields": fields})
+	}
+	return false
+}
+
+// subject splits the SEP-10 subject into the Stellar account and the memo
+// that identifies a shared-account customer.
+func subject(r *http.Request) (string, string) {
+	account, customerMemo, _ := strings.Cut(middleware.AccountFromContext(r.Context()), ":")
+	return account, customerMemo
+}
+
+func (s *Service) actionDeadline(status string, from time.Time) time.Time {
+	timeout, ok := s.Config.ExpireAfter[status]
+	if !ok || timeout <= 0 {
+		return time.Time{}
+	}
+	return from.Add(timeout)
+}
+
+func (s *Service) formatAmount(asset config.Asset, value decimal.Decimal) string {
+	return transfer.FormatAmount(asset, value, s.Config.RoundingMode)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
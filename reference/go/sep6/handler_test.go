@@ -0,0 +1,252 @@
+package sep6
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/memo"
+	"github.com/stellar/sep-reference/reference/go/internal/middleware"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+	"github.com/stellar/sep-reference/reference/go/sep10"
+	"github.com/stellar/sep-reference/reference/go/sep12"
+)
+
+const testAccount = "GTESTACCOUNTAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+type fakeCustomers map[string]string
+
+func (f fakeCustomers) Requirements(account, _ string) (string, []string) {
+	status, ok := f[account]
+	if !ok {
+		return sep12.StatusNeedsInfo, []string{"first_name", "last_name", "email_address"}
+	}
+	return status, nil
+}
+
+func TestDepositRequiresCustomerInfo(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+	customers := service.Customers.(fakeCustomers)
+
+	var body map[string]any
+	get(t, mux, token, "/sep6/deposit?asset_code=USDC&amount=10", http.StatusForbidden, &body)
+	if body["type"] != "non_interactive_customer_info_needed" || len(body["fields"].([]any)) != 3 {
+		t.Fatalf("expected customer info request, got %+v", body)
+	}
+
+	customers[testAccount] = sep12.StatusProcessing
+	get(t, mux, token, "/sep6/withdraw?asset_code=USDC&amount=10&type=bank_account", http.StatusForbidden, &body)
+	if body["type"] != "customer_info_status" || body["status"] != "pending" {
+		t.Fatalf("expected pending customer status, got %+v", body)
+	}
+
+	customers[testAccount] = sep12.StatusRejected
+	get(t, mux, token, "/sep6/deposit?asset_code=USDC&amount=10", http.StatusForbidden, &body)
+	if body["status"] != "denied" {
+		t.Fatalf("expected denied customer status, got %+v", body)
+	}
+	if got := service.TxStore.ListByAccount(db.TransactionQuery{Account: testAccount}); len(got) != 0 {
+		t.Fatalf("expected no transactions before KYC, got %d", len(got))
+	}
+}
+
+func TestDepositAndWithdraw(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Customers.(fakeCustomers)[testAccount] = sep12.StatusAccepted
+	token := testToken(t, testAccount)
+
+	get(t, mux, token, "/sep6/deposit?asset_code=USDC&amount=0.5", http.StatusBadRequest, nil)
+	get(t, mux, token, "/sep6/deposit?asset_code=USDC&amount=10&account=GOTHER", http.StatusForbidden, nil)
+
+	var deposit depositResponse
+	get(t, mux, token, "/sep6/deposit?asset_code=USDC&amount=100", http.StatusOK, &deposit)
+	if deposit.ID == "" || deposit.How == "" || deposit.MinAmount != "1" {
+		t.Fatalf("unexpected deposit response: %+v", deposit)
+	}
+
+	var withdraw withdrawResponse
+	get(t, mux, token, "/sep6/withdraw?asset_code=USDC&amount=50&type=bank_account&dest=DE89370400440532013000", http.StatusOK, &withdraw)
+	if withdraw.AccountID != service.Config.DistributionAccount || withdraw.MemoType != memo.TypeID || withdraw.Memo == "" {
+		t.Fatalf("unexpected withdraw response: %+v", withdraw)
+	}
+
+	var found struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	get(t, mux, token, "/sep6/transaction?id="+deposit.ID, http.StatusOK, &found)
+	tx := found.Transaction
+	if tx["kind"] != "deposit" || tx["status"] != transfer.StatusPendingUserTransferStart || tx["amount_in"] != "100.00" || tx["amount_fee"] != "1.10" || tx["amount_out"] != "98.90" {
+		t.Fatalf("unexpected deposit transaction: %+v", tx)
+	}
+	get(t, mux, testToken(t, "GOTHERACCOUNT"), "/sep6/transaction?id="+deposit.ID, http.StatusNotFound, nil)
+
+	// SEP-24 transactions share the store but are not listed by SEP-6.
+	if err := service.TxStore.Create(db.Transaction{ID: "sep24-tx", Protocol: transfer.ProtocolSEP24, Kind: "deposit", Account: testAccount, AssetCode: "USDC", Status: transfer.StatusIncomplete}, db.Audit{Source: db.SourceAdmin}); err != nil {
+		t.Fatalf("create sep24 transaction: %v", err)
+	}
+	var listed struct {
+		Transactions []map[string]any `json:"transactions"`
+	}
+	get(t, mux, token, "/sep6/transactions?asset_code=USDC", http.StatusOK, &listed)
+	if len(listed.Transactions) != 2 {
+		t.Fatalf("expected 2 SEP-6 transactions, got %+v", listed.Transactions)
+	}
+	get(t, mux, token, "/sep6/transactions?asset_code=USDC&kind=withdrawal", http.StatusOK, &listed)
+	if len(listed.Transactions) != 1 || listed.Transactions[0]["withdraw_memo"] != withdraw.Memo {
+		t.Fatalf("expected the withdrawal only, got %+v", listed.Transactions)
+	}
+	get(t, mux, token, "/sep6/transaction?id=sep24-tx", http.StatusNotFound, nil)
+}
+
+func TestListTransactionsCapsLimitAndFiltersAcrossPages(t *testing.T) {
+	service, mux := testServiceAndMux()
+	token := testToken(t, testAccount)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []db.Transaction{{ID: "tx-withdraw", Account: testAccount, Protocol: transfer.ProtocolSEP6, Kind: "withdraw", AssetCode: "USDC", StartedAt: start}}
+	for i := range transfer.MaxListLimit + 5 {
+		seed = append(seed, db.Transaction{ID: fmt.Sprintf("tx-%03d", i), Account: testAccount, Protocol: transfer.ProtocolSEP6, Kind: "deposit", AssetCode: "USDC", StartedAt: start.Add(time.Duration(i+1) * time.Second)})
+	}
+	for _, tx := range seed {
+		if err := service.TxStore.Create(tx, db.Audit{Source: db.SourceAdmin}); err != nil {
+			t.Fatalf("create transaction: %v", err)
+		}
+	}
+
+	var listed struct {
+		Transactions []map[string]any `json:"transactions"`
+	}
+	for _, query := range []string{"", "&limit=100000"} {
+		get(t, mux, token, "/sep6/transactions?asset_code=USDC"+query, http.StatusOK, &listed)
+		if len(listed.Transactions) != transfer.MaxListLimit {
+			t.Fatalf("%q: expected the page to be capped at %d, got %d", query, transfer.MaxListLimit, len(listed.Transactions))
+		}
+	}
+	// The only withdrawal is the oldest, several store pages down.
+	get(t, mux, token, "/sep6/transactions?asset_code=USDC&kind=withdrawal&limit=10", http.StatusOK, &listed)
+	if len(listed.Transactions) != 1 || listed.Transactions[0]["id"] != "tx-withdraw" {
+		t.Fatalf("expected the withdrawal, got %+v", listed.Transactions)
+	}
+}
+
+func TestDepositExchangeUsesQuote(t *testing.T) {
+	service, mux := testServiceAndMux()
+	service.Customers.(fakeCustomers)[testAccount] = sep12.StatusAccepted
+	token := testToken(t, testAccount)
+	now := time.Now().UTC()
+	quote := db.Quote{
+		ID:         "quote-1",
+		Account:    testAccount,
+		Context:    transfer.ProtocolSEP6,
+		SellAsset:  "iso4217:BRL",
+		SellAmount: decimal.MustParse("500"),
+		BuyAsset:   "stellar:USDC",
+		BuyAmount:  decimal.MustParse("99"),
+		Price:      decimal.MustParse("5"),
+		TotalPrice: decimal.MustParse("5.0505050"),
+		Fee:        db.FeeDetails{Total: decimal.MustParse("1"), Asset: "stellar:USDC"},
+		ExpiresAt:  now.Add(time.Minute),
+	}
+	sep24Quote := quote
+	sep24Quote.ID, sep24Quote.Context = "quote-sep24", transfer.ProtocolSEP24
+	for _, q := range []db.Quote{quote, sep24Quote} {
+		if err := service.Quotes.Create(q); err != nil {
+			t.Fatalf("create quote: %v", err)
+		}
+	}
+
+	get(t, mux, token, "/sep6/deposit-exchange?destination_asset=USDC&source_asset=iso4217:BRL&amount=500", http.StatusBadRequest, nil)
+	get(t, mux, token, "/sep6/deposit-exchange?destination_asset=USDC&source_asset=iso4217:BRL&amount=500&quote_id=quote-sep24", http.StatusBadRequest, nil)
+	get(t, mux, token, "/sep6/deposit-exchange?destination_asset=USDC&source_asset=iso4217:EUR&amount=500&quote_id=quote-1", http.StatusBadRequest, nil)
+
+	var deposit depositResponse
+	get(t, mux, token, "/sep6/deposit-exchange?destination_asset=USDC&source_asset=iso4217:BRL&amount=500&quote_id=quote-1", http.StatusOK, &deposit)
+	var found struct {
+		Transaction map[string]any `json:"transaction"`
+	}
+	get(t, mux, token, "/sep6/transaction?id="+deposit.ID, http.StatusOK, &found)
+	tx := found.Transaction
+	if tx["kind"] != "deposit-exchange" || tx["quote_id"] != "quote-1" || tx["amount_in_asset"] != "iso4217:BRL" || tx["amount_out"] != "99.00" {
+		t.Fatalf("unexpected exchange transaction: %+v", tx)
+	}
+	if bound, _ := service.Quotes.GetByID("quote-1"); bound.TransactionID != deposit.ID {
+		t.Fatalf("expected quote to be bound to %s, got %q", deposit.ID, bound.TransactionID)
+	}
+	get(t, mux, token, "/sep6/deposit-exchange?destination_asset=USDC&source_asset=iso4217:BRL&amount=500&quote_id=quote-1", http.StatusBadRequest, nil)
+}
+
+func TestInfoAndFee(t *testing.T) {
+	_, mux := testServiceAndMux()
+	var info infoResponse
+	get(t, mux, "", "/sep6/info", http.StatusOK, &info)
+	usdc := info.Deposit["USDC"]
+	if !usdc.Enabled || !usdc.AuthenticationRequired || usdc.FeeFixed != "1.0" || usdc.MinAmount != "1" {
+		t.Fatalf("unexpected deposit info: %+v", usdc)
+	}
+	if !info.DepositExchange["USDC"].Enabled || !info.Transactions.Enabled || !info.Features.ClaimableBalances {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	get(t, mux, "", "/sep6/fee?operation=deposit&asset_code=USDC&amount=100", http.StatusForbidden, nil)
+	var fee map[string]string
+	get(t, mux, testToken(t, testAccount), "/sep6/fee?operation=deposit&asset_code=USDC&amount=100", http.StatusOK, &fee)
+	if fee["fee"] != "1.10" {
+		t.Fatalf("expected fee 1.10, got %+v", fee)
+	}
+}
+
+func get(t *testing.T, mux *http.ServeMux, token, path string, want int, out any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != want {
+		t.Fatalf("%s: expected %d, got %d body=%s", path, want, rec.Code, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+}
+
+func testToken(t *testing.T, account string) string {
+	t.Helper()
+	token, err := sep10.IssueToken(account, "localhost:8080", "", "localhost:8080", "jwt-secret", time.Now().UTC(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func testServiceAndMux() (*Service, *http.ServeMux) {
+	cfg := config.Config{
+		HomeDomain:          "localhost:8080",
+		JWTSecret:           "jwt-secret",
+		DistributionAccount: "GDISTRIBUTIONAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		WithdrawMemoType:    memo.TypeID,
+		Assets: []config.Asset{{
+			Code:                "USDC",
+			Enabled:             true,
+			SignificantDecimals: 2,
+			FeeFixed:            decimal.MustParse("1.0"),
+			FeePercent:          decimal.MustParse("0.1"),
+			Deposit:             config.AssetOperation{MinAmount: decimal.MustParse("1")},
+		}},
+	}
+	service := NewService(cfg, db.NewMemoryTransactionStore(), fakeCustomers{})
+	service.Quotes = db.NewMemoryQuoteStore()
+
+	mux := http.NewServeMux()
+	service.RegisterRoutes(mux, middleware.SEP10Auth(cfg.JWTSecret))
+	return service, mux
+}
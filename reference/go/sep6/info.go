@@ -0,0 +1,130 @@
+package sep6
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/config"
+	"github.com/stellar/sep-reference/reference/go/internal/decimal"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+type assetInfo struct {
+	Enabled                bool        `json:"enabled"`
+	AuthenticationRequired bool        `json:"authentication_required,omitempty"`
+	AssetIssuer            string      `json:"asset_issuer,omitempty"`
+	FeeFixed               json.Number `json:"fee_fixed,omitempty"`
+	FeePercent             json.Number `json:"fee_percent,omitempty"`
+	MinAmount              json.Number `json:"min_amount,omitempty"`
+	MaxAmount              json.Number `json:"max_amount,omitempty"`
+}
+
+type endpointInfo struct {
+	Enabled                bool `json:"enabled"`
+	AuthenticationRequired bool `json:"authentication_required"`
+}
+
+type featureInfo struct {
+	AccountCreation   bool `json:"account_creation"`
+	ClaimableBalances bool `json:"claimable_balances"`
+}
+
+type infoResponse struct {
+	Deposit          map[string]assetInfo `json:"deposit"`
+	DepositExchange  map[string]assetInfo `json:"deposit-exchange"`
+	Withdraw         map[string]assetInfo `json:"withdraw"`
+	WithdrawExchange map[string]assetInfo `json:"withdraw-exchange"`
+	Fee              endpointInfo         `json:"fee"`
+	Transactions     endpointInfo         `json:"transactions"`
+	Transaction      endpointInfo         `json:"transaction"`
+	Features         featureInfo          `json:"features"`
+}
+
+func (s *Service) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	authenticated := endpointInfo{Enabled: true, AuthenticationRequired: true}
+	out := infoResponse{
+		Deposit:          map[string]assetInfo{},
+		DepositExchange:  map[string]assetInfo{},
+		Withdraw:         map[string]assetInfo{},
+		WithdrawExchange: map[string]assetInfo{},
+		Fee:              authenticated,
+		Transactions:     authenticated,
+		Transaction:      authenticated,
+		Features:         featureInfo{AccountCreation: false, ClaimableBalances: true},
+	}
+	for _, asset := range s.Config.Assets {
+		if !asset.ID().IsStellar() {
+			continue
+		}
+		deposit := s.assetInfo(asset, fees.OperationDeposit)
+		withdraw := s.assetInfo(asset, fees.OperationWithdraw)
+		out.Deposit[asset.Code] = deposit
+		out.Withdraw[asset.Code] = withdraw
+		// Exchange fees come from the SEP-38 quote.
+		out.DepositExchange[asset.Code] = assetInfo{Enabled: deposit.Enabled && s.Quotes != nil, AuthenticationRequired: deposit.AuthenticationRequired, AssetIssuer: deposit.AssetIssuer}
+		out.WithdrawExchange[asset.Code] = assetInfo{Enabled: withdraw.Enabled && s.Quotes != nil, AuthenticationRequired: withdraw.AuthenticationRequired, AssetIssuer: withdraw.AssetIssuer}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Service) assetInfo(asset config.Asset, operation string) assetInfo {
+	if !asset.Enabled || !asset.OperationEnabled(operation) {
+		return assetInfo{Enabled: false}
+	}
+	info := assetInfo{Enabled: true, AuthenticationRequired: true, AssetIssuer: asset.Issuer}
+	info.MinAmount, info.MaxAmount = limitInfo(asset, operation)
+	if summary, ok := s.Fees.Summary(operation, asset.ID()); ok {
+		info.FeeFixed = json.Number(summary.Fixed.String())
+		info.FeePercent = json.Number(summary.Percent.String())
+	}
+	return info
+}
+
+func (s *Service) handleGetFee(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	operation := query.Get("operation")
+	if operation == "" || query.Get("asset_code") == "" || query.Get("amount") == "" {
+		writeError(w, http.StatusBadRequest, "missing required query params")
+		return
+	}
+	if operation != fees.OperationDeposit && operation != fees.OperationWithdraw {
+		writeError(w, http.StatusBadRequest, "invalid operation")
+		return
+	}
+	asset, ok := transfer.OperationAsset(s.Config.Assets, query.Get("asset_code"), query.Get("asset_issuer"), operation)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	amount, err := decimal.Parse(query.Get("amount"))
+	if err != nil || amount.Sign() < 0 {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+
+	result, err := s.Fees.Calculate(fees.Request{
+		Operation: operation,
+		Type:      fundingMethod(query),
+		Asset:     asset.ID(),
+		Amount:    amount,
+	})
+	if errors.Is(err, fees.ErrNoMatchingRule) {
+		writeError(w, http.StatusBadRequest, "fee is not available for this request")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to calculate fee")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"fee": s.formatAmount(asset, result.Total)})
+}
@@ -0,0 +1,212 @@
+package sep6
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+var kinds = map[string]bool{"deposit": true, "deposit-exchange": true, "withdrawal": true, "withdrawal-exchange": true}
+
+func (s *Service) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	account, _ := subject(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+
+	query := r.URL.Query()
+	id := query.Get("id")
+	externalID := query.Get("external_transaction_id")
+	stellarID := query.Get("stellar_transaction_id")
+	if id == "" && externalID == "" && stellarID == "" {
+		writeError(w, http.StatusBadRequest, "missing transaction identifier")
+		return
+	}
+	tx, err := transfer.FindTransaction(s.TxStore, account, transfer.ProtocolSEP6, transfer.Lookup{ID: id, ExternalTransactionID: externalID, StellarTransactionID: stellarID})
+	if errors.Is(err, transfer.ErrConflictingIdentifiers) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, "transaction not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": s.toSEP6Transaction(tx)})
+}
+
+func (s *Service) handleListTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	account, _ := subject(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+
+	query := r.URL.Query()
+	assetCode := strings.TrimSpace(query.Get("asset_code"))
+	if assetCode == "" {
+		writeError(w, http.StatusBadRequest, "missing asset_code")
+		return
+	}
+	limit := transfer.MaxListLimit
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, transfer.MaxListLimit)
+	}
+	kindFilter := map[string]bool{}
+	if raw := strings.TrimSpace(query.Get("kind")); raw != "" {
+		for _, kind := range strings.Split(raw, ",") {
+			if !kinds[kind] {
+				writeError(w, http.StatusBadRequest, "invalid kind")
+				return
+			}
+			kindFilter[kind] = true
+		}
+	}
+	var noOlderThan time.Time
+	if raw := strings.TrimSpace(query.Get("no_older_than")); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid no_older_than")
+			return
+		}
+		noOlderThan = parsed
+	}
+
+	pagingID := strings.TrimSpace(query.Get("paging_id"))
+	if pagingID != "" {
+		if _, err := transfer.FindTransaction(s.TxStore, account, transfer.ProtocolSEP6, transfer.Lookup{ID: pagingID}); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid paging_id")
+			return
+		}
+	}
+
+	// Exchange kinds depend on the quote, so the kind filter stays here.
+	storeQuery := db.TransactionQuery{
+		Account:     account,
+		Protocol:    transfer.ProtocolSEP6,
+		AssetCode:   assetCode,
+		NoOlderThan: noOlderThan,
+		Cursor:      pagingID,
+		Limit:       limit,
+	}
+	transactions := make([]map[string]any, 0)
+	for len(transactions) < limit {
+		page := s.TxStore.ListByAccount(storeQuery)
+		for _, tx := range page {
+			if len(kindFilter) > 0 && !kindFilter[sep6Kind(tx)] {
+				continue
+			}
+			transactions = append(transactions, s.toSEP6Transaction(tx))
+			if len(transactions) == limit {
+				break
+			}
+		}
+		if len(page) < storeQuery.Limit {
+			break
+		}
+		storeQuery.Cursor = page[len(page)-1].ID
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transactions": transactions})
+}
+
+// sep6Kind reports the SEP-6 kind; exchanges are the transactions priced by
+// a SEP-38 quote.
+func sep6Kind(tx db.Transaction) string {
+	kind := "deposit"
+	if tx.Kind == "withdraw" || tx.Kind == "withdrawal" {
+		kind = "withdrawal"
+	}
+	if tx.QuoteID != "" {
+		kind += "-exchange"
+	}
+	return kind
+}
+
+func (s *Service) toSEP6Transaction(tx db.Transaction) map[string]any {
+	asset := transfer.TxAsset(s.Config.Assets, tx)
+	assetID := asset.ID().String()
+	out := map[string]any{
+		"id":         tx.ID,
+		"kind":       sep6Kind(tx),
+		"status":     tx.Status,
+		"started_at": tx.StartedAt,
+		"updated_at": tx.UpdatedAt,
+	}
+	if !tx.Amount.IsZero() {
+		inAsset, inID := asset, assetID
+		if tx.AmountInAsset != "" {
+			inAsset, inID = transfer.AssetForID(s.Config.Assets, tx.AmountInAsset, asset), tx.AmountInAsset
+		}
+		out["amount_in"] = s.formatAmount(inAsset, tx.Amount)
+		out["amount_in_asset"] = inID
+	}
+	if !tx.AmountIn.IsZero() {
+		out["amount_in"] = s.formatAmount(asset, tx.AmountIn)
+	}
+	if !tx.AmountOut.IsZero() {
+		outAsset, outID := asset, assetID
+		if tx.AmountOutAsset != "" {
+			outAsset, outID = transfer.AssetForID(s.Config.Assets, tx.AmountOutAsset, asset), tx.AmountOutAsset
+		}
+		out["amount_out"] = s.formatAmount(outAsset, tx.AmountOut)
+		out["amount_out_asset"] = outID
+	}
+	if tx.FeeDetails != nil {
+		feeAsset := transfer.AssetForID(s.Config.Assets, tx.FeeDetails.Asset, asset)
+		out["amount_fee"] = s.formatAmount(feeAsset, tx.FeeDetails.Total)
+		out["amount_fee_asset"] = tx.FeeDetails.Asset
+		out["fee_details"] = transfer.RenderFeeDetails(feeAsset, *tx.FeeDetails, s.Config.RoundingMode)
+	}
+	if tx.QuoteID != "" {
+		out["quote_id"] = tx.QuoteID
+	}
+	if tx.From != "" {
+		out["from"] = tx.From
+	}
+	if tx.To != "" {
+		out["to"] = tx.To
+	}
+	if !tx.UserActionRequiredBy.IsZero() {
+		out["user_action_required_by"] = tx.UserActionRequiredBy
+	}
+	if tx.StellarTransactionID != "" {
+		out["stellar_transaction_id"] = tx.StellarTransactionID
+	}
+	if tx.ExternalTransactionID != "" {
+		out["external_transaction_id"] = tx.ExternalTransactionID
+	}
+	if tx.ClaimableBalanceID != "" {
+		out["claimable_balance_id"] = tx.ClaimableBalanceID
+	}
+	if tx.WithdrawAnchorAccount != "" {
+		out["withdraw_anchor_account"] = tx.WithdrawAnchorAccount
+		out["withdraw_memo"] = tx.WithdrawMemo
+		out["withdraw_memo_type"] = tx.WithdrawMemoType
+	}
+	if tx.Refunds != nil {
+		out["refunds"] = transfer.RenderRefunds(asset, *tx.Refunds, s.Config.RoundingMode)
+		out["refunded"] = tx.Status == transfer.StatusRefunded
+	}
+	if tx.Status == transfer.StatusPendingCustomerInfoUpdate && len(tx.KYCFields) > 0 {
+		out["required_customer_info_updates"] = tx.KYCFields
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package sep6
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/sep-reference/reference/go/internal/db"
+	"github.com/stellar/sep-reference/reference/go/internal/fees"
+	"github.com/stellar/sep-reference/reference/go/internal/transfer"
+)
+
+type withdrawResponse struct {
+	AccountID string      `json:"account_id"`
+	MemoType  string      `json:"memo_type"`
+	Memo      string      `json:"memo"`
+	ID        string      `json:"id"`
+	MinAmount json.Number `json:"min_amount,omitempty"`
+	MaxAmount json.Number `json:"max_amount,omitempty"`
+}
+
+func (s *Service) handleWithdraw(w http.ResponseWriter, r *http.Request) {
+	s.withdraw(w, r, false)
+}
+
+func (s *Service) handleWithdrawExchange(w http.ResponseWriter, r *http.Request) {
+	s.withdraw(w, r, true)
+}
+
+func (s *Service) withdraw(w http.ResponseWriter, r *http.Request, exchange bool) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+	account, customerMemo := subject(r)
+	if account == "" {
+		writeError(w, http.StatusForbidden, "missing subject")
+		return
+	}
+	if raw := query.Get("account"); raw != "" && raw != account {
+		writeError(w, http.StatusForbidden, "account mismatch")
+		return
+	}
+
+	param := "asset_code"
+	if exchange {
+		param = "source_asset"
+	}
+	code := query.Get(param)
+	if code == "" {
+		writeError(w, http.StatusBadRequest, "missing "+param)
+		return
+	}
+	asset, ok := transfer.OperationAsset(s.Config.Assets, code, query.Get("asset_issuer"), "withdraw")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unsupported asset")
+		return
+	}
+	amount, err := transfer.ParseAmount(asset, query.Get("amount"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid amount")
+		return
+	}
+	destination := query.Get("destination_asset")
+	if exchange {
+		if err := validateExchange(destination, amount.IsZero(), query.Get("quote_id")); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if err := transfer.CheckLimits(asset, "withdraw", amount, s.Config.RoundingMode); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !s.requireCustomer(w, account, customerMemo) {
+		return
+	}
+
+	now := s.Now()
+	id := s.NewID()
+	tx := db.Transaction{
+		ID:                   id,
+		Protocol:             transfer.ProtocolSEP6,
+		Kind:                 "withdraw",
+		Status:               transfer.StatusPendingUserTransferStart,
+		Account:              account,
+		From:                 account,
+		To:                   query.Get("dest"),
+		AssetCode:            asset.Code,
+		AssetIssuer:          asset.Issuer,
+		Amount:               amount,
+		StartedAt:            now,
+		UpdatedAt:            now,
+		UserActionRequiredBy: s.actionDeadline(transfer.StatusPendingUserTransferStart, now),
+	}
+	if !s.priceTransaction(w, &tx, query, asset, destination, fees.OperationWithdraw, exchange) {
+		return
+	}
+	if err := transfer.AssignWithdrawMemo(s.Memos, s.Config, &tx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to allocate withdraw memo")
+		return
+	}
+	err = transfer.CreateTransaction(s.TxStore, s.Quotes, tx, db.Audit{Actor: account, Source: db.SourceAPI, Reason: "withdrawal requested"})
+	if errors.Is(err, transfer.ErrInvalidQuote) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create transaction")
+		return
+	}
+
+	min, max := limitInfo(asset, "withdraw")
+	writeJSON(w, http.StatusOK, withdrawResponse{
+		AccountID: tx.WithdrawAnchorAccount,
+		MemoType:  tx.WithdrawMemoType,
+		Memo:      tx.WithdrawMemo,
+		ID:        id,
+		MinAmount: min,
+		MaxAmount: max,
+	})
+}
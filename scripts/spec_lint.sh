@@ -2,25 +2,39 @@
 set -euo pipefail
 
 required_files=(
+  "specs/sep6/openapi.yaml"
+  "specs/sep6/ai-spec.md"
+  "specs/sep6/test-vectors.json"
   "specs/sep10/openapi.yaml"
   "specs/sep24/openapi.yaml"
   "specs/sep10/ai-spec.md"
   "specs/sep24/ai-spec.md"
   "specs/sep10/test-vectors.json"
   "specs/sep24/test-vectors.json"
+  "specs/sep12/openapi.yaml"
+  "specs/sep12/ai-spec.md"
+  "specs/sep12/test-vectors.json"
+  "specs/sep31/openapi.yaml"
+  "specs/sep31/ai-spec.md"
+  "specs/sep31/test-vectors.json"
+  "specs/sep38/openapi.yaml"
+  "specs/sep38/ai-spec.md"
+  "specs/sep38/test-vectors.json"
+  "specs/platform/openapi.yaml"
+  "specs/platform/ai-spec.md"
 )
 
 for f in "${required_files[@]}"; do
   [[ -f "$f" ]] || { echo "missing required spec file: $f"; exit 1; }
 done
 
-for f in specs/sep10/openapi.yaml specs/sep24/openapi.yaml; do
+for f in specs/sep6/openapi.yaml specs/sep10/openapi.yaml specs/sep12/openapi.yaml specs/sep24/openapi.yaml specs/sep31/openapi.yaml specs/sep38/openapi.yaml specs/platform/openapi.yaml; do
   grep -q '^openapi: 3.1.0' "$f" || { echo "$f must declare OpenAPI 3.1.0"; exit 1; }
   grep -q '^paths:' "$f" || { echo "$f missing paths section"; exit 1; }
   grep -q '^components:' "$f" || { echo "$f missing components section"; exit 1; }
 done
 
-for f in specs/sep10/test-vectors.json specs/sep24/test-vectors.json; do
+for f in specs/sep6/test-vectors.json specs/sep10/test-vectors.json specs/sep12/test-vectors.json specs/sep24/test-vectors.json specs/sep31/test-vectors.json specs/sep38/test-vectors.json specs/shared/sep9-fields.json; do
   python3 -m json.tool "$f" >/dev/null
 done
 
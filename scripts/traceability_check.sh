@@ -3,7 +3,11 @@ set -euo pipefail
 
 matrices=(
   "specs/traceability/sep1-sep10-matrix.md"
+  "specs/traceability/sep6-matrix.md"
+  "specs/traceability/sep12-matrix.md"
   "specs/traceability/sep24-matrix.md"
+  "specs/traceability/sep31-matrix.md"
+  "specs/traceability/sep38-matrix.md"
 )
 
 for f in "${matrices[@]}"; do